@@ -0,0 +1,67 @@
+package fft
+
+import (
+	"math"
+)
+
+// bluestein computes transforms of arbitrary length n as a circular
+// convolution of power-of-two length m >= 2n-1 (Bluestein's chirp-z algorithm):
+//
+// X[k] = w[k] * sum_j (x[j] * w[j]) * conj(w[k-j]), with w[k] = exp(-i*pi*k^2/n).
+type bluestein struct {
+	n      int
+	chirp  []complex128 // w[k], k = 0..n-1
+	filter []complex128 // forward transform of the conjugate chirp, scaled by 1/m
+	plan   *Plan        // power-of-two plan of length m
+	work   []complex128
+}
+
+func newBluestein(n int) *bluestein {
+	m := 1
+	for m < 2*n-1 {
+		m *= 2
+	}
+	b := &bluestein{n: n, plan: NewPlan(m)}
+	b.chirp = make([]complex128, n)
+	for k := 0; k < n; k++ {
+		// reduce k^2 modulo 2n to keep the angle accurate for large k
+		kk := (int64(k) * int64(k)) % int64(2*n)
+		b.chirp[k] = expi(-math.Pi * float64(kk) / float64(n))
+	}
+
+	b.filter = make([]complex128, m)
+	scale := complex(1/float64(m), 0)
+	b.filter[0] = scale
+	for k := 1; k < n; k++ {
+		c := complex(real(b.chirp[k]), -imag(b.chirp[k])) * scale
+		b.filter[k] = c
+		b.filter[m-k] = c
+	}
+	b.plan.Forward(b.filter, b.filter)
+	b.work = make([]complex128, m)
+	return b
+}
+
+func (b *bluestein) clone() *bluestein {
+	c := *b
+	c.plan = b.plan.Clone()
+	c.work = make([]complex128, len(b.work))
+	return &c
+}
+
+func (b *bluestein) transform(dst, src []complex128) {
+	for k, v := range src {
+		b.work[k] = v * b.chirp[k]
+	}
+	for k := b.n; k < len(b.work); k++ {
+		b.work[k] = 0
+	}
+	b.plan.Forward(b.work, b.work)
+	for k, v := range b.filter {
+		b.work[k] *= v
+	}
+	b.plan.Inverse(b.work, b.work)
+	for k := range dst {
+		dst[k] = b.work[k] * b.chirp[k]
+	}
+}
@@ -0,0 +1,338 @@
+// Package fft implements fast Fourier transforms of complex and real data
+// in pure Go.
+//
+// Transforms are computed through plans that are created once for a given
+// length and can then be executed any number of times. Lengths whose prime
+// factors are small are handled by a mixed-radix Stockham algorithm with
+// specialized butterflies for radices 2, 3, 4 and 5; lengths with a large
+// prime factor fall back to Bluestein's chirp-z algorithm, so every length
+// runs in O(n log n).
+//
+// Like FFTW, the transforms are unnormalized: Inverse(Forward(x)) returns
+// n*x.
+package fft
+
+import (
+	"math"
+)
+
+// maxRadix is the largest prime factor handled by the generic butterfly.
+// Lengths with a larger prime factor use Bluestein's algorithm.
+const maxRadix = 31
+
+// A Plan computes discrete Fourier transforms of complex data of a fixed length.
+// A Plan holds workspace and is not safe for concurrent use;
+// use Clone to obtain an independent copy for each goroutine.
+type Plan struct {
+	n      int
+	stages []stage
+	blue   *bluestein
+	work   []complex128 // ping-pong buffer of length n
+	gen    []complex128 // scratch for the generic butterfly
+}
+
+// A stage is one radix pass of the Stockham algorithm.
+type stage struct {
+	radix  int
+	m      int          // length of the sub-transforms produced by this pass
+	stride int          // number of interleaved transforms
+	tw     []complex128 // tw[q*(radix-1)+k-1] = exp(-2*pi*i*q*k/(m*radix))
+	roots  []complex128 // exp(-2*pi*i*j/radix), generic radices only
+}
+
+// NewPlan returns a Plan for complex transforms of length n.
+func NewPlan(n int) *Plan {
+	if n < 1 {
+		panic("fft: transform length must be positive")
+	}
+	p := &Plan{n: n}
+	factors := factorize(n)
+	if len(factors) == 0 {
+		return p
+	}
+	if factors[len(factors)-1] > maxRadix {
+		p.blue = newBluestein(n)
+		return p
+	}
+
+	p.work = make([]complex128, n)
+	p.gen = make([]complex128, factors[len(factors)-1])
+	length, stride := n, 1
+	for _, radix := range factors {
+		m := length / radix
+		st := stage{radix: radix, m: m, stride: stride}
+		st.tw = make([]complex128, m*(radix-1))
+		for q := 0; q < m; q++ {
+			for k := 1; k < radix; k++ {
+				st.tw[q*(radix-1)+k-1] = expi(-2 * math.Pi * float64((q*k)%length) / float64(length))
+			}
+		}
+		if radix > 5 {
+			st.roots = make([]complex128, radix)
+			for j := 0; j < radix; j++ {
+				st.roots[j] = expi(-2 * math.Pi * float64(j) / float64(radix))
+			}
+		}
+		p.stages = append(p.stages, st)
+		length = m
+		stride *= radix
+	}
+	return p
+}
+
+// Len returns the transform length of the plan.
+func (p *Plan) Len() int {
+	return p.n
+}
+
+// Clone returns a copy of the plan that shares its precomputed tables
+// but has its own workspace, so that the two can be used concurrently.
+func (p *Plan) Clone() *Plan {
+	c := &Plan{n: p.n, stages: p.stages}
+	if p.blue != nil {
+		c.blue = p.blue.clone()
+		return c
+	}
+	c.work = make([]complex128, len(p.work))
+	c.gen = make([]complex128, len(p.gen))
+	return c
+}
+
+// Forward computes the discrete Fourier transform
+//
+// dst[k] = sum_j src[j] * exp(-2*pi*i*j*k/n)
+//
+// and returns dst. If dst is nil a new slice is allocated.
+// dst and src may be the same slice.
+func (p *Plan) Forward(dst, src []complex128) []complex128 {
+	dst = p.check(dst, src)
+	if p.blue != nil {
+		p.blue.transform(dst, src)
+		return dst
+	}
+	p.transform(dst, src)
+	return dst
+}
+
+// Inverse computes the unnormalized inverse discrete Fourier transform
+//
+// dst[j] = sum_k src[k] * exp(2*pi*i*j*k/n)
+//
+// and returns dst. If dst is nil a new slice is allocated.
+// dst and src may be the same slice.
+func (p *Plan) Inverse(dst, src []complex128) []complex128 {
+	dst = p.check(dst, src)
+	// ifft(x) = conj(fft(conj(x)))
+	for i, v := range src {
+		dst[i] = complex(real(v), -imag(v))
+	}
+	p.Forward(dst, dst)
+	for i, v := range dst {
+		dst[i] = complex(real(v), -imag(v))
+	}
+	return dst
+}
+
+func (p *Plan) check(dst, src []complex128) []complex128 {
+	if len(src) != p.n {
+		panic("fft: source length does not match the plan")
+	}
+	if dst == nil {
+		dst = make([]complex128, p.n)
+	}
+	if len(dst) != p.n {
+		panic("fft: destination length does not match the plan")
+	}
+	return dst
+}
+
+// transform runs the Stockham passes, alternating between dst and the
+// workspace so that the last pass writes into dst.
+func (p *Plan) transform(dst, src []complex128) {
+	if len(p.stages) == 0 {
+		dst[0] = src[0]
+		return
+	}
+	bufs := [2][]complex128{dst, p.work}
+	last := len(p.stages) - 1
+	x := src
+	if last%2 == 0 && &src[0] == &dst[0] {
+		copy(p.work, src)
+		x = p.work
+	}
+	for i := range p.stages {
+		y := bufs[(last-i)%2]
+		p.pass(&p.stages[i], y, x)
+		x = y
+	}
+}
+
+// pass computes one radix pass from x into y.
+func (p *Plan) pass(st *stage, y, x []complex128) {
+	m, s := st.m, st.stride
+	switch st.radix {
+	case 2:
+		for q := 0; q < m; q++ {
+			w1 := st.tw[q]
+			for j := 0; j < s; j++ {
+				a0 := x[j+s*q]
+				a1 := x[j+s*(q+m)]
+				o := j + s*2*q
+				y[o] = a0 + a1
+				y[o+s] = (a0 - a1) * w1
+			}
+		}
+	case 3:
+		const sin60 = 0.86602540378443864676
+		for q := 0; q < m; q++ {
+			w1, w2 := st.tw[2*q], st.tw[2*q+1]
+			for j := 0; j < s; j++ {
+				a0 := x[j+s*q]
+				a1 := x[j+s*(q+m)]
+				a2 := x[j+s*(q+2*m)]
+				t0 := a1 + a2
+				t1 := a0 - 0.5*t0
+				d := a1 - a2
+				t2 := complex(sin60*imag(d), -sin60*real(d)) // -i*sin60*d
+				o := j + s*3*q
+				y[o] = a0 + t0
+				y[o+s] = (t1 + t2) * w1
+				y[o+2*s] = (t1 - t2) * w2
+			}
+		}
+	case 4:
+		for q := 0; q < m; q++ {
+			w1, w2, w3 := st.tw[3*q], st.tw[3*q+1], st.tw[3*q+2]
+			for j := 0; j < s; j++ {
+				a0 := x[j+s*q]
+				a1 := x[j+s*(q+m)]
+				a2 := x[j+s*(q+2*m)]
+				a3 := x[j+s*(q+3*m)]
+				t0 := a0 + a2
+				t1 := a0 - a2
+				t2 := a1 + a3
+				d := a1 - a3
+				t3 := complex(imag(d), -real(d)) // -i*d
+				o := j + s*4*q
+				y[o] = t0 + t2
+				y[o+s] = (t1 + t3) * w1
+				y[o+2*s] = (t0 - t2) * w2
+				y[o+3*s] = (t1 - t3) * w3
+			}
+		}
+	case 5:
+		const (
+			c1 = 0.30901699437494742410  // cos(2*pi/5)
+			c2 = -0.80901699437494742410 // cos(4*pi/5)
+			s1 = 0.95105651629515357212  // sin(2*pi/5)
+			s2 = 0.58778525229247312917  // sin(4*pi/5)
+		)
+		for q := 0; q < m; q++ {
+			tw := st.tw[4*q : 4*q+4]
+			for j := 0; j < s; j++ {
+				a0 := x[j+s*q]
+				a1 := x[j+s*(q+m)]
+				a2 := x[j+s*(q+2*m)]
+				a3 := x[j+s*(q+3*m)]
+				a4 := x[j+s*(q+4*m)]
+				u1, u2 := a1+a4, a2+a3
+				v1, v2 := a1-a4, a2-a3
+				r1 := a0 + complex(c1, 0)*u1 + complex(c2, 0)*u2
+				r2 := a0 + complex(c2, 0)*u1 + complex(c1, 0)*u2
+				e1 := complex(s1, 0)*v1 + complex(s2, 0)*v2
+				e2 := complex(s2, 0)*v1 - complex(s1, 0)*v2
+				i1 := complex(imag(e1), -real(e1)) // -i*e1
+				i2 := complex(imag(e2), -real(e2)) // -i*e2
+				o := j + s*5*q
+				y[o] = a0 + u1 + u2
+				y[o+s] = (r1 + i1) * tw[0]
+				y[o+2*s] = (r2 + i2) * tw[1]
+				y[o+3*s] = (r2 - i2) * tw[2]
+				y[o+4*s] = (r1 - i1) * tw[3]
+			}
+		}
+	default:
+		r := st.radix
+		a := p.gen[:r]
+		for q := 0; q < m; q++ {
+			tw := st.tw[q*(r-1) : (q+1)*(r-1)]
+			for j := 0; j < s; j++ {
+				for k := 0; k < r; k++ {
+					a[k] = x[j+s*(q+k*m)]
+				}
+				o := j + s*r*q
+				for k := 0; k < r; k++ {
+					var sum complex128
+					idx := 0
+					for _, v := range a {
+						sum += v * st.roots[idx]
+						idx += k
+						if idx >= r {
+							idx -= r
+						}
+					}
+					if k > 0 {
+						sum *= tw[k-1]
+					}
+					y[o+k*s] = sum
+				}
+			}
+		}
+	}
+}
+
+// factorize returns the radices used for a transform of length n,
+// with the largest prime factor last. It returns nil for n = 1.
+func factorize(n int) []int {
+	var factors []int
+	for n%4 == 0 {
+		factors = append(factors, 4)
+		n /= 4
+	}
+	for _, f := range []int{2, 3, 5} {
+		for n%f == 0 {
+			factors = append(factors, f)
+			n /= f
+		}
+	}
+	for f := 7; f*f <= n; f += 2 {
+		for n%f == 0 {
+			factors = append(factors, f)
+			n /= f
+		}
+	}
+	if n > 1 {
+		factors = append(factors, n)
+	}
+	return factors
+}
+
+// NextFastLen returns the smallest integer >= n of the form 2^a * 3^b * 5^c,
+// which is a length the transforms handle most efficiently.
+// It is typically used to choose a zero-padded length.
+func NextFastLen(n int) int {
+	if n <= 1 {
+		return 1
+	}
+	best := 1
+	for best < n {
+		best *= 2
+	}
+	for p5 := 1; p5 < best; p5 *= 5 {
+		for p35 := p5; p35 < best; p35 *= 3 {
+			l := p35
+			for l < n {
+				l *= 2
+			}
+			if l < best {
+				best = l
+			}
+		}
+	}
+	return best
+}
+
+func expi(theta float64) complex128 {
+	s, c := math.Sincos(theta)
+	return complex(c, s)
+}
@@ -0,0 +1,145 @@
+package fft
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"testing"
+)
+
+var testLengths = []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 12, 15, 16, 17, 25, 30, 31, 37, 49, 64, 97, 100, 121, 128, 210, 243, 360, 1009, 1024, 2018}
+
+func dft(x []complex128, sign float64) []complex128 {
+	n := len(x)
+	y := make([]complex128, n)
+	for k := 0; k < n; k++ {
+		for j := 0; j < n; j++ {
+			y[k] += x[j] * cmplx.Rect(1, sign*2*math.Pi*float64((j*k)%n)/float64(n))
+		}
+	}
+	return y
+}
+
+func maxDiff(a, b []complex128) float64 {
+	d := 0.0
+	for i := range a {
+		d = math.Max(d, cmplx.Abs(a[i]-b[i]))
+	}
+	return d
+}
+
+func TestPlan(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, n := range testLengths {
+		x := make([]complex128, n)
+		for i := range x {
+			x[i] = complex(rng.NormFloat64(), rng.NormFloat64())
+		}
+		tol := 1e-12 * float64(n)
+		p := NewPlan(n)
+
+		expected := dft(x, -1)
+		actual := p.Forward(nil, x)
+		if d := maxDiff(actual, expected); d > tol {
+			t.Errorf("n=%d: forward transform differs from DFT by %g", n, d)
+		}
+
+		expected = dft(x, 1)
+		actual = p.Inverse(nil, x)
+		if d := maxDiff(actual, expected); d > tol {
+			t.Errorf("n=%d: inverse transform differs from DFT by %g", n, d)
+		}
+
+		// in place round trip
+		y := append([]complex128(nil), x...)
+		p.Forward(y, y)
+		p.Inverse(y, y)
+		for i := range y {
+			y[i] /= complex(float64(n), 0)
+		}
+		if d := maxDiff(y, x); d > tol {
+			t.Errorf("n=%d: in-place round trip differs by %g", n, d)
+		}
+	}
+}
+
+func TestRealPlan(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for _, n := range testLengths {
+		x := make([]float64, n)
+		cx := make([]complex128, n)
+		for i := range x {
+			x[i] = rng.NormFloat64()
+			cx[i] = complex(x[i], 0)
+		}
+		tol := 1e-12 * float64(n)
+		p := NewRealPlan(n)
+
+		expected := dft(cx, -1)[:n/2+1]
+		spectrum := p.Forward(nil, x)
+		if len(spectrum) != p.SpectrumLen() {
+			t.Errorf("n=%d: spectrum length %d, expected %d", n, len(spectrum), p.SpectrumLen())
+		}
+		if d := maxDiff(spectrum, expected); d > tol {
+			t.Errorf("n=%d: real forward transform differs from DFT by %g", n, d)
+		}
+
+		y := p.Inverse(nil, spectrum)
+		for i := range y {
+			if d := math.Abs(y[i]/float64(n) - x[i]); d > tol {
+				t.Errorf("n=%d: real round trip differs by %g at %d", n, d, i)
+				break
+			}
+		}
+	}
+}
+
+func TestClone(t *testing.T) {
+	for _, n := range []int{48, 101} {
+		p := NewRealPlan(n)
+		c := p.Clone()
+		x := make([]float64, n)
+		for i := range x {
+			x[i] = float64(i % 7)
+		}
+		a := p.Forward(nil, x)
+		b := c.Forward(nil, x)
+		if d := maxDiff(a, b); d != 0 {
+			t.Errorf("n=%d: clone differs from original by %g", n, d)
+		}
+	}
+}
+
+func TestNextFastLen(t *testing.T) {
+	cases := [][2]int{{0, 1}, {1, 1}, {7, 8}, {11, 12}, {13, 15}, {17, 18}, {97, 100}, {1001, 1024}, {1025, 1080}}
+	for _, c := range cases {
+		if l := NextFastLen(c[0]); l != c[1] {
+			t.Errorf("NextFastLen(%d) = %d, expected %d", c[0], l, c[1])
+		}
+	}
+}
+
+func BenchmarkRealPlan1024(b *testing.B) {
+	benchmarkRealPlan(b, 1024)
+}
+
+func BenchmarkRealPlan1000(b *testing.B) {
+	benchmarkRealPlan(b, 1000)
+}
+
+func BenchmarkRealPlan1009(b *testing.B) {
+	benchmarkRealPlan(b, 1009)
+}
+
+func benchmarkRealPlan(b *testing.B, n int) {
+	x := make([]float64, n)
+	for i := range x {
+		x[i] = rand.Float64()
+	}
+	p := NewRealPlan(n)
+	dst := make([]complex128, p.SpectrumLen())
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.Forward(dst, x)
+	}
+}
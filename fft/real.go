@@ -0,0 +1,157 @@
+package fft
+
+import (
+	"math"
+)
+
+// A RealPlan computes discrete Fourier transforms of real data of a fixed length n.
+// The forward transform returns the n/2+1 non-redundant coefficients of the
+// Hermitian spectrum, and the inverse transform maps such a half spectrum
+// back to n real values.
+//
+// Even lengths are computed with a complex transform of length n/2.
+// A RealPlan holds workspace and is not safe for concurrent use;
+// use Clone to obtain an independent copy for each goroutine.
+type RealPlan struct {
+	n    int
+	plan *Plan        // complex plan of length n/2 (n even) or n (n odd)
+	tw   []complex128 // exp(-2*pi*i*k/n), k = 0..n/2, n even only
+	work []complex128
+}
+
+// NewRealPlan returns a RealPlan for real transforms of length n.
+func NewRealPlan(n int) *RealPlan {
+	if n < 1 {
+		panic("fft: transform length must be positive")
+	}
+	p := &RealPlan{n: n}
+	if n%2 == 1 {
+		p.plan = NewPlan(n)
+		p.work = make([]complex128, n)
+		return p
+	}
+	h := n / 2
+	p.plan = NewPlan(h)
+	p.work = make([]complex128, h)
+	p.tw = make([]complex128, h+1)
+	for k := range p.tw {
+		p.tw[k] = expi(-2 * math.Pi * float64(k) / float64(n))
+	}
+	return p
+}
+
+// Len returns the length of the real data of the plan.
+func (p *RealPlan) Len() int {
+	return p.n
+}
+
+// SpectrumLen returns the number of complex coefficients, n/2+1,
+// produced by Forward and consumed by Inverse.
+func (p *RealPlan) SpectrumLen() int {
+	return p.n/2 + 1
+}
+
+// Clone returns a copy of the plan that shares its precomputed tables
+// but has its own workspace, so that the two can be used concurrently.
+func (p *RealPlan) Clone() *RealPlan {
+	c := *p
+	c.plan = p.plan.Clone()
+	c.work = make([]complex128, len(p.work))
+	return &c
+}
+
+// Forward computes the first n/2+1 coefficients of the discrete Fourier
+// transform of the real sequence src,
+//
+// dst[k] = sum_j src[j] * exp(-2*pi*i*j*k/n),
+//
+// and returns dst. If dst is nil a new slice is allocated.
+func (p *RealPlan) Forward(dst []complex128, src []float64) []complex128 {
+	if len(src) != p.n {
+		panic("fft: source length does not match the plan")
+	}
+	if dst == nil {
+		dst = make([]complex128, p.SpectrumLen())
+	}
+	if len(dst) != p.SpectrumLen() {
+		panic("fft: destination length does not match the plan")
+	}
+
+	if p.n%2 == 1 {
+		for i, v := range src {
+			p.work[i] = complex(v, 0)
+		}
+		p.plan.Forward(p.work, p.work)
+		copy(dst, p.work)
+		return dst
+	}
+
+	// pack even and odd samples as real and imaginary parts.
+	h := p.n / 2
+	for k := 0; k < h; k++ {
+		p.work[k] = complex(src[2*k], src[2*k+1])
+	}
+	z := p.plan.Forward(p.work, p.work)
+	for k := 0; k <= h; k++ {
+		zk := z[k%h]
+		zc := z[(h-k)%h]
+		zc = complex(real(zc), -imag(zc))
+		even := 0.5 * (zk + zc)
+		d := 0.5 * (zk - zc)
+		odd := complex(imag(d), -real(d)) // -i*d
+		dst[k] = even + p.tw[k]*odd
+	}
+	return dst
+}
+
+// Inverse computes the unnormalized inverse transform of the half spectrum src,
+//
+// dst[j] = sum_k X[k] * exp(2*pi*i*j*k/n),
+//
+// where X is the Hermitian extension of src, and returns dst.
+// The imaginary parts of src[0] and, for even n, src[n/2] are ignored.
+// If dst is nil a new slice is allocated.
+func (p *RealPlan) Inverse(dst []float64, src []complex128) []float64 {
+	if len(src) != p.SpectrumLen() {
+		panic("fft: source length does not match the plan")
+	}
+	if dst == nil {
+		dst = make([]float64, p.n)
+	}
+	if len(dst) != p.n {
+		panic("fft: destination length does not match the plan")
+	}
+
+	if p.n%2 == 1 {
+		p.work[0] = complex(real(src[0]), 0)
+		for k := 1; k < len(src); k++ {
+			p.work[k] = src[k]
+			p.work[p.n-k] = complex(real(src[k]), -imag(src[k]))
+		}
+		p.plan.Inverse(p.work, p.work)
+		for i := range dst {
+			dst[i] = real(p.work[i])
+		}
+		return dst
+	}
+
+	h := p.n / 2
+	for k := 0; k < h; k++ {
+		xk := src[k]
+		xc := src[h-k]
+		xc = complex(real(xc), -imag(xc))
+		if k == 0 {
+			xk = complex(real(xk), 0)
+			xc = complex(real(xc), 0)
+		}
+		even := xk + xc
+		d := (xk - xc) * complex(real(p.tw[k]), -imag(p.tw[k]))
+		p.work[k] = even + complex(-imag(d), real(d)) // even + i*d
+	}
+	z := p.plan.Inverse(p.work, p.work)
+	for k := 0; k < h; k++ {
+		dst[2*k] = real(z[k])
+		dst[2*k+1] = imag(z[k])
+	}
+	return dst
+}
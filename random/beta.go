@@ -0,0 +1,119 @@
+//   Copyright (C) 2012 Mingzhi Lin
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS
+// OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package random
+
+import (
+	"github.com/mingzhi/gomath/specfunc"
+	"math"
+	"math/rand"
+)
+
+// A Beta distribution with shape parameters Alpha and Beta.
+// p(x) = x^(Alpha-1) * (1-x)^(Beta-1) / B(Alpha, Beta) for 0 <= x <= 1
+type Beta struct {
+	Alpha float64
+	Beta  float64
+
+	randomGenerator *rand.Rand // random number generator
+}
+
+// NewBeta returns a Beta distribution with the provided shape parameters.
+func NewBeta(alpha, beta float64, src rand.Source) *Beta {
+	if !(alpha > 0) || !(beta > 0) {
+		panic("Illegal argument for Beta distribution: alpha <= 0 or beta <= 0")
+	}
+	b := &Beta{Alpha: alpha, Beta: beta}
+	b.randomGenerator = rand.New(src)
+	return b
+}
+
+// Cdf returns the cumulative distribution function.
+func (b Beta) Cdf(x float64) float64 {
+	switch {
+	case x <= 0:
+		return 0
+	case x >= 1:
+		return 1
+	}
+	return specfunc.RegularizedBeta(x, b.Alpha, b.Beta)
+}
+
+// Float64 returns a random number from the distribution,
+// as X / (X + Y) with X ~ Gamma(Alpha, 1) and Y ~ Gamma(Beta, 1).
+// The ratio is formed from log X and log Y, so that small shapes, for which
+// both X and Y may underflow to zero, still give a number in [0, 1].
+func (b Beta) Float64() float64 {
+	lx := logGammaVariate(b.randomGenerator, b.Alpha)
+	ly := logGammaVariate(b.randomGenerator, b.Beta)
+	return 1 / (1 + math.Exp(ly-lx))
+}
+
+// LogPdf returns the logarithm of the probability distribution function.
+func (b Beta) LogPdf(x float64) float64 {
+	switch {
+	case x < 0 || x > 1:
+		return math.Inf(-1)
+	case x == 0:
+		return betaEdge(b.Alpha, b.Beta)
+	case x == 1:
+		return betaEdge(b.Beta, b.Alpha)
+	}
+	return (b.Alpha-1)*math.Log(x) + (b.Beta-1)*math.Log1p(-x) - specfunc.LogBeta(b.Alpha, b.Beta)
+}
+
+// betaEdge returns the log density at the end of the support where
+// the exponent a-1 vanishes.
+func betaEdge(a, other float64) float64 {
+	switch {
+	case a < 1:
+		return math.Inf(1)
+	case a > 1:
+		return math.Inf(-1)
+	}
+	return -specfunc.LogBeta(1, other)
+}
+
+// Mean returns the mean Alpha / (Alpha + Beta).
+func (b Beta) Mean() float64 {
+	return b.Alpha / (b.Alpha + b.Beta)
+}
+
+// Pdf returns the probability distribution function.
+func (b Beta) Pdf(x float64) float64 {
+	return math.Exp(b.LogPdf(x))
+}
+
+// Quantile returns the inverse of the cumulative distribution function,
+// the x with Cdf(x) = p.
+func (b Beta) Quantile(p float64) float64 {
+	return specfunc.InvRegularizedBeta(p, b.Alpha, b.Beta)
+}
+
+// Seed uses the provided seed value to initialize the generator to a deterministic state.
+func (b *Beta) Seed(seed int64) {
+	b.randomGenerator.Seed(seed)
+}
+
+// Variance returns the variance Alpha*Beta / ((Alpha+Beta)^2 * (Alpha+Beta+1)).
+func (b Beta) Variance() float64 {
+	s := b.Alpha + b.Beta
+	return b.Alpha * b.Beta / (s * s * (s + 1))
+}
@@ -68,9 +68,17 @@ func NewBinomial(n int, p float64, src rand.Source) *Binomial {
 	return binomial
 }
 
-// Cdf returns the cumulative distribution function.
+// Cdf returns the cumulative distribution function,
+// P(X <= k) = I(1-P; N-k, k+1) with the regularized incomplete beta function.
 func (binomial Binomial) Cdf(k int) (p float64) {
-	panic("have not implemented")
+	switch {
+	case k < 0:
+		p = 0
+	case k >= binomial.N:
+		p = 1
+	default:
+		p = specfunc.RegularizedBeta(1-binomial.P, float64(binomial.N-k), float64(k+1))
+	}
 	return
 }
 
@@ -93,11 +101,37 @@ func (binomial *Binomial) Lock(locked bool) {
 
 // Pdf returns the probability distribution function.
 func (binomial Binomial) Pdf(k int) (p float64) {
+	if k < 0 || k > binomial.N {
+		return 0
+	}
 	r := binomial.N - k
 	p = math.Exp(binomial.log_n - specfunc.LogFactorial(k) - specfunc.LogFactorial(r) + binomial.log_p*float64(k) + binomial.log_q*float64(r))
 	return
 }
 
+// Quantile returns the smallest k with Cdf(k) >= p,
+// or -1 if p is not in [0, 1].
+func (binomial *Binomial) Quantile(p float64) int {
+	n := float64(binomial.N)
+	sd := math.Sqrt(n * binomial.P * (1 - binomial.P))
+	skew := (1 - 2*binomial.P) / sd
+	return discreteQuantile(p, 0, binomial.N, n*binomial.P, sd, skew, binomial.Cdf)
+}
+
+// Survival returns the survival function, P(X > k) = 1 - Cdf(k),
+// accurate in the upper tail.
+func (binomial *Binomial) Survival(k int) (p float64) {
+	switch {
+	case k < 0:
+		p = 1
+	case k >= binomial.N:
+		p = 0
+	default:
+		p = specfunc.RegularizedBeta(binomial.P, float64(k+1), float64(binomial.N-k))
+	}
+	return
+}
+
 // Generate a random number from the Binomial distribution.
 // This is a port of Biomial.java from Colt java library, which is based on
 // V. Kachitvichyanukul, B.W. Schmeiser (1988): Binomial random variate generation, Communications of the ACM 31, 216-222.
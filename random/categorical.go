@@ -0,0 +1,244 @@
+//   Copyright (C) 2012 Mingzhi Lin
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS
+// OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package random
+
+import (
+	"math"
+	"math/rand"
+)
+
+// A Categorical distribution on 0, 1, ..., n-1 with probabilities
+// proportional to non-negative weights.
+// p(k) = w[k] / sum(w)
+//
+// Draws take O(1) time from a Walker alias table, built in O(n) time by
+// Vose's method. Weights can be changed one at a time in O(log n) time with
+// SetWeight, which keeps the weights in a Fenwick tree: after a change, draws
+// search the tree in O(log n) time until as many draws as there are
+// categories have been made, and then the alias table is rebuilt, so that
+// the amortized cost of a draw stays O(1) when updates are rare.
+type Categorical struct {
+	weights  []float64
+	positive int // number of categories of positive weight
+
+	randomGenerator *rand.Rand // random number generator
+
+	// alias table: category i is kept with probability prob[i], and
+	// otherwise replaced by alias[i]
+	prob  []float64
+	alias []int
+	stale int // draws since the alias table went out of date, or -1 if up to date
+
+	tree []float64 // Fenwick tree of the weights, 1-based
+}
+
+// NewCategorical returns a Categorical distribution with the provided weights,
+// which are copied.
+func NewCategorical(weights []float64, src rand.Source) *Categorical {
+	if len(weights) == 0 {
+		panic("Illegal argument for Categorical distribution: no weights")
+	}
+	c := &Categorical{weights: append([]float64(nil), weights...)}
+	for _, w := range c.weights {
+		if !(w >= 0) || math.IsInf(w, 1) {
+			panic("Illegal argument for Categorical distribution: weights must be finite and non-negative")
+		}
+		if w > 0 {
+			c.positive++
+		}
+	}
+	if c.positive == 0 {
+		panic("Illegal argument for Categorical distribution: all the weights are zero")
+	}
+	c.randomGenerator = rand.New(src)
+	c.prob = make([]float64, len(weights))
+	c.alias = make([]int, len(weights))
+	c.tree = make([]float64, len(weights)+1)
+	c.rebuild()
+	return c
+}
+
+// rebuild recomputes the Fenwick tree, which also clears the rounding
+// accumulated by updates, and the alias table by Vose's method.
+func (c *Categorical) rebuild() {
+	n := len(c.weights)
+	for i := range c.tree {
+		c.tree[i] = 0
+	}
+	for i, w := range c.weights {
+		j := i + 1
+		c.tree[j] += w
+		if k := j + j&-j; k <= n {
+			c.tree[k] += c.tree[j]
+		}
+	}
+	total := c.Total()
+
+	// scaled probabilities, split into those below and above the average
+	small := make([]int, 0, n)
+	large := make([]int, 0, n)
+	for i, w := range c.weights {
+		c.prob[i] = w * float64(n) / total
+		if c.prob[i] < 1 {
+			small = append(small, i)
+		} else {
+			large = append(large, i)
+		}
+	}
+	for len(small) > 0 && len(large) > 0 {
+		s := small[len(small)-1]
+		small = small[:len(small)-1]
+		l := large[len(large)-1]
+		c.alias[s] = l
+		c.prob[l] -= 1 - c.prob[s]
+		if c.prob[l] < 1 {
+			large = large[:len(large)-1]
+			small = append(small, l)
+		}
+	}
+	// the rest are full up to rounding
+	for _, i := range large {
+		c.prob[i] = 1
+	}
+	for _, i := range small {
+		c.prob[i] = 1
+	}
+	c.stale = -1
+}
+
+// Cdf returns the cumulative distribution function.
+func (c *Categorical) Cdf(k int) (p float64) {
+	switch {
+	case k < 0:
+		return 0
+	case k >= len(c.weights)-1:
+		return 1
+	}
+	s := 0.0
+	for j := k + 1; j > 0; j -= j & -j {
+		s += c.tree[j]
+	}
+	return math.Min(s/c.Total(), 1)
+}
+
+// Int returns a random number from the distribution.
+func (c *Categorical) Int() int {
+	n := len(c.weights)
+	if c.stale < 0 {
+		u := c.randomGenerator.Float64() * float64(n)
+		i := int(u)
+		if u-float64(i) < c.prob[i] {
+			return i
+		}
+		return c.alias[i]
+	}
+	c.stale++
+	if c.stale >= n {
+		c.rebuild()
+		return c.Int()
+	}
+	// descend the Fenwick tree to the first category whose cumulative
+	// weight exceeds u
+	u := c.randomGenerator.Float64() * c.Total()
+	i := 0
+	for step := highBit(n); step > 0; step >>= 1 {
+		if j := i + step; j <= n && c.tree[j] <= u {
+			i = j
+			u -= c.tree[j]
+		}
+	}
+	// rounding left in the tree by updates can land past the end or on a
+	// category of weight zero; rebuilding removes it, and the redraw from
+	// the exact alias table cannot miss
+	if i >= n || c.weights[i] == 0 {
+		c.rebuild()
+		return c.Int()
+	}
+	return i
+}
+
+// highBit returns the largest power of two not above n > 0.
+func highBit(n int) int {
+	b := 1
+	for b <= n/2 {
+		b <<= 1
+	}
+	return b
+}
+
+// Len returns the number of categories.
+func (c *Categorical) Len() int {
+	return len(c.weights)
+}
+
+// Pdf returns the probability distribution function.
+func (c *Categorical) Pdf(k int) (p float64) {
+	if k < 0 || k >= len(c.weights) {
+		return 0
+	}
+	return c.weights[k] / c.Total()
+}
+
+// Seed uses the provided seed value to initialize the generator to a deterministic state.
+func (c *Categorical) Seed(seed int64) {
+	c.randomGenerator.Seed(seed)
+}
+
+// SetWeight changes the weight of category k to w, in O(log n) time.
+// It panics, leaving the distribution unchanged, if w is invalid or would
+// make all the weights zero.
+func (c *Categorical) SetWeight(k int, w float64) {
+	if k < 0 || k >= len(c.weights) {
+		panic("Illegal argument for Categorical distribution: category out of range")
+	}
+	if !(w >= 0) || math.IsInf(w, 1) {
+		panic("Illegal argument for Categorical distribution: weights must be finite and non-negative")
+	}
+	old := c.weights[k]
+	if w == 0 && old > 0 && c.positive == 1 {
+		panic("Illegal argument for Categorical distribution: all the weights are zero")
+	}
+	switch {
+	case w > 0 && old == 0:
+		c.positive++
+	case w == 0 && old > 0:
+		c.positive--
+	}
+	c.weights[k] = w
+	for j := k + 1; j < len(c.tree); j += j & -j {
+		c.tree[j] += w - old
+	}
+	c.stale = 0
+}
+
+// Total returns the sum of the weights.
+func (c *Categorical) Total() float64 {
+	s := 0.0
+	for j := len(c.weights); j > 0; j -= j & -j {
+		s += c.tree[j]
+	}
+	return s
+}
+
+// Weight returns the weight of category k.
+func (c *Categorical) Weight(k int) float64 {
+	return c.weights[k]
+}
@@ -0,0 +1,89 @@
+//   Copyright (C) 2012 Mingzhi Lin
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS
+// OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package random
+
+import (
+	"github.com/mingzhi/gomath/specfunc"
+	"math"
+	"math/rand"
+)
+
+// A ChiSquared distribution with K degrees of freedom,
+// the Gamma distribution with shape K/2 and rate 1/2.
+type ChiSquared struct {
+	K float64 // degrees of freedom
+
+	randomGenerator *rand.Rand // random number generator
+}
+
+// NewChiSquared returns a ChiSquared distribution with k degrees of freedom.
+func NewChiSquared(k float64, src rand.Source) *ChiSquared {
+	if !(k > 0) {
+		panic("Illegal argument for ChiSquared distribution: k <= 0")
+	}
+	chi := &ChiSquared{K: k}
+	chi.randomGenerator = rand.New(src)
+	return chi
+}
+
+// Cdf returns the cumulative distribution function.
+func (chi ChiSquared) Cdf(x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	return specfunc.RegularizedGammaP(chi.K/2, x/2)
+}
+
+// Float64 returns a random number from the distribution.
+func (chi ChiSquared) Float64() float64 {
+	return 2 * gammaVariate(chi.randomGenerator, chi.K/2)
+}
+
+// LogPdf returns the logarithm of the probability distribution function.
+func (chi ChiSquared) LogPdf(x float64) float64 {
+	return gammaLogPdf(chi.K/2, 0.5, x)
+}
+
+// Mean returns the mean K.
+func (chi ChiSquared) Mean() float64 {
+	return chi.K
+}
+
+// Pdf returns the probability distribution function.
+func (chi ChiSquared) Pdf(x float64) float64 {
+	return math.Exp(chi.LogPdf(x))
+}
+
+// Quantile returns the inverse of the cumulative distribution function,
+// the x with Cdf(x) = p.
+func (chi ChiSquared) Quantile(p float64) float64 {
+	return 2 * specfunc.InvRegularizedGammaP(chi.K/2, p)
+}
+
+// Seed uses the provided seed value to initialize the generator to a deterministic state.
+func (chi *ChiSquared) Seed(seed int64) {
+	chi.randomGenerator.Seed(seed)
+}
+
+// Variance returns the variance 2*K.
+func (chi ChiSquared) Variance() float64 {
+	return 2 * chi.K
+}
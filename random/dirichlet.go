@@ -0,0 +1,120 @@
+//   Copyright (C) 2012 Mingzhi Lin
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS
+// OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package random
+
+import (
+	"math"
+	"math/rand"
+)
+
+// A Dirichlet distribution on the probability simplex with concentration
+// parameters Alpha.
+// p(x) = Gamma(sum(Alpha)) / prod(Gamma(Alpha[i])) * prod(x[i]^(Alpha[i]-1))
+type Dirichlet struct {
+	Alpha []float64
+
+	randomGenerator *rand.Rand // random number generator
+}
+
+// NewDirichlet returns a Dirichlet distribution with the provided positive
+// concentration parameters, which are copied.
+func NewDirichlet(alpha []float64, src rand.Source) *Dirichlet {
+	if len(alpha) < 2 {
+		panic("Illegal argument for Dirichlet distribution: fewer than two parameters")
+	}
+	for _, a := range alpha {
+		if !(a > 0) || math.IsInf(a, 1) {
+			panic("Illegal argument for Dirichlet distribution: alpha must be finite and positive")
+		}
+	}
+	d := &Dirichlet{Alpha: append([]float64(nil), alpha...)}
+	d.randomGenerator = rand.New(src)
+	return d
+}
+
+// Float64s returns a random point of the simplex from the distribution,
+// as independent Gamma(Alpha[i], 1) random numbers divided by their sum.
+// For Alpha[i] < 1 the Gamma random numbers are kept as logarithms,
+// log Gamma(Alpha+1) + log(U)/Alpha, so that small parameters do not
+// underflow to a sum of zero.
+func (d *Dirichlet) Float64s() []float64 {
+	r := d.randomGenerator
+	x := make([]float64, len(d.Alpha))
+	max := math.Inf(-1)
+	for i, a := range d.Alpha {
+		x[i] = logGammaVariate(r, a)
+		if x[i] > max {
+			max = x[i]
+		}
+	}
+	sum := 0.0
+	for i, l := range x {
+		x[i] = math.Exp(l - max)
+		sum += x[i]
+	}
+	for i := range x {
+		x[i] /= sum
+	}
+	return x
+}
+
+// LogPdf returns the logarithm of the probability distribution function
+// at x, which should sum to one.
+func (d *Dirichlet) LogPdf(x []float64) float64 {
+	if len(x) != len(d.Alpha) {
+		panic("Illegal argument for Dirichlet distribution: x and alpha differ in length")
+	}
+	total := 0.0
+	l := 0.0
+	for i, a := range d.Alpha {
+		if x[i] < 0 || x[i] > 1 {
+			return math.Inf(-1)
+		}
+		total += a
+		lg, _ := math.Lgamma(a)
+		l += (a-1)*math.Log(x[i]) - lg
+	}
+	lg, _ := math.Lgamma(total)
+	return l + lg
+}
+
+// Mean returns the mean Alpha / sum(Alpha).
+func (d *Dirichlet) Mean() []float64 {
+	total := 0.0
+	for _, a := range d.Alpha {
+		total += a
+	}
+	m := make([]float64, len(d.Alpha))
+	for i, a := range d.Alpha {
+		m[i] = a / total
+	}
+	return m
+}
+
+// Pdf returns the probability distribution function at x.
+func (d *Dirichlet) Pdf(x []float64) float64 {
+	return math.Exp(d.LogPdf(x))
+}
+
+// Seed uses the provided seed value to initialize the generator to a deterministic state.
+func (d *Dirichlet) Seed(seed int64) {
+	d.randomGenerator.Seed(seed)
+}
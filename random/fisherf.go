@@ -0,0 +1,149 @@
+//   Copyright (C) 2012 Mingzhi Lin
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS
+// OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package random
+
+import (
+	"github.com/mingzhi/gomath/specfunc"
+	"math"
+	"math/rand"
+)
+
+// A FisherF distribution with D1 and D2 degrees of freedom,
+// the distribution of (U1/D1) / (U2/D2) for independent ChiSquared U1, U2.
+// p(x) = (D1/D2)^(D1/2) * x^(D1/2-1) * (1 + D1*x/D2)^(-(D1+D2)/2) / B(D1/2, D2/2)
+type FisherF struct {
+	D1, D2 float64 // degrees of freedom
+
+	randomGenerator *rand.Rand // random number generator
+}
+
+// NewFisherF returns a FisherF distribution with d1 and d2 degrees of freedom.
+func NewFisherF(d1, d2 float64, src rand.Source) *FisherF {
+	if !(d1 > 0) || !(d2 > 0) {
+		panic("Illegal argument for FisherF distribution: d1 <= 0 or d2 <= 0")
+	}
+	f := &FisherF{D1: d1, D2: d2}
+	f.randomGenerator = rand.New(src)
+	return f
+}
+
+// Cdf returns the cumulative distribution function.
+func (f FisherF) Cdf(x float64) float64 {
+	switch {
+	case x <= 0:
+		return 0
+	case math.IsInf(x, 1):
+		return 1
+	}
+	return specfunc.RegularizedBeta(f.D1*x/(f.D1*x+f.D2), f.D1/2, f.D2/2)
+}
+
+// Float64 returns a random number from the distribution.
+func (f FisherF) Float64() float64 {
+	u1 := gammaVariate(f.randomGenerator, f.D1/2)
+	u2 := gammaVariate(f.randomGenerator, f.D2/2)
+	return (u1 / f.D1) / (u2 / f.D2)
+}
+
+// LogPdf returns the logarithm of the probability distribution function.
+func (f FisherF) LogPdf(x float64) float64 {
+	switch {
+	case x < 0:
+		return math.Inf(-1)
+	case x == 0:
+		return betaEdge(f.D1/2, f.D2/2) + math.Log(f.D1/f.D2)
+	}
+	a, b := f.D1/2, f.D2/2
+	r := f.D1 / f.D2
+	return a*math.Log(r) + (a-1)*math.Log(x) - (a+b)*math.Log1p(r*x) - specfunc.LogBeta(a, b)
+}
+
+// Mean returns the mean D2 / (D2 - 2), or NaN for D2 <= 2.
+func (f FisherF) Mean() float64 {
+	if f.D2 <= 2 {
+		return math.NaN()
+	}
+	return f.D2 / (f.D2 - 2)
+}
+
+// Pdf returns the probability distribution function.
+func (f FisherF) Pdf(x float64) float64 {
+	return math.Exp(f.LogPdf(x))
+}
+
+// Quantile returns the inverse of the cumulative distribution function,
+// the x with Cdf(x) = p.
+func (f FisherF) Quantile(p float64) float64 {
+	switch {
+	case math.IsNaN(p) || p < 0 || p > 1:
+		return math.NaN()
+	case p == 1:
+		return math.Inf(1)
+	case p <= 0.5:
+		x := specfunc.InvRegularizedBeta(p, f.D1/2, f.D2/2)
+		return f.D2 * x / (f.D1 * (1 - x))
+	}
+	return f.SurvivalQuantile(1 - p)
+}
+
+// Seed uses the provided seed value to initialize the generator to a deterministic state.
+func (f *FisherF) Seed(seed int64) {
+	f.randomGenerator.Seed(seed)
+}
+
+// Survival returns the survival function 1 - Cdf(x),
+// accurate in the upper tail.
+func (f FisherF) Survival(x float64) float64 {
+	switch {
+	case x <= 0:
+		return 1
+	case math.IsInf(x, 1):
+		return 0
+	}
+	return specfunc.RegularizedBeta(f.D2/(f.D2+f.D1*x), f.D2/2, f.D1/2)
+}
+
+// SurvivalQuantile returns the x with Survival(x) = q,
+// accurate for small q, where 1-q rounds.
+func (f FisherF) SurvivalQuantile(q float64) float64 {
+	if math.IsNaN(q) || q < 0 || q > 1 {
+		return math.NaN()
+	}
+	if q == 0 {
+		return math.Inf(1)
+	}
+	y := specfunc.InvRegularizedBeta(q, f.D2/2, f.D1/2)
+	return f.D2 * (1 - y) / (f.D1 * y)
+}
+
+// Variance returns the variance
+// 2 * D2^2 * (D1 + D2 - 2) / (D1 * (D2 - 2)^2 * (D2 - 4)),
+// +Inf for 2 < D2 <= 4, or NaN for D2 <= 2.
+func (f FisherF) Variance() float64 {
+	switch {
+	case f.D2 <= 2:
+		return math.NaN()
+	case f.D2 <= 4:
+		return math.Inf(1)
+	}
+	d := f.D2 - 2
+	return 2 * f.D2 * f.D2 * (f.D1 + f.D2 - 2) / (f.D1 * d * d * (f.D2 - 4))
+}
@@ -0,0 +1,145 @@
+//   Copyright (C) 2012 Mingzhi Lin
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS
+// OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package random
+
+import (
+	"github.com/mingzhi/gomath/specfunc"
+	"math"
+	"math/rand"
+)
+
+// A Gamma distribution with shape K and rate Rate.
+// p(x) = Rate^K * x^(K-1) * exp(-Rate*x) / Gamma(K) for x >= 0
+type Gamma struct {
+	K    float64 // shape
+	Rate float64 // rate, the inverse of the scale
+
+	randomGenerator *rand.Rand // random number generator
+}
+
+// NewGamma returns a Gamma distribution with the provided shape and rate.
+func NewGamma(shape, rate float64, src rand.Source) *Gamma {
+	if !(shape > 0) || !(rate > 0) {
+		panic("Illegal argument for Gamma distribution: shape <= 0 or rate <= 0")
+	}
+	gamma := &Gamma{K: shape, Rate: rate}
+	gamma.randomGenerator = rand.New(src)
+	return gamma
+}
+
+// Cdf returns the cumulative distribution function.
+func (gamma Gamma) Cdf(x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	return specfunc.RegularizedGammaP(gamma.K, gamma.Rate*x)
+}
+
+// Float64 returns a random number from the distribution.
+func (gamma Gamma) Float64() float64 {
+	return gammaVariate(gamma.randomGenerator, gamma.K) / gamma.Rate
+}
+
+// LogPdf returns the logarithm of the probability distribution function.
+func (gamma Gamma) LogPdf(x float64) float64 {
+	return gammaLogPdf(gamma.K, gamma.Rate, x)
+}
+
+// Mean returns the mean K / Rate.
+func (gamma Gamma) Mean() float64 {
+	return gamma.K / gamma.Rate
+}
+
+// Pdf returns the probability distribution function.
+func (gamma Gamma) Pdf(x float64) float64 {
+	return math.Exp(gamma.LogPdf(x))
+}
+
+// Quantile returns the inverse of the cumulative distribution function,
+// the x with Cdf(x) = p.
+func (gamma Gamma) Quantile(p float64) float64 {
+	return specfunc.InvRegularizedGammaP(gamma.K, p) / gamma.Rate
+}
+
+// Seed uses the provided seed value to initialize the generator to a deterministic state.
+func (gamma *Gamma) Seed(seed int64) {
+	gamma.randomGenerator.Seed(seed)
+}
+
+// Variance returns the variance K / Rate^2.
+func (gamma Gamma) Variance() float64 {
+	return gamma.K / (gamma.Rate * gamma.Rate)
+}
+
+// gammaLogPdf returns the log density of the Gamma(shape, rate) distribution at x.
+func gammaLogPdf(shape, rate, x float64) float64 {
+	switch {
+	case x < 0:
+		return math.Inf(-1)
+	case x == 0 && shape == 1:
+		return math.Log(rate)
+	case x == 0 && shape < 1:
+		return math.Inf(1)
+	case x == 0:
+		return math.Inf(-1)
+	}
+	lg, _ := math.Lgamma(shape)
+	return shape*math.Log(rate) + (shape-1)*math.Log(x) - rate*x - lg
+}
+
+// logGammaVariate returns the logarithm of a Gamma(shape, 1) random number.
+// For shape < 1 it is computed as log Gamma(shape+1) + log(U)/shape, which
+// stays finite where the variate itself underflows to zero.
+func logGammaVariate(r *rand.Rand, shape float64) float64 {
+	if shape < 1 {
+		u := 1 - r.Float64() // in (0, 1]
+		return math.Log(gammaVariate(r, shape+1)) + math.Log(u)/shape
+	}
+	return math.Log(gammaVariate(r, shape))
+}
+
+// gammaVariate returns a Gamma(shape, 1) random number by the method of
+// Marsaglia and Tsang (2000). For shape < 1, it uses
+// Gamma(shape) = Gamma(shape+1) * U^(1/shape).
+func gammaVariate(r *rand.Rand, shape float64) float64 {
+	if shape < 1 {
+		u := 1 - r.Float64() // in (0, 1]
+		return gammaVariate(r, shape+1) * math.Pow(u, 1/shape)
+	}
+	d := shape - 1.0/3
+	c := 1 / math.Sqrt(9*d)
+	for {
+		var x, v float64
+		for v <= 0 {
+			x = stdNormal(r)
+			v = 1 + c*x
+		}
+		v = v * v * v
+		u := r.Float64()
+		x2 := x * x
+		if u < 1-0.0331*x2*x2 {
+			return d * v
+		}
+		if math.Log(u) < 0.5*x2+d*(1-v+math.Log(v)) {
+			return d * v
+		}
+	}
+}
@@ -0,0 +1,99 @@
+//   Copyright (C) 2012 Mingzhi Lin
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS
+// OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package random
+
+import (
+	"math"
+	"math/rand"
+)
+
+// A Geometric distribution of the number of failures before the first success,
+// with success probability P.
+// p(k) = P * (1-P)^k for k = 0, 1, ...
+type Geometric struct {
+	P float64
+
+	randomGenerator *rand.Rand // random number generator
+}
+
+// NewGeometric returns a Geometric distribution with success probability p.
+func NewGeometric(p float64, src rand.Source) *Geometric {
+	if !(p > 0 && p <= 1) {
+		panic("Illegal argument for Geometric distribution: p is not in (0, 1]")
+	}
+	geometric := &Geometric{P: p}
+	geometric.randomGenerator = rand.New(src)
+	return geometric
+}
+
+// Cdf returns the cumulative distribution function, 1 - (1-P)^(k+1).
+func (geometric Geometric) Cdf(k int) (p float64) {
+	if k < 0 {
+		return 0
+	}
+	return -math.Expm1(float64(k+1) * math.Log1p(-geometric.P))
+}
+
+// Int returns a random number from the distribution, by inversion of an
+// exponential random number.
+func (geometric Geometric) Int() int {
+	if geometric.P == 1 {
+		return 0
+	}
+	k := math.Floor(geometric.randomGenerator.ExpFloat64() / -math.Log1p(-geometric.P))
+	if k >= float64(maxInt) {
+		return maxInt
+	}
+	return int(k)
+}
+
+// Pdf returns the probability distribution function.
+func (geometric Geometric) Pdf(k int) (p float64) {
+	if k < 0 {
+		return 0
+	}
+	if k == 0 {
+		return geometric.P
+	}
+	return geometric.P * math.Exp(float64(k)*math.Log1p(-geometric.P))
+}
+
+// Quantile returns the smallest k with Cdf(k) >= p,
+// the largest int for p = 1, or -1 if p is not in [0, 1].
+func (geometric Geometric) Quantile(p float64) int {
+	q := 1 - geometric.P
+	mean := q / geometric.P
+	sd := math.Sqrt(q) / geometric.P
+	return discreteQuantile(p, 0, maxInt, mean, sd, (2-geometric.P)/math.Sqrt(q), geometric.Cdf)
+}
+
+// Seed uses the provided seed value to initialize the generator to a deterministic state.
+func (geometric *Geometric) Seed(seed int64) {
+	geometric.randomGenerator.Seed(seed)
+}
+
+// Survival returns the survival function, P(X > k) = (1-P)^(k+1).
+func (geometric Geometric) Survival(k int) (p float64) {
+	if k < 0 {
+		return 1
+	}
+	return math.Exp(float64(k+1) * math.Log1p(-geometric.P))
+}
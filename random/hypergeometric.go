@@ -0,0 +1,300 @@
+//   Copyright (C) 2012 Mingzhi Lin
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS
+// OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package random
+
+import (
+	"github.com/mingzhi/gomath/specfunc"
+	"math"
+	"math/rand"
+)
+
+// A Hypergeometric distribution of the number of successes in Draws draws
+// without replacement from a population of N with K successes.
+// p(k) = C(K, k) * C(N-K, Draws-k) / C(N, Draws) for max(0, Draws-N+K) <= k <= min(K, Draws)
+type Hypergeometric struct {
+	N     int // population size
+	K     int // number of successes in the population
+	Draws int // number of draws
+
+	randomGenerator *rand.Rand // random number generator
+
+	// the sampler draws from the reduced distribution with n1 <= n2 and
+	// k <= N/2, and maps back by complements
+	n1, n2, k    int
+	swapK, swapN bool
+	m            int     // mode of the reduced distribution
+	inversion    bool    // whether to use inversion
+	p0           float64 // probability of 0 for inversion
+	a            float64 // log of the mode's term for H2PE
+	xl, xr       float64 // ends of the uniform center of the hat
+	lambdaL      float64 // rate of the left exponential tail
+	lambdaR      float64 // rate of the right exponential tail
+	p1, p2, p3   float64 // cumulative areas of the hat regions
+}
+
+// NewHypergeometric returns a Hypergeometric distribution of draws draws from
+// a population of n with k successes.
+func NewHypergeometric(n, k, draws int, src rand.Source) *Hypergeometric {
+	if n < 1 || k < 0 || k > n || draws < 0 || draws > n {
+		panic("Illegal argument for Hypergeometric distribution: need 0 <= k <= n and 0 <= draws <= n")
+	}
+	h := &Hypergeometric{N: n, K: k, Draws: draws}
+	h.randomGenerator = rand.New(src)
+
+	h.n1, h.n2, h.k = k, n-k, draws
+	if h.n1 > h.n2 {
+		h.n1, h.n2 = h.n2, h.n1
+		h.swapK = true
+	}
+	if h.k > n/2 {
+		h.k = n - h.k
+		h.swapN = true
+	}
+	n1, n2, kk := float64(h.n1), float64(h.n2), float64(h.k)
+	nn := float64(n)
+	h.m = int(math.Floor((kk + 1) * (n1 + 1) / (nn + 2)))
+	// with k <= N/2 <= n2, the reduced distribution starts at 0
+	if h.m < 10 {
+		h.inversion = true
+		h.p0 = math.Exp(specfunc.LogFactorial(h.n2) + specfunc.LogFactorial(n-h.k) -
+			specfunc.LogFactorial(h.n2-h.k) - specfunc.LogFactorial(n))
+		return h
+	}
+
+	// H2PE setup: a uniform hat over (xl, xr) around the mode and
+	// exponential tails beyond
+	m := float64(h.m)
+	h.a = lnFactorial(m) + lnFactorial(n1-m) + lnFactorial(kk-m) + lnFactorial(n2-kk+m)
+	d := math.Floor(1.5*math.Sqrt((nn-kk)*kk*n1*n2/((nn-1)*nn*nn)) + 0.5)
+	h.xl = m - d + 0.5
+	h.xr = m + d + 0.5
+	kl := math.Exp(h.a - lnFactorial(h.xl) - lnFactorial(n1-h.xl) - lnFactorial(kk-h.xl) - lnFactorial(n2-kk+h.xl))
+	kr := math.Exp(h.a - lnFactorial(h.xr-1) - lnFactorial(n1-h.xr+1) - lnFactorial(kk-h.xr+1) - lnFactorial(n2-kk+h.xr-1))
+	h.lambdaL = -math.Log(h.xl * (n2 - kk + h.xl) / ((n1 - h.xl + 1) * (kk - h.xl + 1)))
+	h.lambdaR = -math.Log((n1 - h.xr + 1) * (kk - h.xr + 1) / (h.xr * (n2 - kk + h.xr)))
+	h.p1 = 2 * d
+	h.p2 = h.p1 + kl/h.lambdaL
+	h.p3 = h.p2 + kr/h.lambdaR
+	return h
+}
+
+// lnFactorial returns log(x!) for real x.
+func lnFactorial(x float64) float64 {
+	l, _ := math.Lgamma(x + 1)
+	return l
+}
+
+// Cdf returns the cumulative distribution function, summed exactly
+// over the shorter tail.
+func (h Hypergeometric) Cdf(k int) (p float64) {
+	lo, hi := h.support()
+	switch {
+	case k < lo:
+		return 0
+	case k >= hi:
+		return 1
+	case float64(k) < h.mean():
+		return h.lowerSum(k)
+	}
+	return 1 - h.upperSum(k+1)
+}
+
+// Int returns a random number from the distribution.
+func (h *Hypergeometric) Int() int {
+	var x int
+	if h.inversion {
+		x = h.invert()
+	} else {
+		x = h.h2pe()
+	}
+	if h.swapN {
+		x = h.n1 - x
+	}
+	if h.swapK {
+		x = h.Draws - x
+	}
+	return x
+}
+
+// Pdf returns the probability distribution function.
+func (h Hypergeometric) Pdf(k int) (p float64) {
+	lo, hi := h.support()
+	if k < lo || k > hi {
+		return 0
+	}
+	return math.Exp(logChoose(h.K, k) + logChoose(h.N-h.K, h.Draws-k) - logChoose(h.N, h.Draws))
+}
+
+// Quantile returns the smallest k with Cdf(k) >= p,
+// or -1 if p is not in [0, 1].
+func (h Hypergeometric) Quantile(p float64) int {
+	lo, hi := h.support()
+	n := float64(h.N)
+	sd := math.Sqrt(float64(h.Draws) * float64(h.K) / n * float64(h.N-h.K) / n * float64(h.N-h.Draws) / (n - 1))
+	skew := 0.0
+	if sd > 0 {
+		skew = float64(h.N-2*h.K) * float64(h.N-2*h.Draws) / (n - 2) * math.Sqrt(n-1) /
+			math.Sqrt(float64(h.Draws)*float64(h.K)*float64(h.N-h.K)*float64(h.N-h.Draws))
+	}
+	return discreteQuantile(p, lo, hi, h.mean(), sd, skew, h.Cdf)
+}
+
+// Seed uses the provided seed value to initialize the generator to a deterministic state.
+func (h *Hypergeometric) Seed(seed int64) {
+	h.randomGenerator.Seed(seed)
+}
+
+// Survival returns the survival function, P(X > k) = 1 - Cdf(k),
+// summed exactly over the shorter tail.
+func (h Hypergeometric) Survival(k int) (p float64) {
+	lo, hi := h.support()
+	switch {
+	case k < lo:
+		return 1
+	case k >= hi:
+		return 0
+	case float64(k) < h.mean():
+		return 1 - h.lowerSum(k)
+	}
+	return h.upperSum(k + 1)
+}
+
+func (h Hypergeometric) support() (lo, hi int) {
+	lo = h.Draws - (h.N - h.K)
+	if lo < 0 {
+		lo = 0
+	}
+	hi = h.K
+	if h.Draws < hi {
+		hi = h.Draws
+	}
+	return
+}
+
+func (h Hypergeometric) mean() float64 {
+	return float64(h.Draws) * float64(h.K) / float64(h.N)
+}
+
+// lowerSum returns P(X <= k), summing the terms down from k with the
+// recurrence p(x-1) = p(x) * x*(N-K-Draws+x) / ((K-x+1)*(Draws-x+1))
+// until they are negligible.
+func (h Hypergeometric) lowerSum(k int) float64 {
+	lo, _ := h.support()
+	t := h.Pdf(k)
+	sum := t
+	for x := k; x > lo && t > machEp*sum; x-- {
+		t *= float64(x) * float64(h.N-h.K-h.Draws+x) / (float64(h.K-x+1) * float64(h.Draws-x+1))
+		sum += t
+	}
+	return sum
+}
+
+// upperSum returns P(X >= k), summing the terms up from k.
+func (h Hypergeometric) upperSum(k int) float64 {
+	_, hi := h.support()
+	t := h.Pdf(k)
+	sum := t
+	for x := k; x < hi && t > machEp*sum; x++ {
+		t *= float64(h.K-x) * float64(h.Draws-x) / (float64(x+1) * float64(h.N-h.K-h.Draws+x+1))
+		sum += t
+	}
+	return sum
+}
+
+// logChoose returns log(C(n, k)).
+func logChoose(n, k int) float64 {
+	return specfunc.LogFactorial(n) - specfunc.LogFactorial(k) - specfunc.LogFactorial(n-k)
+}
+
+// invert samples the reduced distribution by sequential inversion from 0.
+func (h *Hypergeometric) invert() int {
+	n1, n2, k := float64(h.n1), float64(h.n2), float64(h.k)
+	upper := h.n1
+	if h.k < upper {
+		upper = h.k
+	}
+	for {
+		u := h.randomGenerator.Float64()
+		p := h.p0
+		x := 0
+		for u > p && x < upper {
+			u -= p
+			x++
+			fx := float64(x)
+			p *= (n1 - fx + 1) * (k - fx + 1) / (fx * (n2 - k + fx))
+		}
+		if u <= p {
+			return x
+		}
+	}
+}
+
+// h2pe samples the reduced distribution by the H2PE algorithm of
+// Kachitvichyanukul and Schmeiser (1985): acceptance/rejection from a hat
+// that is uniform around the mode with exponential tails.
+func (h *Hypergeometric) h2pe() int {
+	r := h.randomGenerator
+	n1, n2, k := float64(h.n1), float64(h.n2), float64(h.k)
+	upper := h.n1
+	if h.k < upper {
+		upper = h.k
+	}
+	for {
+		u := r.Float64() * h.p3
+		v := 1 - r.Float64() // in (0, 1]
+		var y int
+		switch {
+		case u < h.p1:
+			y = int(math.Floor(h.xl + u))
+		case u < h.p2:
+			y = int(math.Floor(h.xl + math.Log(v)/h.lambdaL))
+			v *= (u - h.p1) * h.lambdaL
+		default:
+			y = int(math.Floor(h.xr - math.Log(v)/h.lambdaR))
+			v *= (u - h.p2) * h.lambdaR
+		}
+		if y < 0 || y > upper {
+			continue
+		}
+
+		// accept if v <= f(y) / f(m)
+		if h.m < 100 || y <= 50 {
+			f := 1.0
+			if h.m < y {
+				for i := float64(h.m + 1); i <= float64(y); i++ {
+					f *= (n1 - i + 1) * (k - i + 1) / ((n2 - k + i) * i)
+				}
+			} else if h.m > y {
+				for i := float64(y + 1); i <= float64(h.m); i++ {
+					f *= i * (n2 - k + i) / ((n1 - i + 1) * (k - i + 1))
+				}
+			}
+			if v <= f {
+				return y
+			}
+			continue
+		}
+		fy := float64(y)
+		if math.Log(v) <= h.a-lnFactorial(fy)-lnFactorial(n1-fy)-lnFactorial(k-fy)-lnFactorial(n2-k+fy) {
+			return y
+		}
+	}
+}
@@ -0,0 +1,119 @@
+//   Copyright (C) 2012 Mingzhi Lin
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS
+// OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package random
+
+import (
+	"github.com/mingzhi/gomath/specfunc"
+	"math"
+	"math/rand"
+)
+
+// A Multinomial distribution of the counts of N independent draws from
+// categories with probabilities P.
+// p(x) = N! / (x[0]! * ... * x[n-1]!) * P[0]^x[0] * ... * P[n-1]^x[n-1]
+type Multinomial struct {
+	N int
+	P []float64
+
+	binomial *Binomial // generator of the conditional counts
+}
+
+// NewMultinomial returns a Multinomial distribution of n draws with
+// probabilities proportional to the non-negative weights p, which are
+// normalized into a copy.
+func NewMultinomial(n int, p []float64, src rand.Source) *Multinomial {
+	if n < 0 || len(p) == 0 {
+		panic("Illegal argument for Multinomial distribution: n < 0 or no probabilities")
+	}
+	total := 0.0
+	for _, v := range p {
+		if !(v >= 0) || math.IsInf(v, 1) {
+			panic("Illegal argument for Multinomial distribution: probabilities must be finite and non-negative")
+		}
+		total += v
+	}
+	if !(total > 0) {
+		panic("Illegal argument for Multinomial distribution: all the probabilities are zero")
+	}
+	m := &Multinomial{N: n, P: make([]float64, len(p))}
+	for i, v := range p {
+		m.P[i] = v / total
+	}
+	m.binomial = NewBinomial(1, 0.5, src)
+	return m
+}
+
+// Ints returns random counts from the distribution. The count of each
+// category is drawn from the Binomial distribution of the draws left,
+// with its probability conditional on the categories before it.
+func (m *Multinomial) Ints() []int {
+	x := make([]int, len(m.P))
+	left := m.N
+	rest := 1.0 // probability of the categories not yet drawn
+	for i, p := range m.P {
+		if left == 0 {
+			break
+		}
+		switch q := p / rest; {
+		case i == len(m.P)-1 || q >= 1:
+			x[i] = left
+		case q > 0:
+			m.binomial.N, m.binomial.P = left, q
+			x[i] = m.binomial.Int()
+		}
+		left -= x[i]
+		rest -= p
+	}
+	return x
+}
+
+// LogPdf returns the logarithm of the probability distribution function.
+func (m *Multinomial) LogPdf(x []int) float64 {
+	if len(x) != len(m.P) {
+		panic("Illegal argument for Multinomial distribution: counts and probabilities differ in length")
+	}
+	n := 0
+	l := 0.0
+	for i, k := range x {
+		if k < 0 {
+			return math.Inf(-1)
+		}
+		n += k
+		l -= specfunc.LogFactorial(k)
+		if k > 0 {
+			l += float64(k) * math.Log(m.P[i])
+		}
+	}
+	if n != m.N {
+		return math.Inf(-1)
+	}
+	return l + specfunc.LogFactorial(m.N)
+}
+
+// Pdf returns the probability distribution function.
+func (m *Multinomial) Pdf(x []int) float64 {
+	return math.Exp(m.LogPdf(x))
+}
+
+// Seed uses the provided seed value to initialize the generator to a deterministic state.
+func (m *Multinomial) Seed(seed int64) {
+	m.binomial.randomGenerator.Seed(seed)
+}
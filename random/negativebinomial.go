@@ -0,0 +1,116 @@
+//   Copyright (C) 2012 Mingzhi Lin
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS
+// OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package random
+
+import (
+	"github.com/mingzhi/gomath/specfunc"
+	"math"
+	"math/rand"
+)
+
+// A NegativeBinomial distribution of the number of failures before the R-th
+// success, with success probability P. R need not be an integer.
+// p(k) = Gamma(k+R) / (k! * Gamma(R)) * P^R * (1-P)^k for k = 0, 1, ...
+//
+// With mean m = R*(1-P)/P, the variance is m + m^2/R, so that R is the
+// inverse of the dispersion of a Gamma-Poisson mixture; see NewNegativeBinomialMean.
+type NegativeBinomial struct {
+	R float64 // number of successes, or size
+	P float64 // success probability
+
+	randomGenerator *rand.Rand // random number generator
+	poisson         *Poisson   // Poisson generator for the mixture
+}
+
+// NewNegativeBinomial returns a NegativeBinomial distribution with r successes
+// of probability p.
+func NewNegativeBinomial(r, p float64, src rand.Source) *NegativeBinomial {
+	if !(r > 0) || !(p > 0 && p < 1) {
+		panic("Illegal argument for NegativeBinomial distribution: r <= 0 or p is not in (0, 1)")
+	}
+	nb := &NegativeBinomial{R: r, P: p}
+	nb.randomGenerator = rand.New(src)
+	nb.poisson = NewPoisson(0, src)
+	return nb
+}
+
+// NewNegativeBinomialMean returns a NegativeBinomial distribution with the
+// provided mean and size r, with variance mean + mean^2/r, as R's mu and size.
+func NewNegativeBinomialMean(mean, r float64, src rand.Source) *NegativeBinomial {
+	if !(mean > 0) {
+		panic("Illegal argument for NegativeBinomial distribution: mean <= 0")
+	}
+	return NewNegativeBinomial(r, r/(r+mean), src)
+}
+
+// Cdf returns the cumulative distribution function,
+// P(X <= k) = I(P; R, k+1) with the regularized incomplete beta function.
+func (nb NegativeBinomial) Cdf(k int) (p float64) {
+	if k < 0 {
+		return 0
+	}
+	return specfunc.RegularizedBeta(nb.P, nb.R, float64(k)+1)
+}
+
+// Int returns a random number from the distribution, as a Poisson random
+// number whose mean is drawn from the Gamma distribution with shape R
+// and rate P/(1-P).
+func (nb NegativeBinomial) Int() int {
+	lambda := gammaVariate(nb.randomGenerator, nb.R) * (1 - nb.P) / nb.P
+	if lambda == 0 {
+		return 0
+	}
+	nb.poisson.Mean = lambda
+	return nb.poisson.Int()
+}
+
+// Pdf returns the probability distribution function.
+func (nb NegativeBinomial) Pdf(k int) (p float64) {
+	if k < 0 {
+		return 0
+	}
+	lk, _ := math.Lgamma(float64(k) + nb.R)
+	lr, _ := math.Lgamma(nb.R)
+	return math.Exp(lk - specfunc.LogFactorial(k) - lr + nb.R*math.Log(nb.P) + float64(k)*math.Log1p(-nb.P))
+}
+
+// Quantile returns the smallest k with Cdf(k) >= p,
+// the largest int for p = 1, or -1 if p is not in [0, 1].
+func (nb NegativeBinomial) Quantile(p float64) int {
+	q := 1 - nb.P
+	mean := nb.R * q / nb.P
+	sd := math.Sqrt(mean / nb.P)
+	return discreteQuantile(p, 0, maxInt, mean, sd, (2-nb.P)/math.Sqrt(nb.R*q), nb.Cdf)
+}
+
+// Seed uses the provided seed value to initialize the generator to a deterministic state.
+func (nb *NegativeBinomial) Seed(seed int64) {
+	nb.randomGenerator.Seed(seed)
+}
+
+// Survival returns the survival function, P(X > k) = 1 - Cdf(k),
+// accurate in the upper tail.
+func (nb NegativeBinomial) Survival(k int) (p float64) {
+	if k < 0 {
+		return 1
+	}
+	return specfunc.RegularizedBeta(1-nb.P, float64(k)+1, nb.R)
+}
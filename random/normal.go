@@ -0,0 +1,241 @@
+//   Copyright (C) 2012 Mingzhi Lin
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS
+// OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package random
+
+import (
+	"math"
+	"math/rand"
+)
+
+// A Normal (Gaussian) distribution with mean Mu and standard deviation Sigma.
+// p(x) = exp(-(x-Mu)^2 / (2*Sigma^2)) / (Sigma * sqrt(2*pi))
+type Normal struct {
+	Mu    float64
+	Sigma float64
+
+	randomGenerator *rand.Rand // random number generator
+}
+
+// NewNormal returns a Normal distribution with the provided mean and standard deviation.
+func NewNormal(mu, sigma float64, src rand.Source) *Normal {
+	if !(sigma > 0) {
+		panic("Illegal argument for Normal distribution: sigma <= 0")
+	}
+	normal := &Normal{Mu: mu, Sigma: sigma}
+	normal.randomGenerator = rand.New(src)
+	return normal
+}
+
+// Cdf returns the cumulative distribution function.
+// It uses erfc, which keeps full relative accuracy in the lower tail.
+func (normal Normal) Cdf(x float64) float64 {
+	return 0.5 * math.Erfc(-(x-normal.Mu)/(normal.Sigma*math.Sqrt2))
+}
+
+// Float64 returns a random number from the distribution.
+func (normal Normal) Float64() float64 {
+	z := stdNormal(normal.randomGenerator)
+	if normal.Mu == 0 && normal.Sigma == 1 {
+		return z
+	}
+	return normal.Mu + normal.Sigma*z
+}
+
+// LogPdf returns the logarithm of the probability distribution function.
+func (normal Normal) LogPdf(x float64) float64 {
+	z := (x - normal.Mu) / normal.Sigma
+	return -0.5*z*z - math.Log(normal.Sigma) - lnSqrt2Pi
+}
+
+// Pdf returns the probability distribution function.
+func (normal Normal) Pdf(x float64) float64 {
+	z := (x - normal.Mu) / normal.Sigma
+	return math.Exp(-0.5*z*z) / (normal.Sigma * sqrt2Pi)
+}
+
+// Quantile returns the inverse of the cumulative distribution function,
+// the x with Cdf(x) = p.
+func (normal Normal) Quantile(p float64) float64 {
+	return normal.Mu + normal.Sigma*stdNormalQuantile(p)
+}
+
+// Seed uses the provided seed value to initialize the generator to a deterministic state.
+func (normal *Normal) Seed(seed int64) {
+	normal.randomGenerator.Seed(seed)
+}
+
+const (
+	sqrt2Pi   = 2.50662827463100050242 // sqrt(2*pi)
+	lnSqrt2Pi = 0.91893853320467274178 // log(sqrt(2*pi))
+)
+
+// Coefficients of the rational approximations of AS241 (PPND16):
+// a, b for |p - 0.5| <= 0.425, c, d for r = sqrt(-log(min(p, 1-p))) <= 5,
+// and e, f beyond.
+var (
+	ppndA = [8]float64{
+		3.3871328727963666080e0, 1.3314166789178437745e+2,
+		1.9715909503065514427e+3, 1.3731693765509461125e+4,
+		4.5921953931549871457e+4, 6.7265770927008700853e+4,
+		3.3430575583588128105e+4, 2.5090809287301226727e+3,
+	}
+	ppndB = [8]float64{
+		1, 4.2313330701600911252e+1,
+		6.8718700749205790830e+2, 5.3941960214247511077e+3,
+		2.1213794301586595867e+4, 3.9307895800092710610e+4,
+		2.8729085735721942674e+4, 5.2264952788528545610e+3,
+	}
+	ppndC = [8]float64{
+		1.42343711074968357734e0, 4.63033784615654529590e0,
+		5.76949722146069140550e0, 3.64784832476320460504e0,
+		1.27045825245236838258e0, 2.41780725177450611770e-1,
+		2.27238449892691845833e-2, 7.74545014278341407640e-4,
+	}
+	ppndD = [8]float64{
+		1, 2.05319162663775882187e0,
+		1.67638483018380384940e0, 6.89767334985100004550e-1,
+		1.48103976427480074590e-1, 1.51986665636164571966e-2,
+		5.47593808499534494600e-4, 1.05075007164441684324e-9,
+	}
+	ppndE = [8]float64{
+		6.65790464350110377720e0, 5.46378491116411436990e0,
+		1.78482653991729133580e0, 2.96560571828504891230e-1,
+		2.65321895265761230930e-2, 1.24266094738807843860e-3,
+		2.71155556874348757815e-5, 2.01033439929228813265e-7,
+	}
+	ppndF = [8]float64{
+		1, 5.99832206555887937690e-1,
+		1.36929880922735805310e-1, 1.48753612908506148525e-2,
+		7.86869131145613259100e-4, 1.84631831751005468180e-5,
+		1.42151175831644588870e-7, 2.04426310338993978564e-15,
+	}
+)
+
+// ratEval returns num(x) / den(x) for polynomials of degree 7 with
+// coefficients in increasing order.
+func ratEval(num, den *[8]float64, x float64) float64 {
+	n, d := num[7], den[7]
+	for i := 6; i >= 0; i-- {
+		n = n*x + num[i]
+		d = d*x + den[i]
+	}
+	return n / d
+}
+
+// stdNormalQuantile returns the p-quantile of the standard normal
+// distribution by algorithm AS241 of Wichura (1988), accurate to about
+// 1 part in 10^16.
+func stdNormalQuantile(p float64) float64 {
+	switch {
+	case math.IsNaN(p) || p < 0 || p > 1:
+		return math.NaN()
+	case p == 0:
+		return math.Inf(-1)
+	case p == 1:
+		return math.Inf(1)
+	}
+	q := p - 0.5
+	if math.Abs(q) <= 0.425 {
+		r := 0.180625 - q*q
+		return q * ratEval(&ppndA, &ppndB, r)
+	}
+	r := p
+	if q > 0 {
+		r = 1 - p
+	}
+	r = math.Sqrt(-math.Log(r))
+	var x float64
+	if r <= 5 {
+		x = ratEval(&ppndC, &ppndD, r-1.6)
+	} else {
+		x = ratEval(&ppndE, &ppndF, r-5)
+	}
+	if q < 0 {
+		return -x
+	}
+	return x
+}
+
+// Ziggurat tables for standard normal sampling (Marsaglia and Tsang, 2000)
+// with the improvements of Doornik (2005): 128 layers of equal area zigV,
+// the base layer ending at zigR and covering the tail.
+const (
+	zigLayers = 128
+	zigR      = 3.442619855899
+	zigV      = 9.91256303526217e-3
+)
+
+var (
+	zigX     [zigLayers + 1]float64 // right edges of the layers
+	zigRatio [zigLayers]float64     // zigX[i+1] / zigX[i]
+)
+
+func init() {
+	f := math.Exp(-0.5 * zigR * zigR)
+	zigX[0] = zigV / f // width of a rectangle with the area of the base layer
+	zigX[1] = zigR
+	for i := 2; i < zigLayers; i++ {
+		zigX[i] = math.Sqrt(-2 * math.Log(zigV/zigX[i-1]+f))
+		f = math.Exp(-0.5 * zigX[i] * zigX[i])
+	}
+	zigX[zigLayers] = 0
+	for i := 0; i < zigLayers; i++ {
+		zigRatio[i] = zigX[i+1] / zigX[i]
+	}
+}
+
+// stdNormal returns a standard normal random number by the ziggurat method.
+// The layer and the uniform come from independent bits of one draw.
+func stdNormal(r *rand.Rand) float64 {
+	for {
+		b := r.Int63()
+		i := b & (zigLayers - 1)
+		u := float64(b>>7-1<<55) / (1 << 55) // uniform on [-1, 1)
+		if math.Abs(u) < zigRatio[i] {
+			return u * zigX[i]
+		}
+		if i == 0 {
+			return normalTail(r, u < 0)
+		}
+		x := u * zigX[i]
+		f0 := math.Exp(-0.5 * (zigX[i]*zigX[i] - x*x))
+		f1 := math.Exp(-0.5 * (zigX[i+1]*zigX[i+1] - x*x))
+		if f1+r.Float64()*(f0-f1) < 1 {
+			return x
+		}
+	}
+}
+
+// normalTail samples the standard normal beyond zigR (Marsaglia, 1964).
+func normalTail(r *rand.Rand, negative bool) float64 {
+	var x, y float64
+	for {
+		x = -math.Log(1-r.Float64()) / zigR
+		y = -math.Log(1 - r.Float64())
+		if 2*y >= x*x {
+			break
+		}
+	}
+	if negative {
+		return -zigR - x
+	}
+	return zigR + x
+}
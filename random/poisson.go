@@ -71,17 +71,50 @@ func NewPoisson(mean float64, src rand.Source) (poisson *Poisson) {
 	return
 }
 
-// Cdf returns the cumulative distribution function.
+// Cdf returns the cumulative distribution function,
+// P(X <= k) = Q(k+1, Mean) with the regularized upper incomplete gamma function.
 func (poisson Poisson) Cdf(k int) (p float64) {
+	switch {
+	case k < 0:
+		p = 0
+	case poisson.Mean == 0:
+		p = 1
+	default:
+		p = specfunc.RegularizedGammaQ(float64(k)+1, poisson.Mean)
+	}
 	return
 }
 
 // Pdf returns the probability distribution function.
 func (poisson Poisson) Pdf(k int) (p float64) {
+	if k < 0 {
+		return 0
+	}
 	p = math.Exp(float64(k)*math.Log(poisson.Mean) - specfunc.LogFactorial(k) - poisson.Mean)
 	return
 }
 
+// Quantile returns the smallest k with Cdf(k) >= p,
+// the largest int for p = 1, or -1 if p is not in [0, 1].
+func (poisson *Poisson) Quantile(p float64) int {
+	sd := math.Sqrt(poisson.Mean)
+	return discreteQuantile(p, 0, maxInt, poisson.Mean, sd, 1/sd, poisson.Cdf)
+}
+
+// Survival returns the survival function, P(X > k) = 1 - Cdf(k),
+// accurate in the upper tail.
+func (poisson *Poisson) Survival(k int) (p float64) {
+	switch {
+	case k < 0:
+		p = 1
+	case poisson.Mean == 0:
+		p = 0
+	default:
+		p = specfunc.RegularizedGammaP(float64(k)+1, poisson.Mean)
+	}
+	return
+}
+
 // Seed uses the provided seed value to initialize the generator to a deterministic state.
 func (poisson *Poisson) Seed(seed int64) {
 	poisson.locker.Lock()
@@ -21,6 +21,7 @@
 package random
 
 import (
+	"math"
 	"math/rand"
 	"sync"
 )
@@ -67,3 +68,78 @@ type Error struct {
 func (error Error) Error() string {
 	return error.Message
 }
+
+// maxInt is the largest int, the quantile of probability one of
+// distributions on all the non-negative integers.
+const maxInt = int(^uint(0) >> 1)
+
+const machEp = 1.11022302462515654042e-16 // 2^-53
+
+// discreteQuantile returns the smallest k in [lo, hi] with cdf(k) >= p,
+// for 0 <= p <= 1 and a non-decreasing cdf with cdf(hi) = 1, or -1 for p
+// outside [0, 1] or NaN, the integer counterpart of the NaN returned by the
+// continuous quantiles. lo must be non-negative. The search
+// starts from the Cornish-Fisher approximation with the given mean, standard
+// deviation and skewness, moves outwards in doubling steps to bracket k and
+// then bisects, so that it takes O(log) evaluations of cdf even far out in
+// the tails.
+func discreteQuantile(p float64, lo, hi int, mean, sd, skew float64, cdf func(int) float64) int {
+	if !(p >= 0 && p <= 1) {
+		return -1
+	}
+	if p == 1 {
+		return hi
+	}
+	// fuzz against rounding in cdf, as R does
+	p *= 1 - 64*machEp
+
+	z := stdNormalQuantile(p)
+	g := math.Floor(mean + sd*(z+skew*(z*z-1)/6) + 0.5)
+	guess := lo
+	switch {
+	case math.IsNaN(g) || g <= float64(lo):
+	case g >= float64(hi):
+		guess = hi
+	default:
+		guess = int(g)
+	}
+
+	// bracket k in (a, b]
+	var a, b int
+	if cdf(guess) >= p {
+		b = guess
+		for step := 1; ; step *= 2 {
+			if step > guess-lo {
+				a = lo - 1
+				break
+			}
+			a = guess - step
+			if cdf(a) < p {
+				break
+			}
+			b = a
+		}
+	} else {
+		a = guess
+		for step := 1; ; step *= 2 {
+			if step >= hi-guess {
+				b = hi
+				break
+			}
+			b = guess + step
+			if cdf(b) >= p {
+				break
+			}
+			a = b
+		}
+	}
+	for b-a > 1 {
+		m := a + (b-a)/2
+		if cdf(m) >= p {
+			b = m
+		} else {
+			a = m
+		}
+	}
+	return b
+}
@@ -79,6 +79,704 @@ func TestBinomial(t *testing.T) {
 	}
 }
 
+func TestNormal(t *testing.T) {
+	src := rand.NewSource(1)
+
+	normal := NewNormal(50, 10, src)
+	err := testContinuousPDF(normal)
+	if err != nil {
+		t.Error(err)
+	}
+
+	// the tails of the standard normal, from the ziggurat base layer
+	std := NewNormal(0, 1, src)
+	n := 1000000
+	tail := 0
+	for i := 0; i < n; i++ {
+		if math.Abs(std.Float64()) > zigR {
+			tail++
+		}
+	}
+	expected := 2 * std.Cdf(-zigR) * float64(n)
+	if math.Abs(float64(tail)-expected) > 5*math.Sqrt(expected) {
+		t.Errorf("%d values beyond %g, expected %g", tail, zigR, expected)
+	}
+
+	for _, c := range []struct{ x, cdf float64 }{
+		{0, 0.5},
+		{1.96, 0.97500210485177952},
+		{-1, 0.15865525393145705},
+		{-10, 7.6198530241605269e-24},
+		{-37.5, 4.605353e-308},
+	} {
+		p := std.Cdf(c.x)
+		if math.Abs(p-c.cdf) > 1e-14*c.cdf && c.x > -37 || math.Abs(p-c.cdf) > 1e-6*c.cdf {
+			t.Errorf("Cdf(%g) = %g, expected %g", c.x, p, c.cdf)
+		}
+	}
+	if q := std.Quantile(0.975); math.Abs(q-1.959963984540054) > 1e-15 {
+		t.Errorf("Quantile(0.975) = %.17g", q)
+	}
+	// Quantile inverts Cdf in all three regions of AS241
+	for _, p := range []float64{1e-300, 1e-20, 1e-10, 0.001, 0.05, 0.3, 0.5, 0.7, 0.9, 0.99} {
+		x := normal.Quantile(p)
+		if got := normal.Cdf(x); math.Abs(got-p) > 1e-12*p {
+			t.Errorf("Cdf(Quantile(%g)) = %g", p, got)
+		}
+	}
+	if math.Abs(normal.LogPdf(42)-math.Log(normal.Pdf(42))) > 1e-14 {
+		t.Errorf("LogPdf(42) = %g, expected %g", normal.LogPdf(42), math.Log(normal.Pdf(42)))
+	}
+}
+
+// scaled multiplies a distribution on [0, 1] by 100, to test it on the bins of testContinuousPDF.
+type scaled struct {
+	ContinuousDistribution
+}
+
+func (s scaled) Cdf(x float64) float64 { return s.ContinuousDistribution.Cdf(x / 100) }
+
+func (s scaled) Float64() float64 { return 100 * s.ContinuousDistribution.Float64() }
+
+func TestGamma(t *testing.T) {
+	src := rand.NewSource(1)
+
+	for _, c := range []struct{ shape, rate float64 }{{9, 0.5}, {0.5, 0.1}, {1, 0.2}} {
+		gamma := NewGamma(c.shape, c.rate, src)
+		err := testContinuousPDF(gamma)
+		if err != nil {
+			t.Errorf("Gamma(%g, %g): %v", c.shape, c.rate, err)
+		}
+		testMoments(t, gamma, gamma.Mean(), gamma.Variance())
+		testQuantile(t, gamma, gamma.Quantile)
+	}
+
+	// Gamma(1, rate) is the exponential distribution
+	gamma := NewGamma(1, 2.3, src)
+	exp := NewExponential(2.3, src)
+	for _, x := range []float64{0, 0.1, 1, 5} {
+		if math.Abs(gamma.Cdf(x)-exp.Cdf(x)) > 1e-15 || math.Abs(gamma.Pdf(x)-exp.Pdf(x)) > 1e-14 {
+			t.Errorf("Gamma(1, 2.3) at %g: %g, %g, expected %g, %g", x, gamma.Cdf(x), gamma.Pdf(x), exp.Cdf(x), exp.Pdf(x))
+		}
+	}
+
+	chi := NewChiSquared(10, src)
+	err := testContinuousPDF(chi)
+	if err != nil {
+		t.Error(err)
+	}
+	testMoments(t, chi, chi.Mean(), chi.Variance())
+	testQuantile(t, chi, chi.Quantile)
+	// R: qchisq(0.95, 1); for even k, P(30) = 1 - exp(-15) * sum over j < 5 of 15^j / j!
+	if q := NewChiSquared(1, src).Quantile(0.95); math.Abs(q-3.841458820694124) > 1e-12 {
+		t.Errorf("chi-squared quantile %.15g, expected 3.841458820694124", q)
+	}
+	if p := chi.Cdf(30); math.Abs(p-0.9991433587892247) > 1e-14 {
+		t.Errorf("chi-squared cdf %.15g, expected 0.9991433587892247", p)
+	}
+	if d := chi.Pdf(30) - NewGamma(5, 0.5, src).Pdf(30); math.Abs(d) > 1e-18 {
+		t.Errorf("chi-squared pdf differs from gamma by %g", d)
+	}
+}
+
+func TestBeta(t *testing.T) {
+	src := rand.NewSource(1)
+
+	for _, c := range []struct{ alpha, beta float64 }{{2, 3}, {0.5, 0.5}, {20, 1}} {
+		beta := NewBeta(c.alpha, c.beta, src)
+		err := testContinuousPDF(scaled{beta})
+		if err != nil {
+			t.Errorf("Beta(%g, %g): %v", c.alpha, c.beta, err)
+		}
+		testMoments(t, beta, beta.Mean(), beta.Variance())
+		testQuantile(t, beta, beta.Quantile)
+	}
+
+	beta := NewBeta(2, 3, src)
+	// I(0.3; 2, 3) = sum over j = 2..4 of C(4, j) 0.3^j 0.7^(4-j)
+	if p := beta.Cdf(0.3); math.Abs(p-0.3483) > 1e-15 {
+		t.Errorf("Cdf(0.3) = %.17g, expected 0.3483", p)
+	}
+	if d := beta.Pdf(0.3); math.Abs(d-12*0.3*0.49) > 1e-14 {
+		t.Errorf("Pdf(0.3) = %g, expected %g", d, 12*0.3*0.49)
+	}
+	if d := NewBeta(1, 3, src).Pdf(0); math.Abs(d-3) > 1e-14 {
+		t.Errorf("Beta(1, 3) Pdf(0) = %g, expected 3", d)
+	}
+
+	// for small shapes both gamma variates underflow, and the mass sits
+	// at the ends of [0, 1]
+	tiny := NewBeta(0.001, 0.001, src)
+	upper := 0
+	for i := 0; i < 10000; i++ {
+		x := tiny.Float64()
+		if !(x >= 0 && x <= 1) {
+			t.Fatalf("Beta(0.001, 0.001) sample %g", x)
+		}
+		if x > 0.5 {
+			upper++
+		}
+	}
+	if upper < 4800 || upper > 5200 {
+		t.Errorf("Beta(0.001, 0.001): %d of 10000 samples above 0.5", upper)
+	}
+	small := NewBeta(0.01, 0.02, src)
+	testMoments(t, small, small.Mean(), small.Variance())
+}
+
+// testMoments compares the sample mean and variance of dd with mean and variance.
+func testMoments(t *testing.T, dd ContinuousDistribution, mean, variance float64) {
+	n := 100000
+	var m, ss float64
+	for i := 0; i < n; i++ {
+		x := dd.Float64()
+		d := x - m
+		m += d / float64(i+1)
+		ss += d * (x - m)
+	}
+	v := ss / float64(n-1)
+	if math.Abs(m-mean) > 5*math.Sqrt(variance/float64(n)) || math.Abs(v-variance) > 0.05*variance {
+		t.Errorf("sample mean %g and variance %g, expected %g and %g", m, v, mean, variance)
+	}
+}
+
+// testQuantile checks that quantile inverts the Cdf of dd.
+func testQuantile(t *testing.T, dd ContinuousDistribution, quantile func(float64) float64) {
+	for _, p := range []float64{1e-10, 0.001, 0.1, 0.5, 0.9, 0.999} {
+		x := quantile(p)
+		if got := dd.Cdf(x); math.Abs(got-p) > 1e-10*p {
+			t.Errorf("Cdf(Quantile(%g)) = %g", p, got)
+		}
+	}
+}
+
+func TestStudentT(t *testing.T) {
+	src := rand.NewSource(1)
+
+	st := NewStudentT(5, src)
+	st.Mu, st.Sigma = 50, 8
+	err := testContinuousPDF(st)
+	if err != nil {
+		t.Error(err)
+	}
+	testMoments(t, st, st.Mean(), st.Variance())
+	testQuantile(t, st, st.Quantile)
+
+	// Nu = 1 is the Cauchy distribution, Cdf(x) = 1/2 + atan(x)/pi, and
+	// Nu = 2 has Cdf(-x) = 1 / (sqrt(2+x^2) * (sqrt(2+x^2) + x)) for x > 0
+	cauchy := NewStudentT(1, src)
+	two := NewStudentT(2, src)
+	for _, x := range []float64{1e-8, 0.3, 1, 10, 1e4, 1e10} {
+		want := math.Atan(1/x) / math.Pi
+		if p := cauchy.Cdf(-x); math.Abs(p-want) > 1e-14*want {
+			t.Errorf("Cauchy Cdf(%g) = %g, expected %g", -x, p, want)
+		}
+		if q := cauchy.Survival(x); q != cauchy.Cdf(-x) {
+			t.Errorf("Cauchy Survival(%g) = %g, expected %g", x, q, cauchy.Cdf(-x))
+		}
+		r := math.Sqrt(2 + x*x)
+		want = 1 / (r * (r + x))
+		if p := two.Cdf(-x); math.Abs(p-want) > 1e-14*want {
+			t.Errorf("t(2) Cdf(%g) = %g, expected %g", -x, p, want)
+		}
+		if d, want := cauchy.Pdf(x), 1/(math.Pi*(1+x*x)); math.Abs(d-want) > 1e-14*want {
+			t.Errorf("Cauchy Pdf(%g) = %g, expected %g", x, d, want)
+		}
+	}
+	if q := cauchy.Quantile(0.995); math.Abs(q-math.Tan(0.495*math.Pi)) > 1e-12*q {
+		t.Errorf("Cauchy Quantile(0.995) = %.15g, expected %.15g", q, math.Tan(0.495*math.Pi))
+	}
+
+	// upper critical values from a published table of the t distribution
+	for _, c := range []struct{ nu, p, q float64 }{
+		{1, 0.95, 6.314}, {4, 0.975, 2.776}, {10, 0.975, 2.228},
+		{30, 0.95, 1.697}, {30, 0.9995, 3.646}, {120, 0.99, 2.358},
+	} {
+		if q := NewStudentT(c.nu, src).Quantile(c.p); math.Abs(q-c.q) > 5e-4 {
+			t.Errorf("t(%g) Quantile(%g) = %g, expected %g", c.nu, c.p, q, c.q)
+		}
+	}
+
+	// large Nu approaches the normal distribution, smoothly across the
+	// approximation, which loses some relative accuracy far in the tail
+	normal := NewNormal(0, 1, src)
+	below, above := NewStudentT(studentTLargeNu, src), NewStudentT(studentTLargeNu+1, src)
+	for _, x := range []float64{-30, -8, -2, -0.1} {
+		if p, q := below.Cdf(x), above.Cdf(x); math.Abs(p-q) > 1e-6*p*math.Max(1, x*x/4) {
+			t.Errorf("Cdf(%g) = %g for nu = 4e5, %g for nu = 4e5+1", x, p, q)
+		}
+		if p := NewStudentT(math.Inf(1), src).Cdf(x); p != normal.Cdf(x) {
+			t.Errorf("Cdf(%g) = %g for infinite nu, expected %g", x, p, normal.Cdf(x))
+		}
+		if d, want := NewStudentT(1e16, src).Pdf(x), normal.Pdf(x); math.Abs(d-want) > 1e-10*want {
+			t.Errorf("Pdf(%g) = %g for nu = 1e16, expected %g", x, d, want)
+		}
+	}
+	for _, p := range []float64{1e-100, 1e-5, 0.2, 0.7} {
+		for _, nu := range []float64{1e6, 1e15} {
+			d := NewStudentT(nu, src)
+			if got := d.Cdf(d.Quantile(p)); math.Abs(got-p) > 1e-12*p {
+				t.Errorf("nu = %g: Cdf(Quantile(%g)) = %g", nu, p, got)
+			}
+		}
+	}
+}
+
+func TestFisherF(t *testing.T) {
+	src := rand.NewSource(1)
+
+	f := NewFisherF(5, 10, src)
+	err := testContinuousPDF(scaled{f})
+	if err != nil {
+		t.Error(err)
+	}
+	testMoments(t, f, f.Mean(), f.Variance())
+	testQuantile(t, f, f.Quantile)
+
+	// F(2, d2) has Survival(x) = (1 + 2x/d2)^(-d2/2)
+	f2 := NewFisherF(2, 7, src)
+	for _, x := range []float64{1e-6, 0.5, 3, 100, 1e6} {
+		want := math.Pow(1+2*x/7, -3.5)
+		if q := f2.Survival(x); math.Abs(q-want) > 1e-13*want {
+			t.Errorf("F(2, 7) Survival(%g) = %g, expected %g", x, q, want)
+		}
+		if q := f2.SurvivalQuantile(want); want < 0.5 && math.Abs(q-x) > 1e-10*x {
+			t.Errorf("F(2, 7) SurvivalQuantile(%g) = %g, expected %g", want, q, x)
+		}
+		if d, want := f2.Pdf(x), math.Pow(1+2*x/7, -4.5); math.Abs(d-want) > 1e-13*want {
+			t.Errorf("F(2, 7) Pdf(%g) = %g, expected %g", x, d, want)
+		}
+	}
+	if d := f2.Pdf(0); math.Abs(d-1) > 1e-14 {
+		t.Errorf("F(2, 7) Pdf(0) = %g, expected 1", d)
+	}
+
+	// the variance is infinite for 2 < D2 <= 4 and undefined below
+	if v := NewFisherF(5, 3, src).Variance(); !math.IsInf(v, 1) {
+		t.Errorf("F(5, 3) Variance = %g, expected +Inf", v)
+	}
+	if v := NewFisherF(5, 2, src).Variance(); !math.IsNaN(v) {
+		t.Errorf("F(5, 2) Variance = %g, expected NaN", v)
+	}
+
+	// F(1, nu) is the square of t(nu)
+	st := NewStudentT(9, src)
+	f19 := NewFisherF(1, 9, src)
+	for _, x := range []float64{0.2, 2, 40} {
+		if q, want := f19.Survival(x*x), 2*st.Survival(x); math.Abs(q-want) > 1e-13*want {
+			t.Errorf("F(1, 9) Survival(%g) = %g, expected %g", x*x, q, want)
+		}
+	}
+
+	// upper 5% and 1% points from a published table of the F distribution
+	for _, c := range []struct{ d1, d2, p, q float64 }{
+		{1, 10, 0.95, 4.96}, {5, 10, 0.95, 3.33}, {3, 20, 0.95, 3.10},
+		{2, 20, 0.99, 5.85}, {10, 60, 0.99, 2.63},
+	} {
+		if q := NewFisherF(c.d1, c.d2, src).Quantile(c.p); math.Abs(q-c.q) > 5e-3 {
+			t.Errorf("F(%g, %g) Quantile(%g) = %g, expected %g", c.d1, c.d2, c.p, q, c.q)
+		}
+	}
+}
+
+func TestDiscreteCdf(t *testing.T) {
+	src := rand.NewSource(1)
+
+	// against summation of the Pdf
+	for _, dd := range []interface {
+		DiscreteDistricution
+		Survival(k int) float64
+		Quantile(p float64) int
+	}{NewBinomial(40, 0.3, src), NewBinomial(7, 0.9, src), NewPoisson(3.5, src), NewPoisson(60, src)} {
+		sum := 0.0
+		for k := -1; k < 150; k++ {
+			if k >= 0 {
+				sum += dd.Pdf(k)
+			}
+			p, q := dd.Cdf(k), dd.Survival(k)
+			if math.Abs(p-sum) > 1e-12 || math.Abs(p+q-1) > 1e-12 {
+				t.Errorf("%T: Cdf(%d) = %g, Survival(%d) = %g, expected %g", dd, k, p, k, q, sum)
+				break
+			}
+			if k >= 0 && dd.Pdf(k) > 1e-300 && p < 1-1e-10 {
+				if got := dd.Quantile(p); got != k {
+					t.Errorf("%T: Quantile(Cdf(%d)) = %d", dd, k, got)
+				}
+			}
+		}
+		checkQuantile(t, dd.Cdf, dd.Quantile)
+	}
+
+	// large N and Mean, where summation is impractical
+	binomial := NewBinomial(1000000000, 0.25, src)
+	poisson := NewPoisson(1e9, src)
+	checkQuantile(t, binomial.Cdf, binomial.Quantile)
+	checkQuantile(t, poisson.Cdf, poisson.Quantile)
+	// close to the normal distribution, with a continuity correction
+	sd := math.Sqrt(1e9 * 0.25 * 0.75)
+	if p, want := binomial.Cdf(250000000+int(2*sd)), NewNormal(0, 1, src).Cdf((math.Floor(2*sd)+0.5)/sd); math.Abs(p-want) > 1e-4 {
+		t.Errorf("Binomial Cdf %g, expected about %g", p, want)
+	}
+	// the upper tail keeps its relative accuracy
+	small := NewBinomial(100, 0.1, src)
+	if q, want := small.Survival(99), math.Pow(0.1, 100); math.Abs(q-want) > 1e-13*want {
+		t.Errorf("Binomial Survival(99) = %g, expected %g", q, want)
+	}
+	if q, want := NewPoisson(1, src).Survival(30), 1/math.Exp(1)/math.Gamma(32)*1.0322; q < want || q > want*1.0001 {
+		t.Errorf("Poisson Survival(30) = %g, expected about %g", q, want)
+	}
+
+	if k := NewPoisson(0, src).Quantile(0.5); k != 0 {
+		t.Errorf("Poisson(0) Quantile(0.5) = %d, expected 0", k)
+	}
+	if k := small.Quantile(1); k != 100 {
+		t.Errorf("Binomial Quantile(1) = %d, expected 100", k)
+	}
+	if k := small.Quantile(0); k != 0 {
+		t.Errorf("Binomial Quantile(0) = %d, expected 0", k)
+	}
+}
+
+// checkQuantile checks that quantile(p) is the smallest k with cdf(k) >= p,
+// and -1 for p outside [0, 1].
+func checkQuantile(t *testing.T, cdf func(int) float64, quantile func(float64) int) {
+	for _, p := range []float64{1e-12, 1e-5, 0.01, 0.3, 0.5, 0.77, 0.99, 1 - 1e-9} {
+		k := quantile(p)
+		if cdf(k) < p*(1-1e-13) || cdf(k-1) >= p {
+			t.Errorf("Quantile(%g) = %d with Cdf %g, %g", p, k, cdf(k-1), cdf(k))
+		}
+	}
+	for _, p := range []float64{-0.1, 1.5, math.NaN()} {
+		if k := quantile(p); k != -1 {
+			t.Errorf("Quantile(%g) = %d, expected -1", p, k)
+		}
+	}
+}
+
+// shifted moves a discrete distribution down by offset, to test it on the bins of testDiscretePDF.
+type shifted struct {
+	DiscreteDistricution
+	offset int
+}
+
+func (s shifted) Int() int { return s.DiscreteDistricution.Int() - s.offset }
+
+func (s shifted) Pdf(k int) float64 { return s.DiscreteDistricution.Pdf(k + s.offset) }
+
+func TestDiscreteDistributions(t *testing.T) {
+	src := rand.NewSource(1)
+
+	for _, c := range []struct {
+		dd interface {
+			DiscreteDistricution
+			Survival(k int) float64
+			Quantile(p float64) int
+		}
+		offset int
+	}{
+		{NewGeometric(0.1, src), 0},
+		{NewGeometric(0.9, src), 0},
+		{NewNegativeBinomial(3.5, 0.2, src), 0},
+		{NewNegativeBinomialMean(40, 2, src), 0},
+		{NewHypergeometric(50, 10, 8, src), 0},     // inversion
+		{NewHypergeometric(200, 150, 120, src), 0}, // H2PE after both complements
+		{NewHypergeometric(2000, 300, 800, src), 70},
+		{NewHypergeometric(2000, 1700, 1200, src), 970},
+		{NewZipf(1.1, 50, src), 0},
+		{NewZipf(0.7, 1000, src), 0},
+		{NewZeta(2.5, src), 0},
+	} {
+		err := testDiscretePDF(shifted{c.dd, c.offset})
+		if err != nil {
+			t.Errorf("%T %v: %v", c.dd, c.dd, err)
+		}
+
+		// Cdf and Survival against summation of the Pdf
+		sum := c.dd.Cdf(c.offset - 1)
+		for k := c.offset - 1; k < c.offset+150; k++ {
+			if k >= c.offset {
+				sum += c.dd.Pdf(k)
+			}
+			p, q := c.dd.Cdf(k), c.dd.Survival(k)
+			// the Pdf of large populations differs by log factorials, with errors of about 1e-12
+			if math.Abs(p-sum) > 1e-11 || math.Abs(p+q-1) > 1e-12 {
+				t.Errorf("%T: Cdf(%d) = %g, Survival(%d) = %g, expected %g", c.dd, k, p, k, q, sum)
+				break
+			}
+		}
+		checkQuantile(t, c.dd.Cdf, c.dd.Quantile)
+	}
+
+	// NegativeBinomial with one success is Geometric
+	geometric := NewGeometric(0.3, src)
+	nb := NewNegativeBinomial(1, 0.3, src)
+	for k := 0; k < 20; k++ {
+		if math.Abs(nb.Pdf(k)-geometric.Pdf(k)) > 1e-15 || math.Abs(nb.Cdf(k)-geometric.Cdf(k)) > 1e-15 {
+			t.Errorf("NegativeBinomial(1, 0.3) at %d: %g, %g, expected %g, %g", k, nb.Pdf(k), nb.Cdf(k), geometric.Pdf(k), geometric.Cdf(k))
+		}
+	}
+	// the mean parameterization, variance mean + mean^2/r
+	nb = NewNegativeBinomialMean(40, 2, src)
+	if m, v := nb.R*(1-nb.P)/nb.P, nb.R*(1-nb.P)/(nb.P*nb.P); math.Abs(m-40) > 1e-12 || math.Abs(v-840) > 1e-9 {
+		t.Errorf("NegativeBinomial mean %g and variance %g, expected 40 and 840", m, v)
+	}
+
+	// hypergeometric: C(5, 2) * C(7, 2) / C(12, 4) = 210/495
+	if p := NewHypergeometric(12, 5, 4, src).Pdf(2); math.Abs(p-210.0/495) > 1e-14 {
+		t.Errorf("Hypergeometric Pdf(2) = %g, expected %g", p, 210.0/495)
+	}
+	// the tails are exact where the normal approximation fails
+	h := NewHypergeometric(1000000, 500000, 1000, src)
+	if q := h.Survival(700); q <= 0 || q > 1e-30 || q < h.Pdf(701) {
+		t.Errorf("Hypergeometric Survival(700) = %g", q)
+	}
+	checkQuantile(t, h.Cdf, h.Quantile)
+
+	// zeta(2) = pi^2/6, and a finite harmonic sum
+	zeta := NewZeta(2, src)
+	if p := zeta.Pdf(1); math.Abs(p-6/(math.Pi*math.Pi)) > 1e-15 {
+		t.Errorf("zeta(2) Pdf(1) = %.17g, expected %.17g", p, 6/(math.Pi*math.Pi))
+	}
+	h10 := 0.0
+	for j := 1; j <= 10; j++ {
+		h10 += 1 / float64(j)
+	}
+	if p := NewZipf(1, 10, src).Cdf(3); math.Abs(p-(1+0.5+1.0/3)/h10) > 1e-15 {
+		t.Errorf("Zipf(1, 10) Cdf(3) = %g, expected %g", p, (1+0.5+1.0/3)/h10)
+	}
+	// the Euler-Maclaurin sum against direct summation
+	for _, c := range []struct{ s, a, b float64 }{{1, 1, 1e5}, {0.5, 30, 2e4}, {1.5, 7, 1e5}, {3, 20, 21}} {
+		direct := 0.0
+		for j := c.b; j >= c.a; j-- {
+			direct += math.Pow(j, -c.s)
+		}
+		if got := powerSum(c.s, c.a, c.b); math.Abs(got-direct) > 1e-13*direct {
+			t.Errorf("powerSum(%g, %g, %g) = %.17g, expected %.17g", c.s, c.a, c.b, got, direct)
+		}
+	}
+	if q := zeta.Survival(1e8); math.Abs(q-1/(1e8+0.5)/zeta.norm) > 1e-10*q {
+		t.Errorf("zeta(2) Survival(1e8) = %g, expected about %g", q, 1/(1e8+0.5)/zeta.norm)
+	}
+}
+
+func TestCategorical(t *testing.T) {
+	src := rand.NewSource(1)
+	rng := rand.New(src)
+
+	weights := make([]float64, 60)
+	for i := range weights {
+		if i%7 != 3 {
+			weights[i] = rng.ExpFloat64()
+		}
+	}
+	c := NewCategorical(weights, src)
+	testAlias(t, c)
+	err := testDiscretePDF(c)
+	if err != nil {
+		t.Error(err)
+	}
+	sum := 0.0
+	for k := -1; k < len(weights)+1; k++ {
+		if k >= 0 {
+			sum += c.Pdf(k)
+		}
+		if p := c.Cdf(k); math.Abs(p-sum) > 1e-14 {
+			t.Errorf("Cdf(%d) = %g, expected %g", k, p, sum)
+		}
+	}
+
+	// updates: draws from the Fenwick tree until the alias table is rebuilt
+	for i := 0; i < 20; i++ {
+		c.SetWeight(rng.Intn(len(weights)), 2*rng.Float64())
+	}
+	c.SetWeight(3, 5)
+	c.SetWeight(10, 0)
+	if c.stale != 0 {
+		t.Fatalf("alias table is not out of date after an update")
+	}
+	err = testDiscretePDF(c)
+	if err != nil {
+		t.Error(err)
+	}
+	if c.stale != -1 {
+		t.Errorf("alias table was not rebuilt")
+	}
+	testAlias(t, c)
+	total := 0.0
+	for k := 0; k < c.Len(); k++ {
+		total += c.Weight(k)
+	}
+	if math.Abs(c.Total()-total) > 1e-13*total || c.Pdf(3) != 5/c.Total() {
+		t.Errorf("total weight %g, expected %g", c.Total(), total)
+	}
+	// every draw from the tree is in the support
+	c.SetWeight(0, 0)
+	for i := 0; i < 1000; i++ {
+		if k := c.Int(); c.Weight(k) == 0 {
+			t.Fatalf("drew category %d of weight zero", k)
+		}
+	}
+
+	// a single category
+	if k := NewCategorical([]float64{2}, src).Int(); k != 0 {
+		t.Errorf("drew %d from one category", k)
+	}
+
+	// zeroing all but one weight, with rounding left in the tree by the
+	// updates, draws only the remaining category
+	for k := 0; k < c.Len(); k++ {
+		c.SetWeight(k, rng.Float64()/3)
+	}
+	for k := 1; k < c.Len(); k++ {
+		c.SetWeight(k, 0)
+	}
+	for i := 0; i < 2*c.Len(); i++ {
+		if k := c.Int(); k != 0 {
+			t.Fatalf("drew category %d of weight zero", k)
+		}
+	}
+	// zeroing the last one panics and leaves the distribution unchanged
+	w0 := c.Weight(0)
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("no panic when all the weights are zero")
+			}
+		}()
+		c.SetWeight(0, 0)
+	}()
+	if c.Weight(0) != w0 || c.Total() <= 0 || c.Pdf(0) != w0/c.Total() {
+		t.Errorf("weight %g and total %g after a failed update, expected %g", c.Weight(0), c.Total(), w0)
+	}
+	if k := c.Int(); k != 0 {
+		t.Errorf("drew category %d of weight zero", k)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("no panic for all zero weights")
+			}
+		}()
+		NewCategorical([]float64{0, 0}, src)
+	}()
+}
+
+// testAlias checks that the alias table of c gives each category its probability.
+func testAlias(t *testing.T, c *Categorical) {
+	n := c.Len()
+	p := make([]float64, n)
+	for i := 0; i < n; i++ {
+		p[i] += c.prob[i] / float64(n)
+		p[c.alias[i]] += (1 - c.prob[i]) / float64(n)
+	}
+	for i := range p {
+		if math.Abs(p[i]-c.Pdf(i)) > 1e-14 {
+			t.Errorf("alias probability of %d: %g, expected %g", i, p[i], c.Pdf(i))
+		}
+	}
+}
+
+// marginal draws one count of a Multinomial, with a Binomial distribution.
+type marginal struct {
+	*Binomial
+	m *Multinomial
+	i int
+}
+
+func (m marginal) Int() int { return m.m.Ints()[m.i] }
+
+func TestMultinomial(t *testing.T) {
+	src := rand.NewSource(1)
+
+	p := []float64{2, 0, 5, 1, 2}
+	m := NewMultinomial(60, p, src)
+	for _, i := range []int{0, 2, 4} {
+		err := testDiscretePDF(marginal{NewBinomial(60, m.P[i], src), m, i})
+		if err != nil {
+			t.Errorf("count %d: %v", i, err)
+		}
+	}
+	for i := 0; i < 1000; i++ {
+		x := m.Ints()
+		n := 0
+		for _, k := range x {
+			n += k
+		}
+		if n != 60 || x[1] != 0 {
+			t.Fatalf("counts %v", x)
+		}
+	}
+
+	// the Pdf sums to one over all the counts of 4 draws from 3 categories
+	m = NewMultinomial(4, []float64{0.2, 0.3, 0.5}, src)
+	sum := 0.0
+	for a := 0; a <= 4; a++ {
+		for b := 0; a+b <= 4; b++ {
+			sum += m.Pdf([]int{a, b, 4 - a - b})
+		}
+	}
+	if math.Abs(sum-1) > 1e-14 {
+		t.Errorf("Pdf sums to %g", sum)
+	}
+	// 4!/(1! 1! 2!) * 0.2 * 0.3 * 0.25
+	if d := m.Pdf([]int{1, 1, 2}); math.Abs(d-0.18) > 1e-15 {
+		t.Errorf("Pdf(1, 1, 2) = %g, expected 0.18", d)
+	}
+	if d := m.Pdf([]int{1, 1, 1}); d != 0 {
+		t.Errorf("Pdf(1, 1, 1) = %g, expected 0", d)
+	}
+}
+
+// component draws one coordinate of a Dirichlet, with a Beta distribution.
+type component struct {
+	*Beta
+	d *Dirichlet
+	i int
+}
+
+func (c component) Float64() float64 { return c.d.Float64s()[c.i] }
+
+func TestDirichlet(t *testing.T) {
+	src := rand.NewSource(1)
+
+	alpha := []float64{0.5, 3, 1.5}
+	d := NewDirichlet(alpha, src)
+	for i, a := range alpha {
+		err := testContinuousPDF(scaled{component{NewBeta(a, 5-a, src), d, i}})
+		if err != nil {
+			t.Errorf("component %d: %v", i, err)
+		}
+	}
+	if m := d.Mean(); math.Abs(m[1]-0.6) > 1e-15 {
+		t.Errorf("mean %v", m)
+	}
+
+	// two components have a Beta distribution
+	d2 := NewDirichlet([]float64{2, 3}, src)
+	beta := NewBeta(2, 3, src)
+	for _, x := range []float64{0.1, 0.5, 0.8} {
+		if p, want := d2.Pdf([]float64{x, 1 - x}), beta.Pdf(x); math.Abs(p-want) > 1e-13*want {
+			t.Errorf("Pdf(%g) = %g, expected %g", x, p, want)
+		}
+	}
+
+	// small parameters do not underflow
+	tiny := NewDirichlet([]float64{0.001, 0.001, 0.001}, src)
+	for i := 0; i < 1000; i++ {
+		x := tiny.Float64s()
+		sum := 0.0
+		for _, v := range x {
+			if math.IsNaN(v) || v < 0 {
+				t.Fatalf("point %v", x)
+			}
+			sum += v
+		}
+		if math.Abs(sum-1) > 1e-15 {
+			t.Fatalf("point %v sums to %g", x, sum)
+		}
+	}
+}
+
 func BenchmarkPoisson(b *testing.B) {
 	b.StopTimer()
 	src := rand.NewSource(1)
@@ -103,6 +801,51 @@ func BenchmarkBinomial(b *testing.B) {
 	}
 }
 
+func BenchmarkGamma(b *testing.B) {
+	b.StopTimer()
+	src := rand.NewSource(1)
+	gamma := NewGamma(2.5, 1, src)
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		gamma.Float64()
+	}
+}
+
+func BenchmarkHypergeometric(b *testing.B) {
+	b.StopTimer()
+	src := rand.NewSource(1)
+	h := NewHypergeometric(100000, 30000, 5000, src)
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		h.Int()
+	}
+}
+
+func BenchmarkCategorical(b *testing.B) {
+	b.StopTimer()
+	src := rand.NewSource(1)
+	rng := rand.New(src)
+	weights := make([]float64, 10000)
+	for i := range weights {
+		weights[i] = rng.Float64()
+	}
+	c := NewCategorical(weights, src)
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		c.Int()
+	}
+}
+
+func BenchmarkNormal(b *testing.B) {
+	b.StopTimer()
+	src := rand.NewSource(1)
+	normal := NewNormal(0, 1, src)
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		normal.Float64()
+	}
+}
+
 func testContinuousPDF(dd ContinuousDistribution) (err error) {
 	n := 100000
 	bins := 100
@@ -118,8 +861,9 @@ func testContinuousPDF(dd ContinuousDistribution) (err error) {
 		}
 	}
 
+	// expected probability of each unit bin [i, i+1)
 	for i := 0; i < bins; i++ {
-		p[i] = dd.Cdf(float64(i+1)) - dd.Cdf(float64(i)) - dd.Pdf(float64(i))
+		p[i] = dd.Cdf(float64(i+1)) - dd.Cdf(float64(i))
 	}
 
 	for i := 0; i < bins; i++ {
@@ -0,0 +1,207 @@
+//   Copyright (C) 2012 Mingzhi Lin
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS
+// OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package random
+
+import (
+	"github.com/mingzhi/gomath/specfunc"
+	"math"
+	"math/rand"
+)
+
+// A StudentT distribution with Nu degrees of freedom, location Mu and scale Sigma.
+// p(x) = Gamma((Nu+1)/2) / (Gamma(Nu/2) * sqrt(Nu*pi) * Sigma) * (1 + z^2/Nu)^(-(Nu+1)/2)
+// with z = (x - Mu) / Sigma. Nu = +Inf gives the Normal distribution.
+type StudentT struct {
+	Nu    float64 // degrees of freedom
+	Mu    float64 // location
+	Sigma float64 // scale
+
+	randomGenerator *rand.Rand // random number generator
+}
+
+// Above studentTLargeNu degrees of freedom, the t distribution is computed from
+// the normal approximation of Abramowitz and Stegun 26.7.8, as R does.
+const studentTLargeNu = 4e5
+
+// NewStudentT returns a standard StudentT distribution with nu degrees of freedom.
+// Set Mu and Sigma for other locations and scales.
+func NewStudentT(nu float64, src rand.Source) *StudentT {
+	if !(nu > 0) {
+		panic("Illegal argument for StudentT distribution: nu <= 0")
+	}
+	t := &StudentT{Nu: nu, Mu: 0, Sigma: 1}
+	t.randomGenerator = rand.New(src)
+	return t
+}
+
+// Cdf returns the cumulative distribution function.
+func (t StudentT) Cdf(x float64) float64 {
+	z := (x - t.Mu) / t.Sigma
+	return studentTTail(-z, t.Nu)
+}
+
+// Float64 returns a random number from the distribution,
+// as Z / sqrt(V/Nu) with Z standard normal and V ChiSquared(Nu).
+func (t StudentT) Float64() float64 {
+	z := stdNormal(t.randomGenerator)
+	if !math.IsInf(t.Nu, 1) {
+		v := 2 * gammaVariate(t.randomGenerator, t.Nu/2)
+		z /= math.Sqrt(v / t.Nu)
+	}
+	return t.Mu + t.Sigma*z
+}
+
+// LogPdf returns the logarithm of the probability distribution function.
+func (t StudentT) LogPdf(x float64) float64 {
+	z := (x - t.Mu) / t.Sigma
+	if math.IsInf(t.Nu, 1) {
+		return -0.5*z*z - math.Log(t.Sigma) - lnSqrt2Pi
+	}
+	// log(Gamma((Nu+1)/2) / Gamma(Nu/2)) - log(sqrt(Nu*pi))
+	c := lnGammaHalfRatio(t.Nu/2) - 0.5*math.Log(t.Nu*math.Pi)
+	return c - math.Log(t.Sigma) - (t.Nu+1)/2*math.Log1p(z*z/t.Nu)
+}
+
+// Mean returns the mean Mu, or NaN for Nu <= 1.
+func (t StudentT) Mean() float64 {
+	if t.Nu <= 1 {
+		return math.NaN()
+	}
+	return t.Mu
+}
+
+// Pdf returns the probability distribution function.
+func (t StudentT) Pdf(x float64) float64 {
+	return math.Exp(t.LogPdf(x))
+}
+
+// Quantile returns the inverse of the cumulative distribution function,
+// the x with Cdf(x) = p.
+func (t StudentT) Quantile(p float64) float64 {
+	switch {
+	case math.IsNaN(p) || p < 0 || p > 1:
+		return math.NaN()
+	case p == 0.5:
+		return t.Mu
+	}
+	// the upper quantile of the tail probability min(p, 1-p)
+	z := studentTUpperQuantile(math.Min(p, 1-p), t.Nu)
+	if p < 0.5 {
+		z = -z
+	}
+	return t.Mu + t.Sigma*z
+}
+
+// Seed uses the provided seed value to initialize the generator to a deterministic state.
+func (t *StudentT) Seed(seed int64) {
+	t.randomGenerator.Seed(seed)
+}
+
+// Survival returns the survival function 1 - Cdf(x),
+// accurate in the upper tail.
+func (t StudentT) Survival(x float64) float64 {
+	z := (x - t.Mu) / t.Sigma
+	return studentTTail(z, t.Nu)
+}
+
+// Variance returns the variance Sigma^2 * Nu / (Nu - 2),
+// +Inf for 1 < Nu <= 2, or NaN for Nu <= 1.
+func (t StudentT) Variance() float64 {
+	switch {
+	case t.Nu <= 1:
+		return math.NaN()
+	case t.Nu <= 2:
+		return math.Inf(1)
+	case math.IsInf(t.Nu, 1):
+		return t.Sigma * t.Sigma
+	}
+	return t.Sigma * t.Sigma * t.Nu / (t.Nu - 2)
+}
+
+// studentTTail returns P(T > z) for the standard t distribution with nu
+// degrees of freedom.
+func studentTTail(z, nu float64) float64 {
+	switch {
+	case math.IsNaN(z):
+		return math.NaN()
+	case math.IsInf(z, 0):
+		if z > 0 {
+			return 0
+		}
+		return 1
+	case nu > studentTLargeNu:
+		v := 1 / (4 * nu)
+		if math.IsInf(nu, 1) {
+			v = 0
+		}
+		return 0.5 * math.Erfc(z*(1-v)/math.Sqrt(1+2*v*z*z)/math.Sqrt2)
+	}
+	// P(|T| > |z|) = I(nu/(nu+z^2); nu/2, 1/2). Close to the center, where
+	// nu/(nu+z^2) rounds to 1, it is the complement of I(z^2/(nu+z^2); 1/2, nu/2).
+	z2 := z * z
+	q := -1.0
+	if z2 < nu {
+		if c := specfunc.RegularizedBeta(z2/(nu+z2), 0.5, nu/2); c < 0.5 {
+			q = 1 - c
+		}
+	}
+	if q < 0 {
+		q = specfunc.RegularizedBeta(nu/(nu+z2), nu/2, 0.5)
+	}
+	if z > 0 {
+		return q / 2
+	}
+	return 1 - q/2
+}
+
+// studentTUpperQuantile returns the z > 0 with P(T > z) = q, for q < 0.5.
+func studentTUpperQuantile(q, nu float64) float64 {
+	if q == 0 {
+		return math.Inf(1)
+	}
+	if nu > studentTLargeNu {
+		// invert the normal approximation of studentTTail
+		v := 1 / (4 * nu)
+		if math.IsInf(nu, 1) {
+			v = 0
+		}
+		z := -stdNormalQuantile(q)
+		return z / math.Sqrt((1-v)*(1-v)-2*v*z*z)
+	}
+	if q < 0.25 {
+		x := specfunc.InvRegularizedBeta(2*q, nu/2, 0.5)
+		return math.Sqrt(nu * (1 - x) / x)
+	}
+	// close to the center, invert the complement to keep 1-x accurate
+	y := specfunc.InvRegularizedBeta(1-2*q, 0.5, nu/2)
+	return math.Sqrt(nu * y / (1 - y))
+}
+
+// lnGammaHalfRatio returns log(Gamma(a+1/2) / Gamma(a)), with an asymptotic
+// series for large a, where the difference of log gamma functions cancels.
+func lnGammaHalfRatio(a float64) float64 {
+	if a < 1e5 {
+		l1, _ := math.Lgamma(a + 0.5)
+		l2, _ := math.Lgamma(a)
+		return l1 - l2
+	}
+	return 0.5*math.Log(a) + math.Log1p(-1/(8*a)+1/(128*a*a))
+}
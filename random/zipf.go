@@ -0,0 +1,242 @@
+//   Copyright (C) 2012 Mingzhi Lin
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS
+// OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package random
+
+import (
+	"math"
+	"math/rand"
+)
+
+// A Zipf distribution on 1, 2, ..., N with exponent S,
+// p(k) = k^(-S) / H(N, S) with H(N, S) the sum of j^(-S) for j = 1..N.
+// N = 0 stands for the unbounded zeta distribution, p(k) = k^(-S) / zeta(S),
+// which needs S > 1.
+type Zipf struct {
+	S float64 // exponent
+	N int     // largest value, or 0 for the zeta distribution
+
+	randomGenerator *rand.Rand // random number generator
+
+	norm float64 // H(N, S) or zeta(S)
+
+	// constants of the rejection-inversion sampler
+	hX1, hN, s float64
+}
+
+// NewZipf returns a Zipf distribution on 1..n with exponent s > 0.
+func NewZipf(s float64, n int, src rand.Source) *Zipf {
+	if !(s > 0) || n < 1 {
+		panic("Illegal argument for Zipf distribution: s <= 0 or n < 1")
+	}
+	z := &Zipf{S: s, N: n}
+	z.randomGenerator = rand.New(src)
+	z.norm = powerSum(s, 1, float64(n))
+	z.hX1 = z.hIntegral(1.5) - 1
+	z.hN = z.hIntegral(float64(n) + 0.5)
+	z.s = 2 - z.hIntegralInverse(z.hIntegral(2.5)-z.h(2))
+	return z
+}
+
+// NewZeta returns a zeta distribution on all the positive integers with exponent s > 1.
+func NewZeta(s float64, src rand.Source) *Zipf {
+	if !(s > 1) {
+		panic("Illegal argument for zeta distribution: s <= 1")
+	}
+	z := &Zipf{S: s}
+	z.randomGenerator = rand.New(src)
+	z.norm = powerSum(s, 1, math.Inf(1))
+	return z
+}
+
+// Cdf returns the cumulative distribution function.
+func (z Zipf) Cdf(k int) (p float64) {
+	switch {
+	case k < 1:
+		return 0
+	case z.N > 0 && k >= z.N:
+		return 1
+	}
+	return powerSum(z.S, 1, float64(k)) / z.norm
+}
+
+// Int returns a random number from the distribution, by the rejection-inversion
+// method of Hörmann and Derflinger (1996) for finite N, and by the rejection
+// method of Devroye (1986, p. 551) for the zeta distribution; values beyond
+// 2^62 of the latter are rejected.
+func (z Zipf) Int() int {
+	r := z.randomGenerator
+	if z.N == 0 {
+		b := math.Pow(2, z.S-1)
+		for {
+			u := 1 - r.Float64() // in (0, 1]
+			v := r.Float64()
+			x := math.Floor(math.Pow(u, -1/(z.S-1)))
+			if x >= 1<<62 {
+				continue
+			}
+			t := math.Pow(1+1/x, z.S-1)
+			if v*x*(t-1)/(b-1) <= t/b {
+				return int(x)
+			}
+		}
+	}
+	for {
+		u := z.hN + r.Float64()*(z.hX1-z.hN)
+		x := z.hIntegralInverse(u)
+		k := int(x + 0.5)
+		if k < 1 {
+			k = 1
+		} else if k > z.N {
+			k = z.N
+		}
+		if float64(k)-x <= z.s || u >= z.hIntegral(float64(k)+0.5)-z.h(float64(k)) {
+			return k
+		}
+	}
+}
+
+// Pdf returns the probability distribution function.
+func (z Zipf) Pdf(k int) (p float64) {
+	if k < 1 || z.N > 0 && k > z.N {
+		return 0
+	}
+	return math.Pow(float64(k), -z.S) / z.norm
+}
+
+// Quantile returns the smallest k with Cdf(k) >= p,
+// N (the largest int for the zeta distribution) for p = 1,
+// or -1 if p is not in [0, 1].
+func (z Zipf) Quantile(p float64) int {
+	hi := z.N
+	if hi == 0 {
+		hi = maxInt
+	}
+	// the moments need not exist: search up from 1
+	return discreteQuantile(p, 1, hi, 1, 0, 0, z.Cdf)
+}
+
+// Seed uses the provided seed value to initialize the generator to a deterministic state.
+func (z *Zipf) Seed(seed int64) {
+	z.randomGenerator.Seed(seed)
+}
+
+// Survival returns the survival function, P(X > k) = 1 - Cdf(k),
+// accurate in the upper tail.
+func (z Zipf) Survival(k int) (p float64) {
+	switch {
+	case k < 1:
+		return 1
+	case z.N > 0 && k >= z.N:
+		return 0
+	}
+	b := math.Inf(1)
+	if z.N > 0 {
+		b = float64(z.N)
+	}
+	return powerSum(z.S, float64(k)+1, b) / z.norm
+}
+
+// h returns x^(-S), the hat function of rejection-inversion.
+func (z Zipf) h(x float64) float64 {
+	return math.Exp(-z.S * math.Log(x))
+}
+
+// hIntegral returns the integral of h, (x^(1-S) - 1) / (1-S),
+// or log(x) for S = 1.
+func (z Zipf) hIntegral(x float64) float64 {
+	logX := math.Log(x)
+	return expm1Ratio((1-z.S)*logX) * logX
+}
+
+// hIntegralInverse returns the inverse of hIntegral.
+func (z Zipf) hIntegralInverse(x float64) float64 {
+	t := x * (1 - z.S)
+	if t < -1 {
+		// limited to the domain of log1p by rounding
+		t = -1
+	}
+	return math.Exp(log1pRatio(t) * x)
+}
+
+// expm1Ratio returns expm1(x)/x, continuous at 0.
+func expm1Ratio(x float64) float64 {
+	if math.Abs(x) > 1e-8 {
+		return math.Expm1(x) / x
+	}
+	return 1 + x*0.5*(1+x/3*(1+0.25*x))
+}
+
+// log1pRatio returns log1p(x)/x, continuous at 0.
+func log1pRatio(x float64) float64 {
+	if math.Abs(x) > 1e-8 {
+		return math.Log1p(x) / x
+	}
+	return 1 - x*(0.5-x*(1.0/3-0.25*x))
+}
+
+// bernoulliRatios holds B(2j) / (2j)! for j = 1..8.
+var bernoulliRatios = [8]float64{
+	1.0 / 12, -1.0 / 720, 1.0 / 30240, -1.0 / 1209600,
+	1.0 / 47900160, -691.0 / 1307674368000, 1.0 / 74724249600,
+	-3617.0 / 10670622842880000,
+}
+
+// powerSum returns the sum of j^(-s) for the integers j from a to b,
+// where b may be +Inf for s > 1. The first terms up to 20 are added
+// directly and the rest by the Euler-Maclaurin formula, accurate to
+// about machine precision.
+func powerSum(s, a, b float64) float64 {
+	sum := 0.0
+	for ; a <= b && a < 20; a++ {
+		sum += math.Pow(a, -s)
+	}
+	if a > b {
+		return sum
+	}
+	fa := math.Pow(a, -s)
+	var fb, integral float64
+	switch {
+	case math.IsInf(b, 1):
+		integral = a * fa / (s - 1)
+	case s == 1:
+		fb = 1 / b
+		integral = math.Log(b / a)
+	default:
+		fb = math.Pow(b, -s)
+		integral = (b*fb - a*fa) / (1 - s)
+	}
+	sum += integral + (fa+fb)/2
+
+	// B(2j)/(2j)! * (f'(b) - f'(a)) for the odd derivatives of f(x) = x^(-s),
+	// f^(2j-1)(x) = -s*(s+1)*...*(s+2j-2) * x^(-s-2j+1)
+	c := s
+	ta, tb := fa/a, 0.0
+	if !math.IsInf(b, 1) {
+		tb = fb / b
+	}
+	for j, r := range bernoulliRatios {
+		sum += r * c * (ta - tb)
+		c *= (s + float64(2*j+1)) * (s + float64(2*j+2))
+		ta /= a * a
+		tb /= b * b
+	}
+	return sum
+}
@@ -0,0 +1,273 @@
+/*
+ *   Copyright (C) 2012 Mingzhi Lin
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining
+ * a copy of this software and associated documentation files (the "Software"),
+ * to deal in the Software without restriction, including without limitation
+ * the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ * and/or sell copies of the Software, and to permit persons to whom the
+ * Software is furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included
+ * in all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS
+ * OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+package specfunc
+
+import (
+	"math"
+)
+
+// LogBeta returns log(B(a, b)) = log(Gamma(a)*Gamma(b)/Gamma(a+b)) for a, b > 0.
+func LogBeta(a, b float64) float64 {
+	la, _ := math.Lgamma(a)
+	lb, _ := math.Lgamma(b)
+	lab, _ := math.Lgamma(a + b)
+	return la + lb - lab
+}
+
+// RegularizedBeta returns the regularized incomplete beta function
+//
+// I(x; a, b) = 1/B(a, b) * integral from 0 to x of t^(a-1) * (1-t)^(b-1) dt
+//
+// for a, b > 0 and 0 <= x <= 1. It is the cdf of a Beta(a, b) variable at x.
+// This is a port of incbet from the Cephes library, which uses a power
+// series for small b*x and one of two continued fractions otherwise.
+func RegularizedBeta(x, a, b float64) float64 {
+	switch {
+	case math.IsNaN(x) || math.IsNaN(a) || math.IsNaN(b) || a <= 0 || b <= 0 || x < 0 || x > 1:
+		return math.NaN()
+	case x == 0:
+		return 0
+	case x == 1:
+		return 1
+	}
+	if b*x <= 1 && x <= 0.95 {
+		return betaSeries(a, b, x)
+	}
+
+	// reverse a and b if x is greater than the mean
+	flag := false
+	xc := 1 - x
+	if x > a/(a+b) {
+		flag = true
+		a, b = b, a
+		x, xc = xc, x
+	}
+	if flag && b*x <= 1 && x <= 0.95 {
+		return 1 - betaSeries(a, b, x)
+	}
+
+	var w float64
+	if x*(a+b-2)-(a-1) < 0 {
+		w = betaFraction1(a, b, x)
+	} else {
+		w = betaFraction2(a, b, x) / xc
+	}
+	y := a*math.Log(x) + b*math.Log(xc) - LogBeta(a, b) + math.Log(w/a)
+	t := 0.0
+	if y > -maxLog {
+		t = math.Exp(y)
+	}
+	if flag {
+		return 1 - t
+	}
+	return t
+}
+
+// betaFraction1 is the first continued fraction expansion of incbet.
+func betaFraction1(a, b, x float64) float64 {
+	k1, k2, k3, k4 := a, a+b, a, a+1
+	k5, k6, k7, k8 := 1.0, b-1, a+1, a+2
+	pkm2, qkm2, pkm1, qkm1 := 0.0, 1.0, 1.0, 1.0
+	ans := 1.0
+	for n := 0; n < 300; n++ {
+		xk := -(x * k1 * k2) / (k3 * k4)
+		pk := pkm1 + pkm2*xk
+		qk := qkm1 + qkm2*xk
+		pkm2, pkm1 = pkm1, pk
+		qkm2, qkm1 = qkm1, qk
+
+		xk = (x * k5 * k6) / (k7 * k8)
+		pk = pkm1 + pkm2*xk
+		qk = qkm1 + qkm2*xk
+		pkm2, pkm1 = pkm1, pk
+		qkm2, qkm1 = qkm1, qk
+
+		if betaConverged(&ans, pk, qk) {
+			break
+		}
+		k1++
+		k2++
+		k3 += 2
+		k4 += 2
+		k5++
+		k6--
+		k7 += 2
+		k8 += 2
+		rescale(&pkm2, &pkm1, &qkm2, &qkm1, pk, qk)
+	}
+	return ans
+}
+
+// betaFraction2 is the second continued fraction expansion of incbet.
+func betaFraction2(a, b, x float64) float64 {
+	k1, k2, k3, k4 := a, b-1, a, a+1
+	k5, k6, k7, k8 := 1.0, a+b, a+1, a+2
+	pkm2, qkm2, pkm1, qkm1 := 0.0, 1.0, 1.0, 1.0
+	z := x / (1 - x)
+	ans := 1.0
+	for n := 0; n < 300; n++ {
+		xk := -(z * k1 * k2) / (k3 * k4)
+		pk := pkm1 + pkm2*xk
+		qk := qkm1 + qkm2*xk
+		pkm2, pkm1 = pkm1, pk
+		qkm2, qkm1 = qkm1, qk
+
+		xk = (z * k5 * k6) / (k7 * k8)
+		pk = pkm1 + pkm2*xk
+		qk = qkm1 + qkm2*xk
+		pkm2, pkm1 = pkm1, pk
+		qkm2, qkm1 = qkm1, qk
+
+		if betaConverged(&ans, pk, qk) {
+			break
+		}
+		k1++
+		k2--
+		k3 += 2
+		k4 += 2
+		k5++
+		k6++
+		k7 += 2
+		k8 += 2
+		rescale(&pkm2, &pkm1, &qkm2, &qkm1, pk, qk)
+	}
+	return ans
+}
+
+func betaConverged(ans *float64, pk, qk float64) bool {
+	r := 0.0
+	if qk != 0 {
+		r = pk / qk
+	}
+	if r == 0 {
+		return false
+	}
+	t := math.Abs((*ans - r) / r)
+	*ans = r
+	return t < 3*machEp
+}
+
+func rescale(pkm2, pkm1, qkm2, qkm1 *float64, pk, qk float64) {
+	if math.Abs(qk)+math.Abs(pk) > big {
+		*pkm2 *= bigInv
+		*pkm1 *= bigInv
+		*qkm2 *= bigInv
+		*qkm1 *= bigInv
+	}
+	if math.Abs(qk) < bigInv || math.Abs(pk) < bigInv {
+		*pkm2 *= big
+		*pkm1 *= big
+		*qkm2 *= big
+		*qkm1 *= big
+	}
+}
+
+// betaSeries is the power series of incbet, for b*x <= 1 and x <= 0.95.
+func betaSeries(a, b, x float64) float64 {
+	ai := 1 / a
+	u := (1 - b) * x
+	v := u / (a + 1)
+	t1 := v
+	t := u
+	n := 2.0
+	s := 0.0
+	z := machEp * ai
+	for math.Abs(v) > z {
+		u = (n - b) * x / n
+		t *= u
+		v = t / (a + n)
+		s += v
+		n++
+	}
+	s += t1 + ai
+
+	y := a*math.Log(x) - LogBeta(a, b) + math.Log(s)
+	if y < -maxLog {
+		return 0
+	}
+	return math.Exp(y)
+}
+
+// InvRegularizedBeta returns x such that RegularizedBeta(x, a, b) = p,
+// for a, b > 0 and 0 <= p <= 1.
+// It uses the starting values of Numerical Recipes (3rd ed., 6.4)
+// refined by Halley's method inside a shrinking bracket.
+func InvRegularizedBeta(p, a, b float64) float64 {
+	switch {
+	case math.IsNaN(p) || math.IsNaN(a) || math.IsNaN(b) || a <= 0 || b <= 0 || p < 0 || p > 1:
+		return math.NaN()
+	case p == 0:
+		return 0
+	case p == 1:
+		return 1
+	}
+	// work in the lower tail, where p keeps its relative accuracy.
+	if p > 0.5 {
+		return 1 - InvRegularizedBeta(1-p, b, a)
+	}
+
+	var x float64
+	if a >= 1 && b >= 1 {
+		t := math.Sqrt(-2 * math.Log(p))
+		z := -((2.30753+t*0.27061)/(1+t*(0.99229+t*0.04481)) - t)
+		al := (z*z - 3) / 6
+		h := 2 / (1/(2*a-1) + 1/(2*b-1))
+		w := z*math.Sqrt(al+h)/h - (1/(2*b-1)-1/(2*a-1))*(al+5.0/6-2/(3*h))
+		x = a / (a + b*math.Exp(2*w))
+	} else {
+		lna := math.Log(a / (a + b))
+		lnb := math.Log(b / (a + b))
+		t := math.Exp(a*lna) / a
+		u := math.Exp(b*lnb) / b
+		w := t + u
+		if p < t/w {
+			x = math.Pow(a*w*p, 1/a)
+		} else {
+			x = 1 - math.Pow(b*w*(1-p), 1/b)
+		}
+	}
+
+	lbeta := LogBeta(a, b)
+	lo, hi := 0.0, 1.0
+	for i := 0; i < 200; i++ {
+		if x <= lo || x >= hi || math.IsNaN(x) {
+			x = lo + (hi-lo)/2
+		}
+		err := RegularizedBeta(x, a, b) - p
+		if err < 0 {
+			lo = x
+		} else {
+			hi = x
+		}
+		if err == 0 || hi-lo <= 4*machEp*x {
+			break
+		}
+		t := math.Exp((a-1)*math.Log(x) + (b-1)*math.Log1p(-x) - lbeta)
+		u := err / t
+		step := u / (1 - 0.5*math.Min(1, u*((a-1)/x-(b-1)/(1-x))))
+		x -= step
+		if math.Abs(step) < 1e-15*x && x > lo && x < hi {
+			break
+		}
+	}
+	return x
+}
@@ -0,0 +1,70 @@
+package specfunc
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRegularizedBeta(t *testing.T) {
+	xs := []float64{1e-6, 0.01, 0.2, 0.5, 0.7, 0.99}
+	for _, x := range xs {
+		// I(x; a, 1) = x^a and I(x; 1, b) = 1 - (1-x)^b
+		for _, a := range []float64{0.3, 1, 2.5, 40} {
+			if i := RegularizedBeta(x, a, 1); math.Abs(i-math.Pow(x, a)) > 1e-14 {
+				t.Errorf("I(%g; %g, 1) = %g, expected %g", x, a, i, math.Pow(x, a))
+			}
+			expected := -math.Expm1(a * math.Log1p(-x))
+			if i := RegularizedBeta(x, 1, a); math.Abs(i-expected) > 1e-14 {
+				t.Errorf("I(%g; 1, %g) = %g, expected %g", x, a, i, expected)
+			}
+		}
+		// I(x; 1/2, 1/2) = 2/pi * asin(sqrt(x))
+		expected := 2 / math.Pi * math.Asin(math.Sqrt(x))
+		if i := RegularizedBeta(x, 0.5, 0.5); math.Abs(i-expected) > 1e-14 {
+			t.Errorf("I(%g; 0.5, 0.5) = %g, expected %g", x, i, expected)
+		}
+		// symmetry
+		for _, ab := range [][2]float64{{2, 3}, {0.5, 7}, {30, 45}, {200, 10}} {
+			s := RegularizedBeta(x, ab[0], ab[1]) + RegularizedBeta(1-x, ab[1], ab[0])
+			if math.Abs(s-1) > 1e-13 {
+				t.Errorf("I(%g; %g, %g) + I(1-x; b, a) = %g", x, ab[0], ab[1], s)
+			}
+		}
+	}
+
+	// integer parameters: binomial tail sums
+	// I(x; k, n-k+1) = P(Binomial(n, x) >= k)
+	n, k, x := 20, 7, 0.3
+	sum := 0.0
+	for j := k; j <= n; j++ {
+		sum += math.Exp(LogFactorial(n)-LogFactorial(j)-LogFactorial(n-j)) * math.Pow(x, float64(j)) * math.Pow(1-x, float64(n-j))
+	}
+	if i := RegularizedBeta(x, float64(k), float64(n-k+1)); math.Abs(i-sum) > 1e-12 {
+		t.Errorf("I(%g; %d, %d) = %g, expected %g", x, k, n-k+1, i, sum)
+	}
+
+	// two-sided 5% point of Student's t with 10 df: I(df/(df+t^2); df/2, 1/2) = 0.05
+	tq := 2.228138851986274
+	if p := RegularizedBeta(10/(10+tq*tq), 5, 0.5); math.Abs(p-0.05) > 1e-13 {
+		t.Errorf("two-sided t(10) p-value at %g is %g, expected 0.05", tq, p)
+	}
+}
+
+func TestInvRegularizedBeta(t *testing.T) {
+	for _, ab := range [][2]float64{{0.5, 0.5}, {1, 1}, {0.2, 3}, {2, 5}, {5, 0.5}, {50, 80}, {0.3, 0.3}} {
+		for _, p := range []float64{1e-12, 1e-4, 0.05, 0.5, 0.9} {
+			x := InvRegularizedBeta(p, ab[0], ab[1])
+			// compare the smaller tail, where x is well represented
+			actual, expected := RegularizedBeta(x, ab[0], ab[1]), p
+			if p > 0.5 {
+				actual, expected = RegularizedBeta(1-x, ab[1], ab[0]), 1-p
+			}
+			if math.Abs(actual-expected) > 1e-11*expected {
+				t.Errorf("I(InvI(%g; %g, %g)) = %g", p, ab[0], ab[1], actual)
+			}
+			if s := x + InvRegularizedBeta(1-p, ab[1], ab[0]); p >= 0.05 && math.Abs(s-1) > 1e-14 {
+				t.Errorf("InvI(%g; %g, %g) + InvI(1-p; b, a) = %g", p, ab[0], ab[1], s)
+			}
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package specfunc
+
+import (
+	"math"
+	"testing"
+)
+
+// poissonTail returns Q(n, x) = exp(-x) * sum_{k<n} x^k/k! for integer n.
+func poissonTail(n int, x float64) float64 {
+	term, sum := 1.0, 1.0
+	for k := 1; k < n; k++ {
+		term *= x / float64(k)
+		sum += term
+	}
+	return math.Exp(-x) * sum
+}
+
+func TestRegularizedGamma(t *testing.T) {
+	xs := []float64{1e-3, 0.1, 0.5, 1, 2.5, 5, 10, 30, 100}
+	for _, x := range xs {
+		// a = 1: exponential distribution
+		if p := RegularizedGammaP(1, x); math.Abs(p+math.Expm1(-x)) > 1e-15 {
+			t.Errorf("P(1, %g) = %g, expected %g", x, p, -math.Expm1(-x))
+		}
+		// a = 1/2: P(1/2, x) = erf(sqrt(x))
+		if p := RegularizedGammaP(0.5, x); math.Abs(p-math.Erf(math.Sqrt(x))) > 1e-14 {
+			t.Errorf("P(0.5, %g) = %g, expected %g", x, p, math.Erf(math.Sqrt(x)))
+		}
+		if q := RegularizedGammaQ(0.5, x); math.Abs(q-math.Erfc(math.Sqrt(x)))/math.Erfc(math.Sqrt(x)) > 1e-12 {
+			t.Errorf("Q(0.5, %g) = %g, expected %g", x, q, math.Erfc(math.Sqrt(x)))
+		}
+		// integer a: Poisson tail sums
+		for _, n := range []int{2, 5, 20} {
+			expected := poissonTail(n, x)
+			q := RegularizedGammaQ(float64(n), x)
+			if math.Abs(q-expected) > 1e-14 && math.Abs(q-expected)/expected > 1e-12 {
+				t.Errorf("Q(%d, %g) = %g, expected %g", n, x, q, expected)
+			}
+			if p := RegularizedGammaP(float64(n), x); math.Abs(p+q-1) > 1e-14 {
+				t.Errorf("P(%d, %g) + Q(%d, %g) = %g, expected 1", n, x, n, x, p+q)
+			}
+		}
+	}
+
+	// upper 5% points of the chi-squared distribution
+	chi2 := map[float64]float64{1: 3.841458820694124, 10: 18.307038053275146, 100: 124.34211340400407}
+	for df, x := range chi2 {
+		if q := RegularizedGammaQ(df/2, x/2); math.Abs(q-0.05) > 1e-12 {
+			t.Errorf("chi-squared(%g) survival at %g is %g, expected 0.05", df, x, q)
+		}
+	}
+
+	if !math.IsNaN(RegularizedGammaP(-1, 1)) || !math.IsNaN(RegularizedGammaQ(1, -1)) {
+		t.Error("invalid arguments should give NaN")
+	}
+}
+
+func TestInvRegularizedGamma(t *testing.T) {
+	for _, a := range []float64{0.1, 0.5, 1, 2.5, 10, 150} {
+		for _, p := range []float64{1e-10, 1e-3, 0.05, 0.3, 0.5, 0.9, 0.999} {
+			x := InvRegularizedGammaP(a, p)
+			if actual := RegularizedGammaP(a, x); math.Abs(actual-p)/p > 1e-10 {
+				t.Errorf("P(%g, InvP(%g, %g)) = %g", a, a, p, actual)
+			}
+			x = InvRegularizedGammaQ(a, p)
+			if actual := RegularizedGammaQ(a, x); math.Abs(actual-p)/p > 1e-10 {
+				t.Errorf("Q(%g, InvQ(%g, %g)) = %g", a, a, p, actual)
+			}
+		}
+	}
+	if x := 2 * InvRegularizedGammaQ(5, 0.05); math.Abs(x-18.307038053275146) > 1e-10 {
+		t.Errorf("upper 5%% point of chi-squared(10) is %g, expected 18.307038053275146", x)
+	}
+}
@@ -0,0 +1,154 @@
+package correlation
+
+import (
+	"github.com/mingzhi/gomath/random"
+	"github.com/mingzhi/gomath/specfunc"
+	"math"
+)
+
+// ACF returns the sample autocorrelation function of x at lags 0..maxLag,
+//
+// r[k] = c[k] / c[0], c[k] = 1/n * sum_t (x[t] - mean) * (x[t+k] - mean),
+//
+// computed with AutoCorrFFT. r[0] is 1.
+func ACF(x []float64, maxLag int) []float64 {
+	n := len(x)
+	if maxLag < 0 || maxLag >= n {
+		panic("correlation: maxLag must be in [0, len(x))")
+	}
+
+	mean := 0.0
+	for _, v := range x {
+		mean += v
+	}
+	mean /= float64(n)
+	d := make([]float64, n)
+	for i, v := range x {
+		d[i] = v - mean
+	}
+
+	c := AutoCorrFFT(d, false)
+	r := make([]float64, maxLag+1)
+	for k := range r {
+		r[k] = c[k] / c[0]
+	}
+	r[0] = 1
+	return r
+}
+
+// PACF returns the sample partial autocorrelation function of x
+// at lags 0..maxLag, computed from ACF with the Durbin-Levinson recursion.
+// pacf[0] is 1.
+func PACF(x []float64, maxLag int) []float64 {
+	return durbinLevinson(ACF(x, maxLag))
+}
+
+// durbinLevinson returns the partial autocorrelations for the
+// autocorrelations r[0..K].
+func durbinLevinson(r []float64) []float64 {
+	maxLag := len(r) - 1
+	pacf := make([]float64, maxLag+1)
+	pacf[0] = 1
+	if maxLag == 0 {
+		return pacf
+	}
+
+	// phi holds the coefficients of the AR(k) fit, phi[j-1] for lag j.
+	phi := make([]float64, maxLag)
+	prev := make([]float64, maxLag)
+	phi[0] = r[1]
+	pacf[1] = r[1]
+	for k := 2; k <= maxLag; k++ {
+		copy(prev, phi[:k-1])
+		num, den := r[k], 1.0
+		for j := 1; j < k; j++ {
+			num -= prev[j-1] * r[k-j]
+			den -= prev[j-1] * r[j]
+		}
+		phikk := num / den
+		for j := 1; j < k; j++ {
+			phi[j-1] = prev[j-1] - phikk*prev[k-j-1]
+		}
+		phi[k-1] = phikk
+		pacf[k] = phikk
+	}
+	return pacf
+}
+
+// BartlettBands returns the half-widths of the 1-alpha confidence bands of
+// the autocorrelations r (as returned by ACF) of a series of length n,
+// under the hypothesis that the series is a moving average of order k-1
+// at lag k (Bartlett's formula):
+//
+// band[k] = z(1-alpha/2) * sqrt((1 + 2 * sum_{j<k} r[j]^2) / n).
+//
+// band[0] is 0.
+func BartlettBands(r []float64, n int, alpha float64) []float64 {
+	z := random.Normal{Sigma: 1}.Quantile(1 - alpha/2)
+	band := make([]float64, len(r))
+	sum := 1.0
+	for k := 1; k < len(r); k++ {
+		band[k] = z * math.Sqrt(sum/float64(n))
+		sum += 2 * r[k] * r[k]
+	}
+	return band
+}
+
+// PACFBand returns the half-width of the 1-alpha confidence band of the
+// partial autocorrelations of a white noise series of length n,
+// z(1-alpha/2) / sqrt(n).
+func PACFBand(n int, alpha float64) float64 {
+	return random.Normal{Sigma: 1}.Quantile(1-alpha/2) / math.Sqrt(float64(n))
+}
+
+// A Portmanteau holds the result of a test for the whiteness of a series.
+type Portmanteau struct {
+	Statistic float64
+	DF        int     // degrees of freedom of the chi-squared distribution
+	PValue    float64 // probability of a larger statistic for white noise
+}
+
+// LjungBox returns the Ljung-Box test of the autocorrelations of x
+// at lags 1..lag,
+//
+// Q = n * (n+2) * sum_k r[k]^2 / (n-k),
+//
+// compared to a chi-squared distribution with lag-fitdf degrees of freedom.
+// fitdf is the number of parameters of the model when x are residuals,
+// e.g. p+q for an ARMA(p, q) model, and 0 otherwise.
+func LjungBox(x []float64, lag, fitdf int) Portmanteau {
+	r := ACF(x, lag)
+	n := float64(len(x))
+	sum := 0.0
+	for k := 1; k <= lag; k++ {
+		sum += r[k] * r[k] / (n - float64(k))
+	}
+	return newPortmanteau(n*(n+2)*sum, lag-fitdf)
+}
+
+// BoxPierce returns the Box-Pierce test of the autocorrelations of x
+// at lags 1..lag,
+//
+// Q = n * sum_k r[k]^2,
+//
+// compared to a chi-squared distribution with lag-fitdf degrees of freedom.
+// See LjungBox, which has better small sample properties.
+func BoxPierce(x []float64, lag, fitdf int) Portmanteau {
+	r := ACF(x, lag)
+	sum := 0.0
+	for k := 1; k <= lag; k++ {
+		sum += r[k] * r[k]
+	}
+	return newPortmanteau(float64(len(x))*sum, lag-fitdf)
+}
+
+func newPortmanteau(q float64, df int) Portmanteau {
+	if df < 1 {
+		panic("correlation: lag must be larger than fitdf")
+	}
+	return Portmanteau{
+		Statistic: q,
+		DF:        df,
+		PValue:    specfunc.RegularizedGammaQ(float64(df)/2, q/2),
+	}
+}
@@ -0,0 +1,140 @@
+package correlation
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func ar1(rng *rand.Rand, n int, phi float64) []float64 {
+	x := make([]float64, n)
+	for i := 1; i < n; i++ {
+		x[i] = phi*x[i-1] + rng.NormFloat64()
+	}
+	return x
+}
+
+func TestACF(t *testing.T) {
+	x := ar1(rand.New(rand.NewSource(1)), 200, 0.6)
+	n := len(x)
+	mean := 0.0
+	for _, v := range x {
+		mean += v
+	}
+	mean /= float64(n)
+	c := func(k int) float64 {
+		s := 0.0
+		for i := 0; i+k < n; i++ {
+			s += (x[i] - mean) * (x[i+k] - mean)
+		}
+		return s
+	}
+
+	r := ACF(x, 20)
+	for k := range r {
+		expected := c(k) / c(0)
+		if math.Abs(r[k]-expected) > 1e-12 {
+			t.Errorf("ACF at lag %d is %f, expected %f\n", k, r[k], expected)
+		}
+	}
+
+	// partial autocorrelations are the last coefficients of the Yule-Walker fits.
+	pacf := PACF(x, 6)
+	for k := 1; k < len(pacf); k++ {
+		a := make([][]float64, k)
+		for i := range a {
+			a[i] = make([]float64, k+1)
+			for j := 0; j < k; j++ {
+				d := i - j
+				if d < 0 {
+					d = -d
+				}
+				a[i][j] = r[d]
+			}
+			a[i][k] = r[i+1]
+		}
+		phi := solve(a)
+		if math.Abs(pacf[k]-phi[k-1]) > 1e-10 {
+			t.Errorf("PACF at lag %d is %f, expected %f\n", k, pacf[k], phi[k-1])
+		}
+	}
+	if math.Abs(pacf[1]-0.6) > 0.15 {
+		t.Errorf("PACF at lag 1 of an AR(1) process with phi = 0.6 is %f\n", pacf[1])
+	}
+
+	bands := BartlettBands(r, n, 0.05)
+	if bands[0] != 0 || math.Abs(bands[1]-1.959963984540054/math.Sqrt(float64(n))) > 1e-12 {
+		t.Errorf("Bartlett bands start with %v\n", bands[:2])
+	}
+	if math.Abs(bands[1]-PACFBand(n, 0.05)) > 1e-15 {
+		t.Errorf("PACF band %f differs from the first Bartlett band %f\n", PACFBand(n, 0.05), bands[1])
+	}
+	if bands[2] <= bands[1] {
+		t.Errorf("Bartlett bands should widen: %v\n", bands[:3])
+	}
+}
+
+// solve solves the augmented linear system a by Gaussian elimination.
+func solve(a [][]float64) []float64 {
+	n := len(a)
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			f := a[j][i] / a[i][i]
+			for k := i; k <= n; k++ {
+				a[j][k] -= f * a[i][k]
+			}
+		}
+	}
+	x := make([]float64, n)
+	for i := n - 1; i >= 0; i-- {
+		x[i] = a[i][n]
+		for j := i + 1; j < n; j++ {
+			x[i] -= a[i][j] * x[j]
+		}
+		x[i] /= a[i][i]
+	}
+	return x
+}
+
+func TestPortmanteau(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	x := ar1(rng, 300, 0.5)
+	r := ACF(x, 10)
+	n := float64(len(x))
+	lb, bp := 0.0, 0.0
+	for k := 1; k <= 10; k++ {
+		lb += r[k] * r[k] / (n - float64(k))
+		bp += r[k] * r[k]
+	}
+	lb *= n * (n + 2)
+	bp *= n
+
+	test := LjungBox(x, 10, 1)
+	if math.Abs(test.Statistic-lb) > 1e-9 || test.DF != 9 {
+		t.Errorf("Ljung-Box statistic %f with %d df, expected %f with 9 df\n", test.Statistic, test.DF, lb)
+	}
+	if test.PValue > 1e-6 {
+		t.Errorf("Ljung-Box p-value %g of an AR(1) process should be small\n", test.PValue)
+	}
+	test = BoxPierce(x, 10, 0)
+	if math.Abs(test.Statistic-bp) > 1e-9 || test.DF != 10 {
+		t.Errorf("Box-Pierce statistic %f with %d df, expected %f with 10 df\n", test.Statistic, test.DF, bp)
+	}
+
+	// the p-values of white noise are uniform; check a batch is not extreme.
+	small := 0
+	for i := 0; i < 200; i++ {
+		if LjungBox(ar1(rng, 300, 0), 10, 0).PValue < 0.05 {
+			small++
+		}
+	}
+	if small > 25 {
+		t.Errorf("%d of 200 white noise series rejected at 5%%\n", small)
+	}
+
+	// p-value at the upper 5% point of chi-squared(10)
+	p := newPortmanteau(18.307038053275146, 10).PValue
+	if math.Abs(p-0.05) > 1e-12 {
+		t.Errorf("p-value %f, expected 0.05\n", p)
+	}
+}
@@ -0,0 +1,116 @@
+package correlation
+
+import (
+	"github.com/mingzhi/gomath/fft"
+)
+
+// Convolve returns the linear convolution
+//
+// y[k] = sum_j x[j] * h[k-j]
+//
+// of x and h in the given mode, computed with a zero-padded FFT.
+// The full result has length len(x)+len(h)-1; SameMode returns its central
+// len(x) values and ValidMode the values where one sequence overlaps
+// the other completely, as in SciPy.
+func Convolve(x, h []float64, mode Mode) []float64 {
+	nx, nh := len(x), len(h)
+	if nx == 0 || nh == 0 {
+		panic("correlation: empty sequence")
+	}
+
+	ftlength := fft.NextFastLen(nx + nh - 1)
+	plan := fft.NewRealPlan(ftlength)
+	data := make([]float64, ftlength)
+	copy(data, x)
+	vx := plan.Forward(nil, data)
+	zero(data)
+	copy(data, h)
+	vh := plan.Forward(nil, data)
+	for i := range vx {
+		vx[i] *= vh[i]
+	}
+	full := plan.Inverse(data, vx)[:nx+nh-1]
+	for i := range full {
+		full[i] /= float64(ftlength)
+	}
+
+	start, length := modeRange(nx, nh, mode)
+	return full[start : start+length]
+}
+
+// An OverlapAdd convolves a long or unbounded signal with a fixed filter
+// chunk by chunk, using the overlap-add method. The filter transform is
+// computed once; each block of the signal costs one forward and one
+// inverse transform.
+//
+// The outputs of successive calls to Process, followed by Flush,
+// are the full convolution of the concatenated chunks with the filter.
+type OverlapAdd struct {
+	h     []float64
+	block int
+	plan  *fft.RealPlan
+	hspec []complex128
+	tail  []float64 // the len(h)-1 outputs still receiving contributions
+	data  []float64
+	spec  []complex128
+}
+
+// NewOverlapAdd returns an OverlapAdd for the filter h that transforms
+// the signal in blocks of the given length. A block of a few times
+// len(h) is usually efficient.
+func NewOverlapAdd(h []float64, block int) *OverlapAdd {
+	if len(h) == 0 {
+		panic("correlation: empty filter")
+	}
+	if block < 1 {
+		panic("correlation: block length must be positive")
+	}
+	o := &OverlapAdd{h: h, block: block}
+	ftlength := fft.NextFastLen(block + len(h) - 1)
+	o.plan = fft.NewRealPlan(ftlength)
+	o.data = make([]float64, ftlength)
+	o.spec = make([]complex128, o.plan.SpectrumLen())
+	copy(o.data, h)
+	o.hspec = o.plan.Forward(nil, o.data)
+	o.tail = make([]float64, len(h)-1)
+	return o
+}
+
+// Process convolves the next chunk of the signal and returns the
+// len(chunk) output values that are now complete.
+func (o *OverlapAdd) Process(chunk []float64) []float64 {
+	out := make([]float64, 0, len(chunk))
+	m := len(o.h)
+	n := float64(o.plan.Len())
+	for len(chunk) > 0 {
+		l := o.block
+		if len(chunk) < l {
+			l = len(chunk)
+		}
+		copy(o.data, chunk[:l])
+		zero(o.data[l:])
+		o.plan.Forward(o.spec, o.data)
+		for i, v := range o.hspec {
+			o.spec[i] *= v
+		}
+		y := o.plan.Inverse(o.data, o.spec)[:l+m-1]
+		for i := range y {
+			y[i] /= n
+		}
+		for i, v := range o.tail {
+			y[i] += v
+		}
+		out = append(out, y[:l]...)
+		copy(o.tail, y[l:])
+		chunk = chunk[l:]
+	}
+	return out
+}
+
+// Flush returns the last len(h)-1 values of the convolution
+// and resets the convolver for a new signal.
+func (o *OverlapAdd) Flush() []float64 {
+	out := append([]float64(nil), o.tail...)
+	zero(o.tail)
+	return out
+}
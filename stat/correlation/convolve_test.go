@@ -0,0 +1,74 @@
+package correlation
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func convolveBruteForce(x, h []float64) []float64 {
+	y := make([]float64, len(x)+len(h)-1)
+	for i, a := range x {
+		for j, b := range h {
+			y[i+j] += a * b
+		}
+	}
+	return y
+}
+
+func TestConvolve(t *testing.T) {
+	x := []float64{1, 2, 3, 4}
+	h := []float64{1, 0, -1}
+	cases := []struct {
+		mode     Mode
+		expected []float64
+	}{
+		{FullMode, []float64{1, 2, 2, 2, -3, -4}},
+		{SameMode, []float64{2, 2, 2, -3}},
+		{ValidMode, []float64{2, 2}},
+	}
+	for _, c := range cases {
+		y := Convolve(x, h, c.mode)
+		if len(y) != len(c.expected) {
+			t.Errorf("mode %d: length %d, expected %d\n", c.mode, len(y), len(c.expected))
+			continue
+		}
+		for i := range y {
+			if math.Abs(y[i]-c.expected[i]) > tolerance {
+				t.Errorf("mode %d: %f at %d, expected %f\n", c.mode, y[i], i, c.expected[i])
+			}
+		}
+	}
+}
+
+func TestOverlapAdd(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	x := randomSeries(rng, 1, 1000)[0]
+	h := randomSeries(rng, 1, 31)[0]
+	expected := convolveBruteForce(x, h)
+
+	for _, block := range []int{1, 16, 64, 2000} {
+		o := NewOverlapAdd(h, block)
+		var y []float64
+		// chunks of irregular sizes
+		for i := 0; i < len(x); {
+			j := i + 1 + rng.Intn(150)
+			if j > len(x) {
+				j = len(x)
+			}
+			y = append(y, o.Process(x[i:j])...)
+			i = j
+		}
+		y = append(y, o.Flush()...)
+		if len(y) != len(expected) {
+			t.Errorf("block %d: length %d, expected %d\n", block, len(y), len(expected))
+			continue
+		}
+		for i := range y {
+			if math.Abs(y[i]-expected[i]) > 1e-9 {
+				t.Errorf("block %d: %f at %d, expected %f\n", block, y[i], i, expected[i])
+				break
+			}
+		}
+	}
+}
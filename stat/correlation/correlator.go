@@ -0,0 +1,151 @@
+package correlation
+
+import (
+	"github.com/mingzhi/gomath/fft"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// A Correlator computes the correlations of many series of the same length.
+// It owns one FFT plan and workspace per worker, so repeated calls to Batch
+// do not recompute twiddles or reallocate buffers.
+//
+// Without a reference it computes autocorrelations, the same as AutoCorrFFT.
+// After SetReference it computes cross-correlations against the reference,
+// the same as XCorrFFT(x, ref, circular).
+//
+// A Correlator may not be used by several goroutines at once;
+// Batch already spreads its work over GOMAXPROCS goroutines.
+type Correlator struct {
+	n        int
+	circular bool
+	ftlength int
+	ref      []complex128 // transform of the reference, nil for autocorrelation
+	workers  []*correlatorWorker
+}
+
+// correlatorWorker holds the plan and buffers of one goroutine.
+type correlatorWorker struct {
+	plan *fft.RealPlan
+	data []float64
+	spec []complex128
+}
+
+// NewCorrelator returns a Correlator for series of length n.
+// If circular is false, the series are zero padded so that
+// the correlations are linear.
+func NewCorrelator(n int, circular bool) *Correlator {
+	if n < 1 {
+		panic("correlation: series length must be positive")
+	}
+	c := &Correlator{n: n, circular: circular, ftlength: n}
+	if !circular {
+		c.ftlength = fft.NextFastLen(2 * n)
+	}
+
+	plan := fft.NewRealPlan(c.ftlength)
+	c.workers = make([]*correlatorWorker, runtime.GOMAXPROCS(0))
+	for i := range c.workers {
+		w := &correlatorWorker{plan: plan}
+		if i > 0 {
+			w.plan = plan.Clone()
+		}
+		w.data = make([]float64, c.ftlength)
+		w.spec = make([]complex128, plan.SpectrumLen())
+		c.workers[i] = w
+	}
+	return c
+}
+
+// N returns the length of the series.
+func (c *Correlator) N() int {
+	return c.n
+}
+
+// SetReference makes Batch compute cross-correlations against ref.
+// The transform of ref is computed once here and reused by every Batch.
+// A nil ref switches back to autocorrelations.
+func (c *Correlator) SetReference(ref []float64) {
+	if ref == nil {
+		c.ref = nil
+		return
+	}
+	c.checkLen(ref)
+	w := c.workers[0]
+	copy(w.data, ref)
+	zero(w.data[c.n:])
+	c.ref = w.plan.Forward(nil, w.data)
+}
+
+// Batch computes the correlation of every series in xs in parallel
+// and returns them in the same order.
+// All the results share one allocation.
+func (c *Correlator) Batch(xs [][]float64) [][]float64 {
+	for _, x := range xs {
+		c.checkLen(x)
+	}
+
+	res := make([][]float64, len(xs))
+	data := make([]float64, len(xs)*c.n)
+	for i := range res {
+		res[i] = data[i*c.n : (i+1)*c.n : (i+1)*c.n]
+	}
+
+	workers := c.workers
+	if len(xs) < len(workers) {
+		workers = workers[:len(xs)]
+	}
+	next := int64(-1)
+	var wg sync.WaitGroup
+	for _, w := range workers {
+		wg.Add(1)
+		go func(w *correlatorWorker) {
+			defer wg.Done()
+			for {
+				i := int(atomic.AddInt64(&next, 1))
+				if i >= len(xs) {
+					return
+				}
+				c.correlate(w, res[i], xs[i])
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	return res
+}
+
+// correlate computes the correlation of x into dst with the buffers of w.
+// The forward transform of x is computed once and used for both sides
+// of the product.
+func (c *Correlator) correlate(w *correlatorWorker, dst, x []float64) {
+	copy(w.data, x)
+	zero(w.data[c.n:])
+	spec := w.plan.Forward(w.spec, w.data)
+	if c.ref == nil {
+		for k, v := range spec {
+			spec[k] = complex(real(v)*real(v)+imag(v)*imag(v), 0)
+		}
+	} else {
+		for k, v := range spec {
+			spec[k] = c.ref[k] * complex(real(v), -imag(v))
+		}
+	}
+	w.plan.Inverse(w.data, spec)
+	for i := range dst {
+		dst[i] = w.data[i] / float64(c.ftlength)
+	}
+}
+
+func (c *Correlator) checkLen(x []float64) {
+	if len(x) != c.n {
+		panic("correlation: series length does not match the correlator")
+	}
+}
+
+func zero(x []float64) {
+	for i := range x {
+		x[i] = 0
+	}
+}
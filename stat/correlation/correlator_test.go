@@ -0,0 +1,69 @@
+package correlation
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func randomSeries(rng *rand.Rand, m, n int) [][]float64 {
+	xs := make([][]float64, m)
+	for i := range xs {
+		xs[i] = make([]float64, n)
+		for j := range xs[i] {
+			xs[i][j] = rng.Float64()
+		}
+	}
+	return xs
+}
+
+func TestCorrelatorBatch(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, n := range []int{1, 10, 37, 64} {
+		xs := randomSeries(rng, 25, n)
+		ref := randomSeries(rng, 1, n)[0]
+		for _, circular := range []bool{true, false} {
+			c := NewCorrelator(n, circular)
+
+			res := c.Batch(xs)
+			for i, x := range xs {
+				expected := AutoCorrFFT(x, circular)
+				for j := range expected {
+					if math.Abs(res[i][j]-expected[j]) > tolerance {
+						t.Errorf("n=%d, circular=%v: autocorrelation %d at lag %d is %f, expected %f\n", n, circular, i, j, res[i][j], expected[j])
+					}
+				}
+			}
+
+			c.SetReference(ref)
+			res = c.Batch(xs)
+			for i, x := range xs {
+				expected := XCorrFFT(x, ref, circular)
+				for j := range expected {
+					if math.Abs(res[i][j]-expected[j]) > tolerance {
+						t.Errorf("n=%d, circular=%v: cross-correlation %d at lag %d is %f, expected %f\n", n, circular, i, j, res[i][j], expected[j])
+					}
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkCorrelatorBatch(b *testing.B) {
+	xs := randomSeries(rand.New(rand.NewSource(1)), 1000, 510)
+	c := NewCorrelator(510, false)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Batch(xs)
+	}
+}
+
+func BenchmarkFFTAutoLoop(b *testing.B) {
+	xs := randomSeries(rand.New(rand.NewSource(1)), 1000, 510)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, x := range xs {
+			AutoCorrFFT(x, false)
+		}
+	}
+}
@@ -1,50 +1,51 @@
 package correlation
 
 import (
-	"github.com/mjibson/go-dsp/fft"
-	"math/cmplx"
+	"github.com/mingzhi/gomath/fft"
 )
 
 func AutoCorrFFT(x []float64, circular bool) []float64 {
 	return XCorrFFT(x, x, circular)
 }
 
+// XCorrFFT returns sum_k x2[k+i] * x1[k] for the non-negative lags
+// i = 0..len(x1)-1. x1 and x2 should have the same length;
+// use XCorr for sequences of different lengths or negative lags.
 func XCorrFFT(x1, x2 []float64, circular bool) []float64 {
 	// zero padding.
 	ftlength := len(x1)
 	if !circular {
-		length := len(x1) * 2
-		var i uint32 = 1
-		for ftlength < length {
-			ftlength = 1 << i
-			i++
-		}
+		ftlength = fft.NextFastLen(2 * len(x1))
 	}
 
-	datax1 := make([]complex128, ftlength)
-	datax2 := make([]complex128, ftlength)
+	datax1 := make([]float64, ftlength)
+	datax2 := make([]float64, ftlength)
 	for i := 0; i < len(x1); i++ {
-		datax1[i] = complex(x2[i%len(x2)], 0)
-		datax2[i] = complex(x1[i%len(x1)], 0)
+		datax1[i] = x2[i%len(x2)]
+		datax2[i] = x1[i%len(x1)]
 	}
 
-	v1 := fft.FFT(datax1)
-	v2 := fft.FFT(datax2)
-	temp := []complex128{}
+	plan := fft.NewRealPlan(ftlength)
+	v1 := plan.Forward(nil, datax1)
+	v2 := plan.Forward(nil, datax2)
 	for i := 0; i < len(v1); i++ {
-		v := v1[i] * cmplx.Conj(v2[i])
-		temp = append(temp, v)
+		v1[i] *= complex(real(v2[i]), -imag(v2[i]))
 	}
-	v3 := fft.IFFT(temp)
+	v3 := plan.Inverse(datax1, v1)
 
-	res := []float64{}
+	res := make([]float64, len(x1))
 	for i := 0; i < len(x1); i++ {
-		res = append(res, real(v3[i]))
+		res[i] = v3[i] / float64(ftlength)
 	}
 	return res
 }
 
+// XCorrBruteForce computes XCorrFFT by direct summation.
+// It panics if x1 and x2 have different lengths.
 func XCorrBruteForce(x1, x2 []float64, circular bool) []float64 {
+	if len(x1) != len(x2) {
+		panic("correlation: x1 and x2 have different lengths, use XCorr")
+	}
 	// zero padding.
 	datax1 := make([]float64, len(x1)*2)
 	datax2 := make([]float64, len(x2)*2)
@@ -1,13 +1,15 @@
 package correlation
 
 import (
-	"github.com/mingzhi/fftw"
-	"math/cmplx"
+	"github.com/mingzhi/gomath/fft"
 )
 
+// FFTW computes correlations of a fixed length with a precomputed
+// real-to-complex plan.
+// The plan is pure Go and does not require cgo or libfftw.
+// An FFTW holds workspace and is not safe for concurrent use.
 type FFTW struct {
-	foward   fftw.DftR2C1DPlan
-	backward fftw.DftC2R1DPlan
+	plan *fft.RealPlan
 }
 
 func NewFFTW(n int, circular bool) FFTW {
@@ -17,38 +19,36 @@ func NewFFTW(n int, circular bool) FFTW {
 		ftlength = n * 2
 	}
 
-	flag := fftw.Measure
-
 	var f FFTW
-	f.foward = fftw.NewDftR2C1DPlan(ftlength, flag)
-	f.backward = fftw.NewDftC2R1DPlan(ftlength, flag)
+	f.plan = fft.NewRealPlan(ftlength)
 
 	return f
 }
 
+// Close releases the plan. It is kept for compatibility with
+// the cgo binding, whose plans had to be freed explicitly.
 func (f *FFTW) Close() {
-	f.foward.Close()
-	f.backward.Close()
+	f.plan = nil
 }
 
 func (f FFTW) XCorr(x1, x2 []float64) []float64 {
-	var v1, v2, temp []complex128
-	var rs []float64
-
-	v1 = f.foward.ExecuteNewArray(x2)
-	v2 = f.foward.ExecuteNewArray(x1)
-
+	totl := f.plan.Len()
+	datax1 := make([]float64, totl)
+	datax2 := make([]float64, totl)
+	copy(datax1, x2)
+	copy(datax2, x1)
+
+	v1 := f.plan.Forward(nil, datax1)
+	v2 := f.plan.Forward(nil, datax2)
 	for i := 0; i < len(v1); i++ {
-		v := v1[i] * cmplx.Conj(v2[i])
-		temp = append(temp, v)
+		v1[i] *= complex(real(v2[i]), -imag(v2[i]))
 	}
 
-	rs = f.backward.ExecuteNewArray(temp)
-	totl := len(f.backward.Real)
+	rs := f.plan.Inverse(datax1, v1)
 
-	res := []float64{}
+	res := make([]float64, len(x1))
 	for i := 0; i < len(x1); i++ {
-		res = append(res, rs[i]/float64(totl))
+		res[i] = rs[i] / float64(totl)
 	}
 	return res
 }
@@ -0,0 +1,149 @@
+package correlation
+
+import (
+	"github.com/mingzhi/gomath/fft"
+	"math"
+)
+
+// MaskedCorr holds the correlation of two series with missing data,
+// indexed by lag like the result of XCorrFFT.
+// At lag i the pairs (x1[k], x2[k+i]) with both values valid contribute.
+type MaskedCorr struct {
+	Sum   []float64 // raw correlation sum over the valid pairs
+	N     []int     // number of valid pairs
+	Coeff []float64 // Pearson correlation coefficient of the valid pairs
+}
+
+// AutoCorrFFTMasked returns the autocorrelation of x, where only the
+// values with mask[i] true are used. See XCorrFFTMasked.
+func AutoCorrFFTMasked(x []float64, mask []bool, circular bool) MaskedCorr {
+	return XCorrFFTMasked(x, x, mask, mask, circular)
+}
+
+// XCorrFFTMasked returns the cross-correlation of x1 and x2, where only the
+// values with m1[i] (respectively m2[i]) true are used; the values at invalid
+// positions are ignored and may be NaN.
+//
+// For every lag it returns the correlation sum, the number of valid pairs
+// and the correlation coefficient of the valid pairs. The coefficient is NaN
+// at lags with fewer than two pairs or without variation.
+// The per-lag means and variances come from correlating the masks with the
+// data and with the squared data, so everything is computed with 6 forward
+// and 6 inverse transforms instead of O(n^2) sums.
+func XCorrFFTMasked(x1, x2 []float64, m1, m2 []bool, circular bool) MaskedCorr {
+	n := len(x1)
+	if len(x2) != n || len(m1) != n || len(m2) != n {
+		panic("correlation: series and masks must have the same length")
+	}
+
+	ftlength := n
+	if !circular {
+		ftlength = fft.NextFastLen(2 * n)
+	}
+	plan := fft.NewRealPlan(ftlength)
+
+	// the data are centered on their valid means for accuracy;
+	// the raw sums are restored at the end.
+	mean1 := maskedMean(x1, m1)
+	mean2 := maskedMean(x2, m2)
+	data := make([]float64, ftlength)
+	transform := func(x []float64, m []bool, mean float64, power int) []complex128 {
+		for i := 0; i < n; i++ {
+			data[i] = 0
+			if m[i] {
+				d := x[i] - mean
+				switch power {
+				case 0:
+					data[i] = 1
+				case 1:
+					data[i] = d
+				case 2:
+					data[i] = d * d
+				}
+			}
+		}
+		return plan.Forward(nil, data)
+	}
+	mask1 := transform(x1, m1, mean1, 0)
+	lin1 := transform(x1, m1, mean1, 1)
+	sq1 := transform(x1, m1, mean1, 2)
+	mask2 := transform(x2, m2, mean2, 0)
+	lin2 := transform(x2, m2, mean2, 1)
+	sq2 := transform(x2, m2, mean2, 2)
+
+	// corr returns sum_k b[k+i] * a[k] from the transforms of a and b.
+	spec := make([]complex128, plan.SpectrumLen())
+	corr := func(a, b []complex128) []float64 {
+		for k := range spec {
+			spec[k] = b[k] * complex(real(a[k]), -imag(a[k]))
+		}
+		r := plan.Inverse(nil, spec)
+		for i := range r {
+			r[i] /= float64(ftlength)
+		}
+		return r[:n]
+	}
+	count := corr(mask1, mask2)
+	cross := corr(lin1, lin2)
+	sum1 := corr(lin1, mask2)
+	sum2 := corr(mask1, lin2)
+	sumSq1 := corr(sq1, mask2)
+	sumSq2 := corr(mask1, sq2)
+
+	// variances below the rounding error of the transforms are zero.
+	tiny1 := 1e-12 * maskedSumSq(x1, m1, mean1)
+	tiny2 := 1e-12 * maskedSumSq(x2, m2, mean2)
+
+	res := MaskedCorr{
+		Sum:   make([]float64, n),
+		N:     make([]int, n),
+		Coeff: make([]float64, n),
+	}
+	for i := 0; i < n; i++ {
+		k := int(math.Floor(count[i] + 0.5))
+		res.N[i] = k
+		if k == 0 {
+			res.Coeff[i] = math.NaN()
+			continue
+		}
+		fk := float64(k)
+		res.Sum[i] = cross[i] + mean2*sum1[i] + mean1*sum2[i] + mean1*mean2*fk
+		if k < 2 {
+			res.Coeff[i] = math.NaN()
+			continue
+		}
+		sxy := cross[i] - sum1[i]*sum2[i]/fk
+		sxx := sumSq1[i] - sum1[i]*sum1[i]/fk
+		syy := sumSq2[i] - sum2[i]*sum2[i]/fk
+		if sxx <= tiny1 || syy <= tiny2 {
+			res.Coeff[i] = math.NaN()
+			continue
+		}
+		res.Coeff[i] = math.Max(-1, math.Min(1, sxy/math.Sqrt(sxx*syy)))
+	}
+	return res
+}
+
+func maskedMean(x []float64, m []bool) float64 {
+	sum, k := 0.0, 0
+	for i, v := range x {
+		if m[i] {
+			sum += v
+			k++
+		}
+	}
+	if k == 0 {
+		return 0
+	}
+	return sum / float64(k)
+}
+
+func maskedSumSq(x []float64, m []bool, mean float64) float64 {
+	sum := 0.0
+	for i, v := range x {
+		if m[i] {
+			sum += (v - mean) * (v - mean)
+		}
+	}
+	return sum
+}
@@ -0,0 +1,112 @@
+package correlation
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// xcorrMaskedBruteForce computes MaskedCorr with O(n^2) sums.
+func xcorrMaskedBruteForce(x1, x2 []float64, m1, m2 []bool, circular bool) MaskedCorr {
+	n := len(x1)
+	res := MaskedCorr{Sum: make([]float64, n), N: make([]int, n), Coeff: make([]float64, n)}
+	for i := 0; i < n; i++ {
+		var a, b []float64
+		for k := 0; k < n; k++ {
+			j := k + i
+			if j >= n {
+				if !circular {
+					break
+				}
+				j -= n
+			}
+			if m1[k] && m2[j] {
+				a = append(a, x1[k])
+				b = append(b, x2[j])
+				res.Sum[i] += x1[k] * x2[j]
+			}
+		}
+		res.N[i] = len(a)
+		res.Coeff[i] = pearson(a, b)
+	}
+	return res
+}
+
+func pearson(a, b []float64) float64 {
+	if len(a) < 2 {
+		return math.NaN()
+	}
+	var ma, mb float64
+	for i := range a {
+		ma += a[i]
+		mb += b[i]
+	}
+	ma /= float64(len(a))
+	mb /= float64(len(b))
+	var sab, saa, sbb float64
+	for i := range a {
+		sab += (a[i] - ma) * (b[i] - mb)
+		saa += (a[i] - ma) * (a[i] - ma)
+		sbb += (b[i] - mb) * (b[i] - mb)
+	}
+	if saa == 0 || sbb == 0 {
+		return math.NaN()
+	}
+	return sab / math.Sqrt(saa*sbb)
+}
+
+func TestXCorrFFTMasked(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	n := 50
+	x1 := make([]float64, n)
+	x2 := make([]float64, n)
+	m1 := make([]bool, n)
+	m2 := make([]bool, n)
+	for i := 0; i < n; i++ {
+		x1[i] = 100 + rng.NormFloat64()
+		x2[i] = 3*x1[i] + rng.NormFloat64()
+		m1[i] = rng.Float64() < 0.8
+		m2[i] = rng.Float64() < 0.7
+		if !m1[i] {
+			x1[i] = math.NaN()
+		}
+	}
+
+	for _, circular := range []bool{true, false} {
+		expected := xcorrMaskedBruteForce(x1, x2, m1, m2, circular)
+		actual := XCorrFFTMasked(x1, x2, m1, m2, circular)
+		for i := 0; i < n; i++ {
+			if actual.N[i] != expected.N[i] {
+				t.Errorf("circular=%v, lag %d: %d pairs, expected %d\n", circular, i, actual.N[i], expected.N[i])
+			}
+			if !EqualFloat64(actual.Sum[i], expected.Sum[i], 1e-8, 1) {
+				t.Errorf("circular=%v, lag %d: sum %f, expected %f\n", circular, i, actual.Sum[i], expected.Sum[i])
+			}
+			if !EqualFloat64(actual.Coeff[i], expected.Coeff[i], 1e-8, 0) {
+				t.Errorf("circular=%v, lag %d: coefficient %f, expected %f\n", circular, i, actual.Coeff[i], expected.Coeff[i])
+			}
+		}
+	}
+}
+
+func TestAutoCorrFFTMaskedFullMask(t *testing.T) {
+	data := []float64{
+		0.1576, 0.9706, 0.9572, 0.4854, 0.8003, 0.1419, 0.4218, 0.9157, 0.7922, 0.9595,
+	}
+	mask := make([]bool, len(data))
+	for i := range mask {
+		mask[i] = true
+	}
+	for _, circular := range []bool{true, false} {
+		expected := AutoCorrBruteForce(data, circular)
+		actual := AutoCorrFFTMasked(data, mask, circular)
+		for i := range expected {
+			if math.Abs(actual.Sum[i]-expected[i]) > tolerance {
+				t.Errorf("circular=%v, lag %d: sum %f, expected %f\n", circular, i, actual.Sum[i], expected[i])
+			}
+		}
+		if math.Abs(actual.Coeff[0]-1) > tolerance {
+			t.Errorf("circular=%v: coefficient at lag 0 is %f, expected 1\n", circular, actual.Coeff[0])
+		}
+	}
+}
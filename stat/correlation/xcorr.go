@@ -0,0 +1,145 @@
+package correlation
+
+import (
+	"github.com/mingzhi/gomath/fft"
+	"math"
+)
+
+// Mode selects which part of a linear correlation or convolution is returned,
+// following NumPy and SciPy.
+type Mode int
+
+const (
+	// FullMode returns every lag at which the sequences overlap.
+	FullMode Mode = iota
+	// SameMode returns the central part of the full result
+	// with the same length as the first sequence.
+	SameMode
+	// ValidMode returns only the lags at which one sequence
+	// overlaps the other completely.
+	ValidMode
+)
+
+// Normalization selects how a cross-correlation is scaled,
+// following MATLAB's xcorr.
+type Normalization int
+
+const (
+	// NoNormalization returns the raw sums.
+	NoNormalization Normalization = iota
+	// BiasedNormalization divides by the length of the longer sequence.
+	BiasedNormalization
+	// UnbiasedNormalization divides lag m by N-|m|, with N the length of the
+	// longer sequence. As in MATLAB, the shorter sequence counts as zero-padded
+	// to length N, so for sequences of different lengths the divisor can be
+	// larger than the number of overlapping pairs.
+	UnbiasedNormalization
+	// CoeffNormalization divides by sqrt(sum(x^2) * sum(y^2)),
+	// so that the autocorrelation at lag 0 is 1.
+	CoeffNormalization
+)
+
+// AutoCorr returns the autocorrelation of x, with the lags, in the given
+// mode and normalization. See XCorr.
+func AutoCorr(x []float64, mode Mode, norm Normalization) (lags []int, r []float64) {
+	return XCorr(x, x, mode, norm)
+}
+
+// XCorr returns the cross-correlation
+//
+// r[m] = sum_n x[n+m] * y[n]
+//
+// of x and y, which may have different lengths, together with the lag m of
+// each returned value. In FullMode the lags run from -(len(y)-1) to len(x)-1.
+// The result is computed with a zero-padded FFT.
+func XCorr(x, y []float64, mode Mode, norm Normalization) (lags []int, r []float64) {
+	nx, ny := len(x), len(y)
+	if nx == 0 || ny == 0 {
+		panic("correlation: empty sequence")
+	}
+
+	full := xcorrFull(x, y)
+	start, length := modeRange(nx, ny, mode)
+	lags = make([]int, length)
+	r = full[start : start+length]
+	for i := range lags {
+		lags[i] = start + i - (ny - 1)
+	}
+
+	n := nx
+	if ny > n {
+		n = ny
+	}
+	switch norm {
+	case BiasedNormalization:
+		for i := range r {
+			r[i] /= float64(n)
+		}
+	case UnbiasedNormalization:
+		for i, m := range lags {
+			if m < 0 {
+				m = -m
+			}
+			r[i] /= float64(n - m)
+		}
+	case CoeffNormalization:
+		var sxx, syy float64
+		for _, v := range x {
+			sxx += v * v
+		}
+		for _, v := range y {
+			syy += v * v
+		}
+		d := math.Sqrt(sxx * syy)
+		for i := range r {
+			r[i] /= d
+		}
+	}
+	return
+}
+
+// xcorrFull returns the full cross-correlation of x and y,
+// where index j holds lag j-(len(y)-1).
+func xcorrFull(x, y []float64) []float64 {
+	nx, ny := len(x), len(y)
+	ftlength := fft.NextFastLen(nx + ny - 1)
+	plan := fft.NewRealPlan(ftlength)
+
+	data := make([]float64, ftlength)
+	copy(data, x)
+	vx := plan.Forward(nil, data)
+	zero(data)
+	copy(data, y)
+	vy := plan.Forward(nil, data)
+	for i := range vx {
+		vx[i] *= complex(real(vy[i]), -imag(vy[i]))
+	}
+	rs := plan.Inverse(data, vx)
+
+	full := make([]float64, nx+ny-1)
+	for j := range full {
+		m := j - (ny - 1)
+		if m < 0 {
+			m += ftlength
+		}
+		full[j] = rs[m] / float64(ftlength)
+	}
+	return full
+}
+
+// modeRange returns the part of a full result of length nx+ny-1
+// that is returned in the given mode.
+func modeRange(nx, ny int, mode Mode) (start, length int) {
+	switch mode {
+	case FullMode:
+		return 0, nx + ny - 1
+	case SameMode:
+		return (ny - 1) / 2, nx
+	case ValidMode:
+		if nx >= ny {
+			return ny - 1, nx - ny + 1
+		}
+		return nx - 1, ny - nx + 1
+	}
+	panic("correlation: unknown mode")
+}
@@ -0,0 +1,111 @@
+package correlation
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestXCorrModes(t *testing.T) {
+	x := []float64{1, 2, 3}
+	y := []float64{1, 1}
+	cases := []struct {
+		mode     Mode
+		norm     Normalization
+		lags     []int
+		expected []float64
+	}{
+		{FullMode, NoNormalization, []int{-1, 0, 1, 2}, []float64{1, 3, 5, 3}},
+		{SameMode, NoNormalization, []int{-1, 0, 1}, []float64{1, 3, 5}},
+		{ValidMode, NoNormalization, []int{0, 1}, []float64{3, 5}},
+		{FullMode, BiasedNormalization, []int{-1, 0, 1, 2}, []float64{1.0 / 3, 1, 5.0 / 3, 1}},
+		{FullMode, UnbiasedNormalization, []int{-1, 0, 1, 2}, []float64{0.5, 1, 2.5, 3}},
+		{ValidMode, CoeffNormalization, []int{0, 1}, []float64{3 / math.Sqrt(28), 5 / math.Sqrt(28)}},
+	}
+	for _, c := range cases {
+		lags, r := XCorr(x, y, c.mode, c.norm)
+		if len(lags) != len(c.lags) || len(r) != len(c.expected) {
+			t.Errorf("mode %d, normalization %d: got %d lags and %d values, expected %d\n", c.mode, c.norm, len(lags), len(r), len(c.lags))
+			continue
+		}
+		for i := range lags {
+			if lags[i] != c.lags[i] || math.Abs(r[i]-c.expected[i]) > tolerance {
+				t.Errorf("mode %d, normalization %d: got %f at lag %d, expected %f at lag %d\n", c.mode, c.norm, r[i], lags[i], c.expected[i], c.lags[i])
+			}
+		}
+	}
+}
+
+func TestXCorrUnequalLengths(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, lens := range [][2]int{{7, 3}, {3, 7}, {8, 8}, {1, 5}, {6, 1}} {
+		x := randomSeries(rng, 1, lens[0])[0]
+		y := randomSeries(rng, 1, lens[1])[0]
+		for _, mode := range []Mode{FullMode, SameMode, ValidMode} {
+			lags, r := XCorr(x, y, mode, NoNormalization)
+			for i, m := range lags {
+				expected := 0.0
+				for n := 0; n < len(y); n++ {
+					if n+m >= 0 && n+m < len(x) {
+						expected += x[n+m] * y[n]
+					}
+				}
+				if math.Abs(r[i]-expected) > tolerance {
+					t.Errorf("lengths %v, mode %d: got %f at lag %d, expected %f\n", lens, mode, r[i], m, expected)
+				}
+			}
+		}
+	}
+}
+
+func TestXCorrUnbiasedUnequalLengths(t *testing.T) {
+	// as MATLAB, the shorter sequence is zero-padded to the longer length N
+	// and lag m is divided by N-|m|
+	rng := rand.New(rand.NewSource(2))
+	for _, lens := range [][2]int{{7, 3}, {3, 7}, {6, 1}} {
+		x := randomSeries(rng, 1, lens[0])[0]
+		y := randomSeries(rng, 1, lens[1])[0]
+		n := lens[0]
+		if lens[1] > n {
+			n = lens[1]
+		}
+		xp := append(append([]float64(nil), x...), make([]float64, n-len(x))...)
+		yp := append(append([]float64(nil), y...), make([]float64, n-len(y))...)
+		lags, r := XCorr(x, y, FullMode, UnbiasedNormalization)
+		plags, pr := XCorr(xp, yp, FullMode, UnbiasedNormalization)
+		for i, m := range lags {
+			j := m - plags[0]
+			if plags[j] != m || math.Abs(r[i]-pr[j]) > tolerance {
+				t.Errorf("lengths %v: got %f at lag %d, expected %f\n", lens, r[i], m, pr[j])
+			}
+		}
+	}
+	_, r := XCorr([]float64{1, 2, 3, 4}, []float64{1, 1}, FullMode, UnbiasedNormalization)
+	expected := []float64{1.0 / 3, 3.0 / 4, 5.0 / 3, 7.0 / 2, 4}
+	for i := range r {
+		if math.Abs(r[i]-expected[i]) > tolerance {
+			t.Errorf("got %f at index %d, expected %f\n", r[i], i, expected[i])
+		}
+	}
+}
+
+func TestAutoCorrMatchesAutoCorrFFT(t *testing.T) {
+	data := []float64{
+		0.1576, 0.9706, 0.9572, 0.4854, 0.8003, 0.1419, 0.4218, 0.9157, 0.7922, 0.9595,
+	}
+	expected := AutoCorrFFT(data, false)
+	lags, r := AutoCorr(data, FullMode, NoNormalization)
+	for i, m := range lags {
+		k := m
+		if k < 0 {
+			k = -k
+		}
+		if math.Abs(r[i]-expected[k]) > tolerance {
+			t.Errorf("got %f at lag %d, expected %f\n", r[i], m, expected[k])
+		}
+	}
+	_, r = AutoCorr(data, FullMode, CoeffNormalization)
+	if math.Abs(r[len(data)-1]-1) > tolerance {
+		t.Errorf("coefficient at lag 0 is %f, expected 1\n", r[len(data)-1])
+	}
+}
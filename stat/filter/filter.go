@@ -0,0 +1,180 @@
+// Digital filtering of real signals: FIR filters applied with FFT
+// convolution, IIR filters in direct form II transposed, and zero-phase
+// forward-backward filtering.
+package filter
+
+import (
+	"github.com/mingzhi/gomath/stat/correlation"
+	"math"
+)
+
+// FIR applies the finite impulse response filter with coefficients b to x,
+//
+// y[n] = sum_k b[k] * x[n-k],
+//
+// and returns the len(x) causal outputs. It is computed with an FFT
+// convolution; use correlation.OverlapAdd for signals that do not fit in
+// memory.
+func FIR(b, x []float64) []float64 {
+	if len(x) == 0 {
+		return []float64{}
+	}
+	return correlation.Convolve(x, b, correlation.FullMode)[:len(x)]
+}
+
+// Filter applies the IIR filter with numerator b and denominator a to x,
+//
+// a[0]*y[n] = sum_k b[k]*x[n-k] - sum_{k>=1} a[k]*y[n-k],
+//
+// in direct form II transposed, like MATLAB and SciPy's lfilter.
+// zi is the initial state of length max(len(a), len(b))-1, or nil for rest;
+// the final state is returned in zf.
+func Filter(b, a, x, zi []float64) (y, zf []float64) {
+	b, a = normalize(b, a)
+	n := len(a)
+	zf = make([]float64, n-1)
+	if zi != nil {
+		if len(zi) != n-1 {
+			panic("filter: initial state has the wrong length")
+		}
+		copy(zf, zi)
+	}
+
+	y = make([]float64, len(x))
+	for i, v := range x {
+		if n == 1 {
+			y[i] = b[0] * v
+			continue
+		}
+		out := b[0]*v + zf[0]
+		for j := 0; j < n-2; j++ {
+			zf[j] = b[j+1]*v + zf[j+1] - a[j+1]*out
+		}
+		zf[n-2] = b[n-1]*v - a[n-1]*out
+		y[i] = out
+	}
+	return
+}
+
+// FilterZI returns the initial state of Filter for the steady state of the
+// step response, so that filtering a constant signal c with initial state
+// c*zi starts without a transient (SciPy's lfilter_zi).
+func FilterZI(b, a []float64) []float64 {
+	b, a = normalize(b, a)
+	n := len(a) - 1
+	if n == 0 {
+		return []float64{}
+	}
+
+	// (I - companion(a)') zi = b[1:] - a[1:]*b[0]
+	m := make([][]float64, n)
+	for i := range m {
+		m[i] = make([]float64, n+1)
+		m[i][i] = 1
+		m[i][0] += a[i+1]
+		if i+1 < n {
+			m[i][i+1] -= 1
+		}
+		m[i][n] = b[i+1] - a[i+1]*b[0]
+	}
+	return solve(m)
+}
+
+// FiltFilt applies the IIR filter with numerator b and denominator a to x
+// forward and then backward, which gives zero phase distortion and squares
+// the magnitude response. As in SciPy's filtfilt, x is extended at both
+// ends by 3*max(len(a), len(b)) values with odd symmetry, and each pass
+// starts from the steady state of FilterZI to reduce edge transients.
+func FiltFilt(b, a, x []float64) []float64 {
+	ntaps := len(a)
+	if len(b) > ntaps {
+		ntaps = len(b)
+	}
+	pad := 3 * ntaps
+	n := len(x)
+	if n <= pad {
+		panic("filter: signal must be longer than 3*max(len(a), len(b))")
+	}
+
+	ext := make([]float64, n+2*pad)
+	for i := 0; i < pad; i++ {
+		ext[i] = 2*x[0] - x[pad-i]
+		ext[n+pad+i] = 2*x[n-1] - x[n-2-i]
+	}
+	copy(ext[pad:], x)
+
+	zi := FilterZI(b, a)
+	state := make([]float64, len(zi))
+	for i, v := range zi {
+		state[i] = v * ext[0]
+	}
+	y, _ := Filter(b, a, ext, state)
+
+	reverse(y)
+	for i, v := range zi {
+		state[i] = v * y[0]
+	}
+	y, _ = Filter(b, a, y, state)
+	reverse(y)
+
+	return y[pad : pad+n]
+}
+
+// normalize returns copies of b and a of equal length with a[0] = 1.
+func normalize(b, a []float64) ([]float64, []float64) {
+	if len(a) == 0 || a[0] == 0 {
+		panic("filter: a[0] must be non-zero")
+	}
+	if len(b) == 0 {
+		panic("filter: empty numerator")
+	}
+	n := len(a)
+	if len(b) > n {
+		n = len(b)
+	}
+	nb := make([]float64, n)
+	na := make([]float64, n)
+	for i, v := range b {
+		nb[i] = v / a[0]
+	}
+	for i, v := range a {
+		na[i] = v / a[0]
+	}
+	return nb, na
+}
+
+// solve solves the augmented linear system m by Gaussian elimination
+// with partial pivoting.
+func solve(m [][]float64) []float64 {
+	n := len(m)
+	for i := 0; i < n; i++ {
+		p := i
+		for j := i + 1; j < n; j++ {
+			if math.Abs(m[j][i]) > math.Abs(m[p][i]) {
+				p = j
+			}
+		}
+		m[i], m[p] = m[p], m[i]
+		for j := i + 1; j < n; j++ {
+			f := m[j][i] / m[i][i]
+			for k := i; k <= n; k++ {
+				m[j][k] -= f * m[i][k]
+			}
+		}
+	}
+	x := make([]float64, n)
+	for i := n - 1; i >= 0; i-- {
+		x[i] = m[i][n]
+		for j := i + 1; j < n; j++ {
+			x[i] -= m[i][j] * x[j]
+		}
+		x[i] /= m[i][i]
+	}
+	return x
+}
+
+func reverse(x []float64) {
+	for i, j := 0, len(x)-1; i < j; i, j = i+1, j-1 {
+		x[i], x[j] = x[j], x[i]
+	}
+}
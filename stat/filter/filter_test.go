@@ -0,0 +1,128 @@
+package filter
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+const tolerance = 1e-10
+
+func TestFilter(t *testing.T) {
+	b := []float64{0.2, 0.3, 0.1}
+	a := []float64{2, -0.6, 0.2, 0.1}
+	rng := rand.New(rand.NewSource(1))
+	x := make([]float64, 50)
+	for i := range x {
+		x[i] = rng.NormFloat64()
+	}
+
+	// direct evaluation of the difference equation
+	expected := make([]float64, len(x))
+	for n := range x {
+		s := 0.0
+		for k := range b {
+			if n-k >= 0 {
+				s += b[k] * x[n-k]
+			}
+		}
+		for k := 1; k < len(a); k++ {
+			if n-k >= 0 {
+				s -= a[k] * expected[n-k]
+			}
+		}
+		expected[n] = s / a[0]
+	}
+
+	y, _ := Filter(b, a, x, nil)
+	for i := range y {
+		if math.Abs(y[i]-expected[i]) > tolerance {
+			t.Errorf("Filter output %f at %d, expected %f", y[i], i, expected[i])
+		}
+	}
+
+	// filtering in two pieces carries the state over
+	y1, zf := Filter(b, a, x[:20], nil)
+	y2, _ := Filter(b, a, x[20:], zf)
+	y = append(y1, y2...)
+	for i := range y {
+		if math.Abs(y[i]-expected[i]) > tolerance {
+			t.Errorf("split Filter output %f at %d, expected %f", y[i], i, expected[i])
+		}
+	}
+
+	// FIR agrees with Filter
+	y = FIR(b, x)
+	e, _ := Filter(b, []float64{1}, x, nil)
+	for i := range y {
+		if math.Abs(y[i]-e[i]) > tolerance {
+			t.Errorf("FIR output %f at %d, expected %f", y[i], i, e[i])
+		}
+	}
+}
+
+func TestFilterZI(t *testing.T) {
+	b := []float64{0.0675, 0.1349, 0.0675}
+	a := []float64{1, -1.1430, 0.4128}
+	zi := FilterZI(b, a)
+	gain := (b[0] + b[1] + b[2]) / (a[0] + a[1] + a[2])
+	c := 3.0
+	x := []float64{c, c, c, c, c}
+	state := []float64{c * zi[0], c * zi[1]}
+	y, _ := Filter(b, a, x, state)
+	for i := range y {
+		if math.Abs(y[i]-c*gain) > tolerance {
+			t.Errorf("step response %f at %d, expected steady state %f", y[i], i, c*gain)
+		}
+	}
+}
+
+func TestFiltFilt(t *testing.T) {
+	// a moving average filtered forward and backward is the centered
+	// convolution with [1 2 3 2 1]/9 away from the edges
+	b := []float64{1.0 / 3, 1.0 / 3, 1.0 / 3}
+	rng := rand.New(rand.NewSource(2))
+	x := make([]float64, 40)
+	for i := range x {
+		x[i] = rng.Float64()
+	}
+	y := FiltFilt(b, []float64{1}, x)
+	h := []float64{1, 2, 3, 2, 1}
+	for i := 2; i < len(x)-2; i++ {
+		expected := 0.0
+		for k := range h {
+			expected += h[k] * x[i+k-2] / 9
+		}
+		if math.Abs(y[i]-expected) > tolerance {
+			t.Errorf("FiltFilt output %f at %d, expected %f", y[i], i, expected)
+		}
+	}
+
+	// zero phase: a low-pass filtered slow sinusoid keeps its phase
+	bl := []float64{0.0675, 0.1349, 0.0675}
+	al := []float64{1, -1.1430, 0.4128}
+	n := 400
+	x = make([]float64, n)
+	for i := range x {
+		x[i] = 1 + math.Sin(2*math.Pi*float64(i)/100)
+	}
+	y = FiltFilt(bl, al, x)
+	for i := 50; i < n-50; i++ {
+		if math.Abs(y[i]-x[i]) > 0.02 {
+			t.Errorf("filtered sinusoid %f at %d, expected about %f", y[i], i, x[i])
+			break
+		}
+	}
+	// constant signals pass without edge transients
+	for i := range x {
+		x[i] = 2
+	}
+	gain := (bl[0] + bl[1] + bl[2]) / (al[0] + al[1] + al[2])
+	y = FiltFilt(bl, al, x)
+	for i := range y {
+		if math.Abs(y[i]-2*gain*gain) > 1e-9 {
+			t.Errorf("filtered constant %f at %d, expected %f", y[i], i, 2*gain*gain)
+			break
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package regression
+
+import (
+	"github.com/mingzhi/gomath/random"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Estimates a straight line by Deming regression, for data in which both
+// x and y are measured with errors of constant variances with a known ratio
+//
+// ratio = var(error of y) / var(error of x).
+//
+// The line minimizes the sum of squared distances of the observations to
+// the line measured along a direction set by the ratio; ratio = 1 gives
+// orthogonal regression (total least squares), which minimizes the
+// perpendicular distances. With sxx, syy and sxy the sums of squares and
+// products about the means, the slope is
+//
+// b = (syy - ratio*sxx + sqrt((syy - ratio*sxx)^2 + 4*ratio*sxy^2)) / (2*sxy).
+//
+// Unlike least squares, the slope is not attenuated by errors in x.
+type Deming struct {
+	line
+	ratio float64
+	s     Simple // moments of the observations
+}
+
+// NewDeming fits a Deming regression line with the given positive ratio of
+// the error variance of y to that of x.
+func NewDeming(x, y []float64, ratio float64) *Deming {
+	checkLineData(x, y)
+	if !(ratio > 0) {
+		panic("regression: error variance ratio must be positive")
+	}
+	d := &Deming{line: line{x: x, y: y}, ratio: ratio}
+	for i, v := range x {
+		d.s.Add(v, y[i])
+	}
+	d.slope, d.intercept = demingLine(&d.s, ratio)
+	return d
+}
+
+// NewOrthogonal fits an orthogonal regression line, a Deming regression
+// with equal error variances.
+func NewOrthogonal(x, y []float64) *Deming {
+	return NewDeming(x, y, 1)
+}
+
+// demingLine returns the Deming line of the observations in s.
+func demingLine(s *Simple, ratio float64) (slope, intercept float64) {
+	sxx, syy, sxy := s.sumXX, s.sumYY, s.sumXY
+	d := syy - ratio*sxx
+	r := math.Sqrt(d*d + 4*ratio*sxy*sxy)
+	// the root without cancellation
+	if d >= 0 {
+		slope = (d + r) / (2 * sxy)
+	} else {
+		slope = 2 * ratio * sxy / (r - d)
+	}
+	return slope, s.ybar - slope*s.xbar
+}
+
+// Returns the ratio of the error variance of y to that of x.
+func (d *Deming) Ratio() float64 {
+	return d.ratio
+}
+
+// JackknifeIntervals returns the 1-alpha confidence intervals of the slope
+// and intercept from their jackknife standard errors,
+//
+// se^2 = (n-1)/n * sum_i (b_(i) - mean(b_(i)))^2,
+//
+// where b_(i) is the estimate without observation i, as estimate +/-
+// t(1-alpha/2, n-2) * se (Linnet, 1990, as R's mcr package).
+func (d *Deming) JackknifeIntervals(alpha float64) (slope, intercept [2]float64) {
+	if alpha <= 0 || alpha >= 1 {
+		panic("regression: alpha must be in (0, 1)")
+	}
+	n := len(d.x)
+	if n < 3 {
+		panic("regression: at least three observations are needed")
+	}
+	bs := make([]float64, n)
+	as := make([]float64, n)
+	for i, v := range d.x {
+		s := d.s
+		s.Remove(v, d.y[i])
+		bs[i], as[i] = demingLine(&s, d.ratio)
+	}
+	t := studentTQuantile(1-alpha/2, float64(n-2))
+	h := t * jackknifeStdErr(bs)
+	slope = [2]float64{d.slope - h, d.slope + h}
+	h = t * jackknifeStdErr(as)
+	intercept = [2]float64{d.intercept - h, d.intercept + h}
+	return
+}
+
+func jackknifeStdErr(v []float64) float64 {
+	n := float64(len(v))
+	mean := 0.0
+	for _, x := range v {
+		mean += x
+	}
+	mean /= n
+	ss := 0.0
+	for _, x := range v {
+		ss += (x - mean) * (x - mean)
+	}
+	return math.Sqrt((n - 1) / n * ss)
+}
+
+// BootstrapIntervals returns the 1-alpha percentile bootstrap confidence
+// intervals of the slope and intercept from b resamples of the observations.
+func (d *Deming) BootstrapIntervals(alpha float64, b int, src rand.Source) (slope, intercept [2]float64) {
+	return bootstrapLine(d.x, d.y, alpha, b, src, func(x, y []float64) (float64, float64) {
+		var s Simple
+		for i, v := range x {
+			s.Add(v, y[i])
+		}
+		return demingLine(&s, d.ratio)
+	})
+}
+
+// bootstrapLine returns the percentile bootstrap intervals of the line
+// fit by fit to b resamples of the pairs (x, y). Resamples on which the fit
+// is not finite are discarded.
+func bootstrapLine(x, y []float64, alpha float64, b int, src rand.Source,
+	fit func(x, y []float64) (slope, intercept float64)) (slope, intercept [2]float64) {
+	if alpha <= 0 || alpha >= 1 {
+		panic("regression: alpha must be in (0, 1)")
+	}
+	if b < 2 {
+		panic("regression: at least two resamples are needed")
+	}
+	rng := rand.New(src)
+	n := len(x)
+	xb := make([]float64, n)
+	yb := make([]float64, n)
+	var bs, as []float64
+	for r := 0; r < b; r++ {
+		for i := range xb {
+			j := rng.Intn(n)
+			xb[i], yb[i] = x[j], y[j]
+		}
+		sb, ib := fit(xb, yb)
+		if math.IsNaN(sb) || math.IsInf(sb, 0) || math.IsNaN(ib) || math.IsInf(ib, 0) {
+			continue
+		}
+		bs = append(bs, sb)
+		as = append(as, ib)
+	}
+	if len(bs) < 2 {
+		nan := [2]float64{math.NaN(), math.NaN()}
+		return nan, nan
+	}
+	sort.Float64s(bs)
+	sort.Float64s(as)
+	slope = [2]float64{quantile(bs, alpha/2), quantile(bs, 1-alpha/2)}
+	intercept = [2]float64{quantile(as, alpha/2), quantile(as, 1-alpha/2)}
+	return
+}
+
+// Estimates a straight line by Passing-Bablok regression (Passing and
+// Bablok, 1983), a nonparametric method for comparing two measurement
+// methods with errors in both x and y that does not assume their
+// distribution or ratio. The slope is the median of the slopes between
+// pairs of observations, shifted by the number K of slopes below -1 so
+// that it is unbiased when the methods agree; slopes of exactly -1 and
+// pairs of identical observations are left out, and pairs with equal x count
+// as infinite slopes. The intercept is the median of y - b*x.
+// The method assumes that x and y are positively related.
+// All the pairwise slopes are kept, which takes O(n^2) memory.
+type PassingBablok struct {
+	line
+	slopes []float64 // sorted pairwise slopes
+	k      int       // number of slopes below -1
+}
+
+// NewPassingBablok fits a Passing-Bablok regression line.
+func NewPassingBablok(x, y []float64) *PassingBablok {
+	checkLineData(x, y)
+	p := &PassingBablok{line: line{x: x, y: y}}
+	for i := range x {
+		for j := i + 1; j < len(x); j++ {
+			dx, dy := x[j]-x[i], y[j]-y[i]
+			var s float64
+			switch {
+			case dx == 0 && dy == 0:
+				continue
+			case dx == 0:
+				s = math.Copysign(math.Inf(1), dy)
+			default:
+				s = dy / dx
+			}
+			if s == -1 {
+				continue
+			}
+			if s < -1 {
+				p.k++
+			}
+			p.slopes = append(p.slopes, s)
+		}
+	}
+	if len(p.slopes) == 0 {
+		panic("regression: all the observations are equal")
+	}
+	sort.Float64s(p.slopes)
+	m := len(p.slopes)
+	if m%2 == 1 {
+		p.slope = p.order((m + 1) / 2)
+	} else {
+		p.slope = (p.order(m/2) + p.order(m/2+1)) / 2
+	}
+	p.intercept = p.interceptFor(p.slope)
+	return p
+}
+
+// order returns the shifted order statistic S_(i+K), 1-based,
+// clamped to the range of the slopes.
+func (p *PassingBablok) order(i int) float64 {
+	i += p.k
+	if i < 1 {
+		i = 1
+	}
+	if i > len(p.slopes) {
+		i = len(p.slopes)
+	}
+	return p.slopes[i-1]
+}
+
+func (p *PassingBablok) interceptFor(slope float64) float64 {
+	d := make([]float64, len(p.x))
+	for i, v := range p.x {
+		d[i] = p.y[i] - slope*v
+	}
+	return median(d)
+}
+
+// Intervals returns the distribution free 1-alpha confidence intervals of the
+// slope and intercept of Passing and Bablok: the slope limits are shifted
+// order statistics of the slopes of ranks (N -/+ C)/2, with N the number of
+// slopes and
+//
+// C = z(1-alpha/2) * sqrt(n*(n-1)*(2*n+5)/18),
+//
+// and the intercept limits are the medians of y - b*x at the slope limits.
+func (p *PassingBablok) Intervals(alpha float64) (slope, intercept [2]float64) {
+	if alpha <= 0 || alpha >= 1 {
+		panic("regression: alpha must be in (0, 1)")
+	}
+	n := float64(len(p.x))
+	c := random.Normal{Sigma: 1}.Quantile(1-alpha/2) * math.Sqrt(n*(n-1)*(2*n+5)/18)
+	m := len(p.slopes)
+	m1 := int(math.Round((float64(m) - c) / 2))
+	m2 := m - m1 + 1
+	slope = [2]float64{p.order(m1), p.order(m2)}
+	intercept = [2]float64{p.interceptFor(slope[1]), p.interceptFor(slope[0])}
+	return
+}
+
+// BootstrapIntervals returns the 1-alpha percentile bootstrap confidence
+// intervals of the slope and intercept from b resamples of the observations.
+func (p *PassingBablok) BootstrapIntervals(alpha float64, b int, src rand.Source) (slope, intercept [2]float64) {
+	return bootstrapLine(p.x, p.y, alpha, b, src, func(x, y []float64) (float64, float64) {
+		same := true
+		for i := range x {
+			same = same && x[i] == x[0] && y[i] == y[0]
+		}
+		if same {
+			return math.NaN(), math.NaN()
+		}
+		f := NewPassingBablok(x, y)
+		return f.slope, f.intercept
+	})
+}
@@ -0,0 +1,171 @@
+package regression
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// methodData returns two measurements with errors of the same true values,
+// with y = 2 + 1.1 * truth.
+func methodData(n int, sx, sy float64, seed int64) (x, y []float64) {
+	rng := rand.New(rand.NewSource(seed))
+	x = make([]float64, n)
+	y = make([]float64, n)
+	for i := range x {
+		truth := 10 + 40*rng.Float64()
+		x[i] = truth + sx*rng.NormFloat64()
+		y[i] = 2 + 1.1*truth + sy*rng.NormFloat64()
+	}
+	return
+}
+
+func TestDeming(t *testing.T) {
+	x, y := methodData(60, 4, 1, 1)
+
+	// the slope lies between those of y on x and of x on y
+	s := NewSimple()
+	for i, v := range x {
+		s.Add(v, y[i])
+	}
+	byx := s.Slope()
+	bxy := s.TotalSumSquares() / s.SumOfCrossProducts()
+	d := NewDeming(x, y, 0.0625)
+	if d.Slope() <= byx || d.Slope() >= bxy {
+		t.Errorf("slope %g not in (%g, %g)", d.Slope(), byx, bxy)
+	}
+	if math.Abs(d.Slope()-1.1) > 0.05 || math.Abs(byx-1.1) < 0.05 {
+		t.Errorf("slope %g, least squares %g, expected about 1.1", d.Slope(), byx)
+	}
+	// and tends to them as the ratio tends to infinity and zero
+	if b := NewDeming(x, y, 1e12).Slope(); relErr(b, byx) > 1e-8 {
+		t.Errorf("slope %g for a large ratio, expected %g", b, byx)
+	}
+	if b := NewDeming(x, y, 1e-12).Slope(); relErr(b, bxy) > 1e-8 {
+		t.Errorf("slope %g for a small ratio, expected %g", b, bxy)
+	}
+	if math.Abs(d.Intercept()-(s.ybar-d.Slope()*s.xbar)) > 1e-12 {
+		t.Errorf("intercept %g", d.Intercept())
+	}
+
+	// orthogonal regression minimizes the perpendicular distances
+	o := NewOrthogonal(x, y)
+	perp := func(b float64) float64 {
+		a := s.ybar - b*s.xbar
+		ss := 0.0
+		for i, v := range x {
+			r := y[i] - a - b*v
+			ss += r * r / (1 + b*b)
+		}
+		return ss
+	}
+	for _, db := range []float64{-1e-3, 1e-3} {
+		if perp(o.Slope()+db) < perp(o.Slope()) {
+			t.Errorf("orthogonal slope %g is not optimal", o.Slope())
+		}
+	}
+
+	// jackknife intervals from refits without each observation
+	slope, intercept := d.JackknifeIntervals(0.05)
+	bs := make([]float64, len(x))
+	as := make([]float64, len(x))
+	for i := range x {
+		xi := append(append([]float64(nil), x[:i]...), x[i+1:]...)
+		yi := append(append([]float64(nil), y[:i]...), y[i+1:]...)
+		di := NewDeming(xi, yi, 0.0625)
+		bs[i], as[i] = di.Slope(), di.Intercept()
+	}
+	tq := studentTQuantile(0.975, float64(len(x)-2))
+	if h := tq * jackknifeStdErr(bs); math.Abs(slope[1]-d.Slope()-h) > 1e-9 ||
+		math.Abs(d.Slope()-slope[0]-h) > 1e-9 {
+		t.Errorf("slope interval %v, half-width %g", slope, h)
+	}
+	if h := tq * jackknifeStdErr(as); math.Abs(intercept[1]-d.Intercept()-h) > 1e-8 {
+		t.Errorf("intercept interval %v, half-width %g", intercept, h)
+	}
+	if slope[0] > 1.1 || slope[1] < 1.1 {
+		t.Errorf("slope interval %v does not cover 1.1", slope)
+	}
+
+	bslope, bintercept := d.BootstrapIntervals(0.05, 500, rand.NewSource(2))
+	if bslope[0] > d.Slope() || bslope[1] < d.Slope() ||
+		bintercept[0] > d.Intercept() || bintercept[1] < d.Intercept() {
+		t.Errorf("bootstrap intervals %v, %v", bslope, bintercept)
+	}
+	// similar to the jackknife intervals
+	if w, bw := slope[1]-slope[0], bslope[1]-bslope[0]; bw < w/2 || bw > 2*w {
+		t.Errorf("bootstrap width %g, jackknife width %g", bw, w)
+	}
+}
+
+func TestPassingBablok(t *testing.T) {
+	// exact agreement
+	x := []float64{1, 2, 3, 4, 5, 6}
+	y := []float64{3, 5, 7, 9, 11, 13}
+	p := NewPassingBablok(x, y)
+	if p.Slope() != 2 || p.Intercept() != 1 {
+		t.Errorf("line %g + %g x, expected 1 + 2 x", p.Intercept(), p.Slope())
+	}
+
+	// brute force shifted median
+	x, y = methodData(25, 1, 1, 3)
+	x[3], y[3] = x[4], y[4]+1 // equal x
+	x[7], y[7] = x[8], y[8]   // identical pair
+	p = NewPassingBablok(x, y)
+	var slopes []float64
+	k := 0
+	for i := range x {
+		for j := range x {
+			if j <= i || (x[i] == x[j] && y[i] == y[j]) {
+				continue
+			}
+			s := (y[j] - y[i]) / (x[j] - x[i])
+			if x[i] == x[j] {
+				s = math.Inf(1)
+				if y[j] < y[i] {
+					s = math.Inf(-1)
+				}
+			}
+			if s == -1 {
+				continue
+			}
+			if s < -1 {
+				k++
+			}
+			slopes = append(slopes, s)
+		}
+	}
+	below, above := 0, 0
+	for _, s := range slopes {
+		if s < p.Slope() {
+			below++
+		} else if s > p.Slope() {
+			above++
+		}
+	}
+	// the shifted median has K more slopes below than above it
+	if below-above != 2*k && below-above != 2*k-1 && below-above != 2*k+1 {
+		t.Errorf("%d slopes below and %d above %g, K = %d", below, above, p.Slope(), k)
+	}
+	if math.Abs(p.Slope()-1.1) > 0.1 {
+		t.Errorf("slope %g, expected about 1.1", p.Slope())
+	}
+
+	slope, intercept := p.Intervals(0.05)
+	if slope[0] > p.Slope() || slope[1] < p.Slope() || slope[0] > 1.1 || slope[1] < 1.1 {
+		t.Errorf("slope interval %v", slope)
+	}
+	if intercept[0] > p.Intercept() || intercept[1] < p.Intercept() {
+		t.Errorf("intercept interval %v around %g", intercept, p.Intercept())
+	}
+	bslope, _ := p.BootstrapIntervals(0.05, 200, rand.NewSource(4))
+	if bslope[0] > p.Slope() || bslope[1] < p.Slope() {
+		t.Errorf("bootstrap slope interval %v", bslope)
+	}
+
+	// symmetric in x and y
+	x, y = methodData(31, 1, 1, 5)
+	if b, bi := NewPassingBablok(x, y).Slope(), NewPassingBablok(y, x).Slope(); math.Abs(b*bi-1) > 1e-12 {
+		t.Errorf("slopes %g and %g are not reciprocal", b, bi)
+	}
+}
@@ -0,0 +1,339 @@
+package regression
+
+import (
+	"github.com/mingzhi/gomath/random"
+	"math"
+	"sort"
+)
+
+// Influence holds the influence measures of the observations
+// of a linear least squares fit, as R's influence.measures.
+type Influence struct {
+	// Hat holds the leverages, the diagonal of the hat matrix
+	// X(X'X)^-1X'. They sum to the number of coefficients p.
+	Hat []float64
+	// Standardized holds the internally studentized residuals,
+	// e_i / (s * sqrt(1 - h_i)) (R's rstandard).
+	Standardized []float64
+	// Studentized holds the externally studentized residuals,
+	// e_i / (s_(i) * sqrt(1 - h_i)), with s_(i) the residual standard
+	// error of the fit without observation i (R's rstudent). They follow
+	// Student's t distribution with n-p-1 degrees of freedom.
+	Studentized []float64
+	// CooksDistance holds the scaled change of the fitted values when
+	// an observation is left out, r_i^2 * h_i / (p * (1 - h_i)),
+	// with r_i the standardized residual.
+	CooksDistance []float64
+	// DFFITS holds the change of the fitted value of an observation when it
+	// is left out, in units of its standard error, t_i * sqrt(h_i / (1 - h_i)),
+	// with t_i the studentized residual.
+	DFFITS []float64
+}
+
+// newInfluence returns the influence measures for the given leverages
+// and residuals of a fit with p coefficients.
+func newInfluence(hat, residuals []float64, p int) *Influence {
+	n := len(hat)
+	df := float64(n - p)
+	sse := 0.0
+	for _, e := range residuals {
+		sse += e * e
+	}
+	s2 := sse / df
+	in := &Influence{
+		Hat:           hat,
+		Standardized:  make([]float64, n),
+		Studentized:   make([]float64, n),
+		CooksDistance: make([]float64, n),
+		DFFITS:        make([]float64, n),
+	}
+	for i, e := range residuals {
+		h := hat[i]
+		r := e / math.Sqrt(s2*(1-h))
+		si2 := (sse - e*e/(1-h)) / (df - 1) // variance without observation i
+		t := e / math.Sqrt(si2*(1-h))
+		in.Standardized[i] = r
+		in.Studentized[i] = t
+		in.CooksDistance[i] = r * r * h / (float64(p) * (1 - h))
+		in.DFFITS[i] = t * math.Sqrt(h/(1-h))
+	}
+	return in
+}
+
+// Returns the influence measures of the observations.
+func (o *OLS) Influence() *Influence {
+	return newInfluence(append([]float64(nil), o.hat...), o.residuals, o.p)
+}
+
+// Returns the influence measures of the observations (x, y), which must be
+// the observations added to the regression, with unit weights.
+// Simple does not keep its observations, so they are passed again.
+func (s *Simple) Influence(x, y []float64) *Influence {
+	if len(x) != len(y) || len(x) != s.n {
+		panic("regression: x and y must hold the observations of the regression")
+	}
+	if s.nWeighted > 0 {
+		panic("regression: influence measures need an unweighted regression")
+	}
+	hat := make([]float64, len(x))
+	residuals := make([]float64, len(x))
+	p := 2
+	for i, v := range x {
+		if s.noIntercept {
+			hat[i] = v * v / s.sumXX
+		} else {
+			d := v - s.xbar
+			hat[i] = 1/float64(s.n) + d*d/s.sumXX
+		}
+		residuals[i] = y[i] - s.Predict(v)
+	}
+	if s.noIntercept {
+		p = 1
+	}
+	return newInfluence(hat, residuals, p)
+}
+
+// VIF returns the variance inflation factors of the columns of x,
+// 1 / (1 - R_j^2), where R_j^2 is the r-square of the regression of
+// column j on the other columns with an intercept. A factor above 10 is
+// commonly taken as a sign of harmful collinearity; it is +Inf for a column
+// that is a linear combination of the others.
+func VIF(x [][]float64) []float64 {
+	if len(x) == 0 {
+		panic("regression: no observations")
+	}
+	k := len(x[0])
+	vif := make([]float64, k)
+	if k == 1 {
+		vif[0] = 1
+		return vif
+	}
+	others := make([][]float64, len(x))
+	y := make([]float64, len(x))
+	for j := range vif {
+		for i, row := range x {
+			if len(row) != k {
+				panic("regression: rows of x have different lengths")
+			}
+			others[i] = append(append(others[i][:0], row[:j]...), row[j+1:]...)
+			y[i] = row[j]
+		}
+		o, err := NewOLS(others, y, true)
+		if err != nil {
+			vif[j] = math.Inf(1)
+			continue
+		}
+		vif[j] = 1 / (1 - o.RSquare())
+	}
+	return vif
+}
+
+// DurbinWatson returns the Durbin-Watson statistic of the residuals
+// of a regression on time ordered observations,
+//
+// d = sum_t (e_t - e_{t-1})^2 / sum_t e_t^2.
+//
+// d is about 2 * (1 - r), with r the lag one autocorrelation of the
+// residuals: values well below 2 indicate positive autocorrelation.
+func DurbinWatson(residuals []float64) float64 {
+	if len(residuals) < 2 {
+		panic("regression: at least two residuals are needed")
+	}
+	var num, den float64
+	for t, e := range residuals {
+		if t > 0 {
+			d := e - residuals[t-1]
+			num += d * d
+		}
+		den += e * e
+	}
+	return num / den
+}
+
+// BreuschPagan returns the Breusch-Pagan test for heteroscedasticity of the
+// residuals of a linear regression, against variances that depend linearly
+// on the columns of x (usually the independent variables of the regression).
+// The squared residuals are regressed on x with an intercept; with studentize,
+// the statistic is Koenker's n * R^2, which is robust to non-normal errors,
+// as the default of R's lmtest::bptest, otherwise it is the explained sum of
+// squares of e^2/mean(e^2) divided by two. Both are compared to a chi-squared
+// distribution with as many degrees of freedom as columns of x.
+func BreuschPagan(x [][]float64, residuals []float64, studentize bool) (statistic float64, df int, pvalue float64) {
+	n := len(residuals)
+	if len(x) != n {
+		panic("regression: x and residuals have different numbers of observations")
+	}
+	sigma2 := 0.0
+	for _, e := range residuals {
+		sigma2 += e * e
+	}
+	sigma2 /= float64(n)
+	u := make([]float64, n)
+	for i, e := range residuals {
+		u[i] = e * e / sigma2
+	}
+	o, err := NewOLS(x, u, true)
+	if err != nil {
+		panic("regression: " + err.Error())
+	}
+	if studentize {
+		statistic = float64(n) * o.RSquare()
+	} else {
+		statistic = o.RegressionSumSquares() / 2
+	}
+	df = len(x[0])
+	return statistic, df, chiSquaredSurvival(statistic, df)
+}
+
+// JarqueBera returns the Jarque-Bera test of the normality of the residuals,
+//
+// JB = n/6 * (S^2 + (K - 3)^2 / 4),
+//
+// with S and K the sample skewness and kurtosis, compared to its asymptotic
+// chi-squared distribution with 2 degrees of freedom.
+func JarqueBera(residuals []float64) (statistic float64, df int, pvalue float64) {
+	n := float64(len(residuals))
+	if n < 2 {
+		panic("regression: at least two residuals are needed")
+	}
+	mean := 0.0
+	for _, e := range residuals {
+		mean += e
+	}
+	mean /= n
+	var m2, m3, m4 float64
+	for _, e := range residuals {
+		d := e - mean
+		m2 += d * d
+		m3 += d * d * d
+		m4 += d * d * d * d
+	}
+	m2, m3, m4 = m2/n, m3/n, m4/n
+	skew := m3 / math.Pow(m2, 1.5)
+	kurt := m4 / (m2 * m2)
+	statistic = n / 6 * (skew*skew + (kurt-3)*(kurt-3)/4)
+	return statistic, 2, chiSquaredSurvival(statistic, 2)
+}
+
+// ShapiroWilk returns the Shapiro-Wilk test of the normality of the sample x,
+// with 3 <= len(x) <= 5000, computed with Royston's (1995) algorithm AS R94
+// as R's shapiro.test: W is the squared correlation of the ordered sample
+// with approximate normal scores, and its p-value comes from a normalizing
+// transformation of log(1 - W).
+func ShapiroWilk(x []float64) (w, pvalue float64) {
+	n := len(x)
+	if n < 3 || n > 5000 {
+		panic("regression: sample size must be in [3, 5000]")
+	}
+	xs := append([]float64(nil), x...)
+	sort.Float64s(xs)
+	rng := xs[n-1] - xs[0]
+	if rng < 1e-19 {
+		panic("regression: all values are identical")
+	}
+
+	// coefficients a[0..n/2-1] of the lower half, antisymmetric
+	nn2 := n / 2
+	a := make([]float64, nn2)
+	an := float64(n)
+	if n == 3 {
+		a[0] = math.Sqrt(0.5)
+	} else {
+		m := make([]float64, nn2)
+		summ2 := 0.0
+		for i := range m {
+			m[i] = random.Normal{Sigma: 1}.Quantile((float64(i+1) - 0.375) / (an + 0.25))
+			summ2 += m[i] * m[i]
+		}
+		summ2 *= 2
+		ssumm2 := math.Sqrt(summ2)
+		rsn := 1 / math.Sqrt(an)
+		a1 := poly(swC1, rsn) - m[0]/ssumm2
+		i1 := 1
+		var fac float64
+		if n > 5 {
+			i1 = 2
+			a2 := -m[1]/ssumm2 + poly(swC2, rsn)
+			fac = math.Sqrt((summ2 - 2*m[0]*m[0] - 2*m[1]*m[1]) / (1 - 2*a1*a1 - 2*a2*a2))
+			a[1] = a2
+		} else {
+			fac = math.Sqrt((summ2 - 2*m[0]*m[0]) / (1 - 2*a1*a1))
+		}
+		a[0] = a1
+		for i := i1; i < nn2; i++ {
+			a[i] = -m[i] / fac
+		}
+	}
+
+	// W = 1 - w1, with w1 computed accurately from centered coefficients
+	coef := func(i int) float64 {
+		j := n - 1 - i
+		switch {
+		case i < j:
+			return -a[i]
+		case i > j:
+			return a[j]
+		}
+		return 0
+	}
+	var sa, sx float64
+	for i, v := range xs {
+		sa += coef(i)
+		sx += v / rng
+	}
+	sa /= an
+	sx /= an
+	var ssa, ssx, sax float64
+	for i, v := range xs {
+		asa := coef(i) - sa
+		xsx := v/rng - sx
+		ssa += asa * asa
+		ssx += xsx * xsx
+		sax += asa * xsx
+	}
+	ssassx := math.Sqrt(ssa * ssx)
+	w1 := (ssassx - sax) * (ssassx + sax) / (ssa * ssx)
+	w = 1 - w1
+
+	if n == 3 {
+		const pi6, stqr = 6 / math.Pi, math.Pi / 3
+		pvalue = pi6 * (math.Asin(math.Sqrt(w)) - stqr)
+		return w, math.Max(pvalue, 0)
+	}
+	y := math.Log(w1)
+	var mean, sd float64
+	if n <= 11 {
+		gamma := poly(swG, an)
+		if y >= gamma {
+			return w, 1e-99
+		}
+		y = -math.Log(gamma - y)
+		mean = poly(swC3, an)
+		sd = math.Exp(poly(swC4, an))
+	} else {
+		lx := math.Log(an)
+		mean = poly(swC5, lx)
+		sd = math.Exp(poly(swC6, lx))
+	}
+	return w, 0.5 * math.Erfc((y-mean)/(sd*math.Sqrt2))
+}
+
+// polynomial approximations of AS R94
+var (
+	swG  = []float64{-2.273, 0.459}
+	swC1 = []float64{0, 0.221157, -0.147981, -2.07119, 4.434685, -2.706056}
+	swC2 = []float64{0, 0.042981, -0.293762, -1.752461, 5.682633, -3.582633}
+	swC3 = []float64{0.544, -0.39978, 0.025054, -6.714e-4}
+	swC4 = []float64{1.3822, -0.77857, 0.062767, -0.0020322}
+	swC5 = []float64{-1.5861, -0.31082, -0.083751, 0.0038915}
+	swC6 = []float64{-0.4803, -0.082676, 0.0030302}
+)
+
+// poly returns c[0] + c[1]*x + c[2]*x^2 + ...
+func poly(c []float64, x float64) float64 {
+	y := 0.0
+	for i := len(c) - 1; i >= 0; i-- {
+		y = y*x + c[i]
+	}
+	return y
+}
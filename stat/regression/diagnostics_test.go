@@ -0,0 +1,174 @@
+package regression
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestInfluence(t *testing.T) {
+	x := make([][]float64, len(carsSpeed))
+	for i, v := range carsSpeed {
+		x[i] = []float64{v}
+	}
+	o, err := NewOLS(x, carsDist, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := o.Influence()
+	s := NewSimple()
+	for i, v := range carsSpeed {
+		s.Add(v, carsDist[i])
+	}
+	si := s.Influence(carsSpeed, carsDist)
+
+	p := 2
+	hsum := 0.0
+	for i := range x {
+		hsum += in.Hat[i]
+
+		// leave observation i out
+		xi := append(append([][]float64(nil), x[:i]...), x[i+1:]...)
+		yi := append(append([]float64(nil), carsDist[:i]...), carsDist[i+1:]...)
+		oi, err := NewOLS(xi, yi, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		si2 := oi.MeanSquareError()
+		e := o.Residuals()[i]
+		student := e / math.Sqrt(si2*(1-in.Hat[i]))
+		dfit := o.Fitted()[i] - oi.Predict(x[i])
+		dffits := dfit / math.Sqrt(si2*in.Hat[i])
+		cook := 0.0
+		for j := range x {
+			d := o.Fitted()[j] - oi.Predict(x[j])
+			cook += d * d
+		}
+		cook /= float64(p) * o.MeanSquareError()
+
+		for _, c := range []struct {
+			name        string
+			got, simple float64
+			want        float64
+		}{
+			{"studentized", in.Studentized[i], si.Studentized[i], student},
+			{"dffits", in.DFFITS[i], si.DFFITS[i], dffits},
+			{"cook", in.CooksDistance[i], si.CooksDistance[i], cook},
+			{"standardized", in.Standardized[i], si.Standardized[i],
+				e / math.Sqrt(o.MeanSquareError()*(1-in.Hat[i]))},
+		} {
+			if math.Abs(c.got-c.want) > 1e-9*math.Max(1, math.Abs(c.want)) ||
+				math.Abs(c.simple-c.want) > 1e-9*math.Max(1, math.Abs(c.want)) {
+				t.Errorf("%s %d: %g (simple %g), expected %g", c.name, i, c.got, c.simple, c.want)
+			}
+		}
+	}
+	if math.Abs(hsum-float64(p)) > 1e-10 {
+		t.Errorf("sum of leverages %g, expected %d", hsum, p)
+	}
+}
+
+func TestInfluenceWeighted(t *testing.T) {
+	// weights summing to the observation count are still a weighted fit
+	s := NewSimple()
+	s.AddWeighted(1, 2, 0.5)
+	s.AddWeighted(2, 3, 1.5)
+	s.Add(3, 5)
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for a weighted regression")
+		}
+	}()
+	s.Influence([]float64{1, 2, 3}, []float64{2, 3, 5})
+}
+
+func TestInfluenceWeightedRemoved(t *testing.T) {
+	// removing the only weighted observation leaves an unweighted fit
+	s := NewSimple()
+	s.Add(1, 2)
+	s.AddWeighted(4, 1, 0.5)
+	s.Add(2, 3)
+	s.Add(3, 5)
+	s.RemoveWeighted(4, 1, 0.5)
+	in := s.Influence([]float64{1, 2, 3}, []float64{2, 3, 5})
+	if h := in.Hat[0] + in.Hat[1] + in.Hat[2]; math.Abs(h-2) > 1e-12 {
+		t.Errorf("sum of leverages %g, expected 2", h)
+	}
+}
+
+func TestVIF(t *testing.T) {
+	x, _ := splitXY(longleyData)
+	// R: car::vif(lm(Employed ~ ., longley))
+	want := []float64{135.53244, 1788.51348, 33.61889, 3.58893, 399.15102, 758.98060}
+	for i, v := range VIF(x) {
+		if relErr(v, want[i]) > 1e-6 {
+			t.Errorf("vif %d: %g, expected %g", i, v, want[i])
+		}
+	}
+}
+
+func TestResidualTests(t *testing.T) {
+	x := make([][]float64, len(carsSpeed))
+	for i, v := range carsSpeed {
+		x[i] = []float64{v}
+	}
+	o, err := NewOLS(x, carsDist, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := o.Residuals()
+
+	// R: lmtest::dwtest(dist ~ speed, data = cars)
+	if d := DurbinWatson(e); math.Abs(d-1.6762) > 5e-5 {
+		t.Errorf("Durbin-Watson %g, expected 1.6762", d)
+	}
+	// R: lmtest::bptest(dist ~ speed, data = cars)
+	bp, df, pv := BreuschPagan(x, e, true)
+	if math.Abs(bp-3.2149) > 5e-5 || df != 1 || math.Abs(pv-0.07297) > 5e-6 {
+		t.Errorf("Breusch-Pagan %g, %d, %g, expected 3.2149, 1, 0.07297", bp, df, pv)
+	}
+	// without studentizing, ESS/2 of e^2/mean(e^2) on x
+	bp0, _, _ := BreuschPagan(x, e, false)
+	if bp0 <= 0 || math.Abs(bp0-bp) < 1e-6 {
+		t.Errorf("Breusch-Pagan without studentizing %g", bp0)
+	}
+
+	jb, df, pv := JarqueBera([]float64{-1, 0, 1})
+	if math.Abs(jb-0.28125) > 1e-12 || df != 2 || math.Abs(pv-math.Exp(-jb/2)) > 1e-12 {
+		t.Errorf("Jarque-Bera %g, %d, %g", jb, df, pv)
+	}
+}
+
+func TestShapiroWilk(t *testing.T) {
+	// weights of 11 men, Shapiro and Wilk (1965)
+	w, pv := ShapiroWilk([]float64{148, 154, 158, 160, 161, 162, 166, 170, 182, 195, 236})
+	if math.Abs(w-0.79) > 0.005 || pv > 0.01 {
+		t.Errorf("W %g, p-value %g, expected about 0.79, below 0.01", w, pv)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	normal := make([]float64, 200)
+	exp := make([]float64, 200)
+	for i := range normal {
+		normal[i] = rng.NormFloat64()
+		exp[i] = rng.ExpFloat64()
+	}
+	w, pv = ShapiroWilk(normal)
+	if w < 0.98 || pv < 0.01 {
+		t.Errorf("normal sample: W %g, p-value %g", w, pv)
+	}
+	if w, pv := ShapiroWilk(exp); w > 0.95 || pv > 1e-6 {
+		t.Errorf("exponential sample: W %g, p-value %g", w, pv)
+	}
+	// W is invariant to location and scale
+	for i, v := range normal {
+		normal[i] = 3*v + 10
+	}
+	if w2, pv2 := ShapiroWilk(normal); math.Abs(w2-w) > 1e-12 || math.Abs(pv2-pv) > 1e-9 {
+		t.Errorf("W %g (%g), expected %g (%g)", w2, pv2, w, pv)
+	}
+	// n = 3 has an exact distribution: W = 1 for equally spaced values
+	if w, pv := ShapiroWilk([]float64{1, 2, 3}); math.Abs(w-1) > 1e-12 || math.Abs(pv-1) > 1e-9 {
+		t.Errorf("W %g, p-value %g, expected 1, 1", w, pv)
+	}
+}
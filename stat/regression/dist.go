@@ -0,0 +1,29 @@
+package regression
+
+import (
+	"github.com/mingzhi/gomath/random"
+)
+
+// studentTTwoSided returns P(|T| > |t|) for Student's t distribution
+// with df degrees of freedom.
+func studentTTwoSided(t, df float64) float64 {
+	d := random.StudentT{Nu: df, Sigma: 1}
+	if t > 0 {
+		t = -t
+	}
+	return 2 * d.Cdf(t)
+}
+
+// studentTQuantile returns the p-quantile of Student's t distribution
+// with df degrees of freedom.
+func studentTQuantile(p, df float64) float64 {
+	d := random.StudentT{Nu: df, Sigma: 1}
+	return d.Quantile(p)
+}
+
+// fisherFSurvival returns P(F > f) for the F distribution
+// with d1 and d2 degrees of freedom.
+func fisherFSurvival(f, d1, d2 float64) float64 {
+	d := random.FisherF{D1: d1, D2: d2}
+	return d.Survival(f)
+}
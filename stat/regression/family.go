@@ -0,0 +1,216 @@
+package regression
+
+import (
+	"math"
+)
+
+// A Family is the error distribution and link function of a generalized
+// linear model. The families follow R's glm: each is defined by its link
+// g(mu) = eta, its variance function V(mu) and its unit deviance.
+type Family struct {
+	Name string // name of the distribution
+	Link string // name of the link function
+
+	linkfun  func(mu float64) float64
+	linkinv  func(eta float64) float64
+	muEta    func(eta float64) float64 // d mu / d eta
+	variance func(mu float64) float64
+	unitDev  func(y, mu float64) float64
+	// aic returns -2 * log-likelihood of the fit with deviance dev;
+	// the penalty for the parameters is added by the fit.
+	aic func(y, mu, w []float64, dev float64) float64
+	// start returns the starting mean of an observation with prior weight w.
+	start func(y, w float64) float64
+	valid func(mu, eta float64) bool
+	// fixed is true when the dispersion is 1, and false when it is estimated.
+	fixed bool
+	// extra is the number of extra parameters counted by the AIC,
+	// 1 for an estimated dispersion.
+	extra int
+}
+
+const linkEpsilon = 2.220446e-16
+
+// GaussianIdentity returns the normal family with identity link,
+// for which a GLM is least squares.
+func GaussianIdentity() *Family {
+	return &Family{
+		Name:     "gaussian",
+		Link:     "identity",
+		linkfun:  func(mu float64) float64 { return mu },
+		linkinv:  func(eta float64) float64 { return eta },
+		muEta:    func(eta float64) float64 { return 1 },
+		variance: func(mu float64) float64 { return 1 },
+		unitDev:  func(y, mu float64) float64 { return (y - mu) * (y - mu) },
+		aic: func(y, mu, w []float64, dev float64) float64 {
+			n := float64(len(y))
+			sumLogW := 0.0
+			for _, wi := range w {
+				if wi > 0 {
+					sumLogW += math.Log(wi)
+				}
+			}
+			return n*(math.Log(2*math.Pi*dev/n)+1) - sumLogW
+		},
+		start: func(y, w float64) float64 { return y },
+		valid: func(mu, eta float64) bool { return true },
+		extra: 1,
+	}
+}
+
+// BinomialLogit returns the binomial family with logit link, for logistic
+// regression. The responses are proportions of successes in [0, 1], with the
+// numbers of trials as prior weights; for binary responses the weights are 1.
+func BinomialLogit() *Family {
+	return &Family{
+		Name: "binomial",
+		Link: "logit",
+		linkfun: func(mu float64) float64 {
+			return math.Log(mu / (1 - mu))
+		},
+		linkinv: func(eta float64) float64 {
+			mu := 1 / (1 + math.Exp(-eta))
+			return math.Min(math.Max(mu, linkEpsilon), 1-linkEpsilon)
+		},
+		muEta: func(eta float64) float64 {
+			e := math.Exp(-math.Abs(eta))
+			return math.Max(e/((1+e)*(1+e)), linkEpsilon)
+		},
+		variance: func(mu float64) float64 { return mu * (1 - mu) },
+		unitDev: func(y, mu float64) float64 {
+			return 2 * (ylogy(y, mu) + ylogy(1-y, 1-mu))
+		},
+		aic: func(y, mu, w []float64, dev float64) float64 {
+			s := 0.0
+			for i := range y {
+				if w[i] > 0 {
+					m := math.Floor(w[i] + 0.5)
+					k := math.Floor(w[i]*y[i] + 0.5)
+					s += logChoose(m, k) + k*math.Log(mu[i]) + (m-k)*math.Log1p(-mu[i])
+				}
+			}
+			return -2 * s
+		},
+		start: func(y, w float64) float64 { return (w*y + 0.5) / (w + 1) },
+		valid: func(mu, eta float64) bool { return mu > 0 && mu < 1 },
+		fixed: true,
+	}
+}
+
+// PoissonLog returns the Poisson family with log link, for counts.
+func PoissonLog() *Family {
+	return &Family{
+		Name:     "poisson",
+		Link:     "log",
+		linkfun:  math.Log,
+		linkinv:  expLinkInv,
+		muEta:    expLinkInv,
+		variance: func(mu float64) float64 { return mu },
+		unitDev: func(y, mu float64) float64 {
+			return 2 * (ylogy(y, mu) - (y - mu))
+		},
+		aic: func(y, mu, w []float64, dev float64) float64 {
+			s := 0.0
+			for i := range y {
+				lg, _ := math.Lgamma(y[i] + 1)
+				s += w[i] * (y[i]*math.Log(mu[i]) - mu[i] - lg)
+			}
+			return -2 * s
+		},
+		start: func(y, w float64) float64 { return y + 0.1 },
+		valid: func(mu, eta float64) bool { return mu > 0 && !math.IsInf(mu, 0) },
+		fixed: true,
+	}
+}
+
+// GammaInverse returns the gamma family with the canonical inverse link,
+// for positive responses with a constant coefficient of variation.
+func GammaInverse() *Family {
+	return &Family{
+		Name:     "Gamma",
+		Link:     "inverse",
+		linkfun:  func(mu float64) float64 { return 1 / mu },
+		linkinv:  func(eta float64) float64 { return 1 / eta },
+		muEta:    func(eta float64) float64 { return -1 / (eta * eta) },
+		variance: func(mu float64) float64 { return mu * mu },
+		unitDev: func(y, mu float64) float64 {
+			return -2 * (math.Log(y/mu) - (y-mu)/mu)
+		},
+		aic: func(y, mu, w []float64, dev float64) float64 {
+			sumW := 0.0
+			for _, wi := range w {
+				sumW += wi
+			}
+			disp := dev / sumW
+			shape := 1 / disp
+			lgShape, _ := math.Lgamma(shape)
+			s := 0.0
+			for i := range y {
+				scale := mu[i] * disp
+				s += w[i] * ((shape-1)*math.Log(y[i]) - y[i]/scale - lgShape - shape*math.Log(scale))
+			}
+			return -2 * s
+		},
+		start: func(y, w float64) float64 { return y },
+		valid: func(mu, eta float64) bool { return mu > 0 && eta != 0 && !math.IsInf(eta, 0) },
+		extra: 1,
+	}
+}
+
+// NegativeBinomialLog returns the negative binomial family with log link
+// and known shape theta, for overdispersed counts with variance
+// mu + mu^2/theta. As theta grows it approaches PoissonLog.
+func NegativeBinomialLog(theta float64) *Family {
+	if theta <= 0 {
+		panic("regression: theta must be positive")
+	}
+	lgTheta, _ := math.Lgamma(theta)
+	return &Family{
+		Name:     "negative binomial",
+		Link:     "log",
+		linkfun:  math.Log,
+		linkinv:  expLinkInv,
+		muEta:    expLinkInv,
+		variance: func(mu float64) float64 { return mu + mu*mu/theta },
+		unitDev: func(y, mu float64) float64 {
+			return 2 * (ylogy(y, mu) - (y+theta)*math.Log((y+theta)/(mu+theta)))
+		},
+		aic: func(y, mu, w []float64, dev float64) float64 {
+			s := 0.0
+			for i := range y {
+				lg1, _ := math.Lgamma(theta + y[i])
+				lg2, _ := math.Lgamma(y[i] + 1)
+				s += w[i] * (lg1 - lgTheta - lg2 + theta*math.Log(theta) + y[i]*math.Log(mu[i]) -
+					(theta+y[i])*math.Log(theta+mu[i]))
+			}
+			return -2 * s
+		},
+		start: func(y, w float64) float64 {
+			if y == 0 {
+				return 1.0 / 6
+			}
+			return y
+		},
+		valid: func(mu, eta float64) bool { return mu > 0 && !math.IsInf(mu, 0) },
+		fixed: true,
+	}
+}
+
+func expLinkInv(eta float64) float64 {
+	return math.Max(math.Exp(eta), linkEpsilon)
+}
+
+// ylogy returns y * log(y/mu), which is 0 for y = 0.
+func ylogy(y, mu float64) float64 {
+	if y == 0 {
+		return 0
+	}
+	return y * math.Log(y/mu)
+}
+
+func logChoose(n, k float64) float64 {
+	a, _ := math.Lgamma(n + 1)
+	b, _ := math.Lgamma(k + 1)
+	c, _ := math.Lgamma(n - k + 1)
+	return a - b - c
+}
@@ -0,0 +1,447 @@
+package regression
+
+import (
+	"github.com/mingzhi/gomath/specfunc"
+	"math"
+)
+
+// GLMOptions holds the optional settings of NewGLM.
+// The zero value fits unit prior weights without offset.
+type GLMOptions struct {
+	// Weights are the prior weights of the observations,
+	// the numbers of trials for the binomial family.
+	Weights []float64
+	// Offset is added to the linear predictor of each observation,
+	// e.g. the log of the exposure of Poisson counts.
+	Offset []float64
+	// Tolerance on the relative change of the deviance between iterations.
+	// The default is 1e-8, as in R.
+	Tolerance float64
+	// MaxIter limits the number of iterations. The default is 25.
+	MaxIter int
+}
+
+// Estimates a generalized linear model
+//
+// g(E[y]) = offset + b0 + b1 * x1 + ... + bk * xk
+//
+// by iteratively reweighted least squares (IRLS), following R's glm.fit:
+// each iteration solves a weighted least squares problem for the working
+// response with the QR decomposition, halving the step if the deviance
+// becomes infinite or the fitted means invalid.
+//
+// Wald tests of the coefficients, the deviance and null deviance, AIC and
+// likelihood ratio tests are available. The dispersion is 1 for the binomial,
+// Poisson and negative binomial families and is estimated by the Pearson
+// statistic otherwise.
+type GLM struct {
+	family     *Family
+	n, p       int // number of observations with positive weight, of coefficients
+	intercept  bool
+	coef       []float64
+	xtwxInv    [][]float64 // (X'WX)^-1 at convergence
+	mu, eta    []float64
+	y, w       []float64
+	dispersion float64
+	deviance   float64
+	nullDev    float64
+	dfNull     int
+	aic        float64
+	iterations int
+	converged  bool
+}
+
+// NewGLM fits y on the rows of x with the given family, with or without an
+// intercept. opts may be nil. An Error is returned if the design is rank
+// deficient or the fit fails; a fit that does not converge within MaxIter
+// iterations is returned with Converged false.
+func NewGLM(x [][]float64, y []float64, intercept bool, family *Family, opts *GLMOptions) (*GLM, error) {
+	if opts == nil {
+		opts = &GLMOptions{}
+	}
+	n := len(y)
+	if len(x) != n {
+		panic("regression: x and y have different numbers of observations")
+	}
+	if n == 0 {
+		panic("regression: no observations")
+	}
+	w := opts.Weights
+	if w == nil {
+		w = make([]float64, n)
+		for i := range w {
+			w[i] = 1
+		}
+	}
+	offset := opts.Offset
+	if offset == nil {
+		offset = make([]float64, n)
+	}
+	if len(w) != n || len(offset) != n {
+		panic("regression: weights and offset must have one value per observation")
+	}
+	tol := opts.Tolerance
+	if tol == 0 {
+		tol = 1e-8
+	}
+	maxIter := opts.MaxIter
+	if maxIter == 0 {
+		maxIter = 25
+	}
+
+	design := make([][]float64, n)
+	for i, row := range x {
+		if intercept {
+			design[i] = append([]float64{1}, row...)
+		} else {
+			design[i] = row
+		}
+	}
+	g := &GLM{family: family, intercept: intercept, y: y, w: w}
+	g.p = len(design[0])
+	for i, wi := range w {
+		if wi < 0 {
+			panic("regression: weights must be non-negative")
+		}
+		if wi > 0 {
+			g.n++
+		}
+		if len(design[i]) != g.p {
+			panic("regression: rows of x have different lengths")
+		}
+	}
+	if g.p == 0 {
+		panic("regression: no coefficients to estimate")
+	}
+	if g.n < g.p {
+		return nil, Error{Message: "regression: not enough observations for the number of coefficients"}
+	}
+	if err := g.fit(design, offset, tol, maxIter); err != nil {
+		return nil, err
+	}
+
+	// null model: the intercept only, or the offset only
+	g.dfNull = g.n
+	if intercept {
+		g.dfNull--
+	}
+	if intercept && opts.Offset != nil {
+		null := &GLM{family: family, y: y, w: w, n: g.n, p: 1}
+		ones := make([][]float64, n)
+		for i := range ones {
+			ones[i] = []float64{1}
+		}
+		if err := null.fit(ones, offset, tol, maxIter); err != nil {
+			return nil, err
+		}
+		g.nullDev = null.deviance
+	} else {
+		var mu0, sumW float64
+		for i, v := range y {
+			mu0 += w[i] * v
+			sumW += w[i]
+		}
+		mu0 /= sumW
+		for i, v := range y {
+			m := mu0
+			if !intercept {
+				m = family.linkinv(offset[i])
+			}
+			g.nullDev += w[i] * family.unitDev(v, m)
+		}
+	}
+	return g, nil
+}
+
+// fit runs IRLS from the starting values of the family.
+func (g *GLM) fit(design [][]float64, offset []float64, tol float64, maxIter int) error {
+	f := g.family
+	n := len(g.y)
+	g.mu = make([]float64, n)
+	g.eta = make([]float64, n)
+	for i, v := range g.y {
+		g.mu[i] = f.start(v, g.w[i])
+		g.eta[i] = f.linkfun(g.mu[i])
+	}
+	devOld := g.computeDeviance()
+
+	rows := make([][]float64, n)
+	z := make([]float64, n)
+	var q *qr
+	var coefOld []float64
+	for g.iterations = 1; g.iterations <= maxIter; g.iterations++ {
+		for i, row := range design {
+			d := f.muEta(g.eta[i])
+			z[i] = g.eta[i] - offset[i] + (g.y[i]-g.mu[i])/d
+			sw := math.Sqrt(g.w[i] * d * d / f.variance(g.mu[i]))
+			z[i] *= sw
+			rows[i] = make([]float64, g.p)
+			for j, v := range row {
+				rows[i][j] = v * sw
+			}
+		}
+		q = newQR(rows)
+		if !q.fullRank() {
+			return Error{Message: "regression: design matrix is rank deficient"}
+		}
+		g.coef = q.solve(z)
+
+		// step halving into the valid region
+		for halvings := 0; ; halvings++ {
+			ok := true
+			for i, row := range design {
+				eta := offset[i]
+				for j, v := range row {
+					eta += v * g.coef[j]
+				}
+				g.eta[i] = eta
+				g.mu[i] = f.linkinv(eta)
+				if !f.valid(g.mu[i], eta) {
+					ok = false
+				}
+			}
+			if ok {
+				g.deviance = g.computeDeviance()
+				if !math.IsInf(g.deviance, 0) && !math.IsNaN(g.deviance) {
+					break
+				}
+			}
+			if coefOld == nil || halvings == 30 {
+				return Error{Message: "regression: no valid fit found, specify better starting values"}
+			}
+			for j := range g.coef {
+				g.coef[j] = (g.coef[j] + coefOld[j]) / 2
+			}
+		}
+
+		if math.Abs(g.deviance-devOld)/(math.Abs(g.deviance)+0.1) < tol {
+			g.converged = true
+			break
+		}
+		devOld = g.deviance
+		coefOld = append([]float64(nil), g.coef...)
+	}
+	if g.iterations > maxIter {
+		g.iterations = maxIter
+	}
+	g.xtwxInv = q.xtxInverse()
+
+	g.dispersion = 1
+	if !f.fixed {
+		g.dispersion = g.pearsonChi2() / float64(g.n-g.p)
+	}
+	g.aic = f.aic(g.y, g.mu, g.w, g.deviance) + 2*float64(g.p+f.extra)
+	return nil
+}
+
+func (g *GLM) computeDeviance() float64 {
+	dev := 0.0
+	for i, v := range g.y {
+		if g.w[i] > 0 {
+			dev += g.w[i] * g.family.unitDev(v, g.mu[i])
+		}
+	}
+	return dev
+}
+
+func (g *GLM) pearsonChi2() float64 {
+	s := 0.0
+	for i, v := range g.y {
+		r := v - g.mu[i]
+		s += g.w[i] * r * r / g.family.variance(g.mu[i])
+	}
+	return s
+}
+
+// Returns the family of the model.
+func (g *GLM) Family() *Family {
+	return g.family
+}
+
+// Returns the number of observations with positive weight.
+func (g *GLM) N() int {
+	return g.n
+}
+
+// Returns the number of estimated coefficients, including the intercept.
+func (g *GLM) NumCoefficients() int {
+	return g.p
+}
+
+// Returns true if IRLS converged.
+func (g *GLM) Converged() bool {
+	return g.converged
+}
+
+// Returns the number of IRLS iterations used.
+func (g *GLM) Iterations() int {
+	return g.iterations
+}
+
+// Returns the estimated coefficients, starting with the intercept if any.
+func (g *GLM) Coefficients() []float64 {
+	return append([]float64(nil), g.coef...)
+}
+
+// Returns the dispersion parameter: 1 for the families with a fixed
+// dispersion, otherwise the Pearson chi-squared statistic divided by the
+// residual degrees of freedom.
+func (g *GLM) Dispersion() float64 {
+	return g.dispersion
+}
+
+// Returns the estimated covariance matrix of the coefficients,
+// dispersion * (X'WX)^-1.
+func (g *GLM) Covariance() [][]float64 {
+	c := make([][]float64, g.p)
+	for i, row := range g.xtwxInv {
+		c[i] = make([]float64, g.p)
+		for j, v := range row {
+			c[i][j] = g.dispersion * v
+		}
+	}
+	return c
+}
+
+// Returns the standard errors of the coefficients.
+func (g *GLM) StdErrors() []float64 {
+	se := make([]float64, g.p)
+	for i := range se {
+		se[i] = math.Sqrt(g.dispersion * g.xtwxInv[i][i])
+	}
+	return se
+}
+
+// Returns the Wald statistics of the coefficients, for the hypotheses
+// that each coefficient is zero.
+func (g *GLM) ZValues() []float64 {
+	z := g.StdErrors()
+	for i, b := range g.coef {
+		z[i] = b / z[i]
+	}
+	return z
+}
+
+// Returns the two-sided p-values of the Wald statistics, from the standard
+// normal distribution when the dispersion is fixed and from Student's t
+// distribution with the residual degrees of freedom when it is estimated.
+func (g *GLM) PValues() []float64 {
+	pv := g.ZValues()
+	for i, z := range pv {
+		if g.family.fixed {
+			pv[i] = math.Erfc(math.Abs(z) / math.Sqrt2)
+		} else {
+			pv[i] = studentTTwoSided(z, float64(g.ResidualDF()))
+		}
+	}
+	return pv
+}
+
+// Returns the deviance of the model, twice the difference between the
+// log-likelihoods of the saturated model and of the fit, times the dispersion.
+func (g *GLM) Deviance() float64 {
+	return g.deviance
+}
+
+// Returns the deviance of the model with only the intercept, or with no
+// coefficients if the model has no intercept.
+func (g *GLM) NullDeviance() float64 {
+	return g.nullDev
+}
+
+// Returns the residual degrees of freedom, n - p.
+func (g *GLM) ResidualDF() int {
+	return g.n - g.p
+}
+
+// Returns the degrees of freedom of the null deviance.
+func (g *GLM) NullDF() int {
+	return g.dfNull
+}
+
+// Returns Akaike's information criterion, -2*logL + 2*k, where k counts
+// the coefficients and, for the gaussian and gamma families, the dispersion.
+func (g *GLM) AIC() float64 {
+	return g.aic
+}
+
+// Returns the fitted means of the observations.
+func (g *GLM) Fitted() []float64 {
+	return append([]float64(nil), g.mu...)
+}
+
+// Returns the linear predictors of the observations, including the offset.
+func (g *GLM) LinearPredictor() []float64 {
+	return append([]float64(nil), g.eta...)
+}
+
+// Returns the deviance residuals, sign(y - mu) * sqrt(w * d(y, mu)).
+func (g *GLM) DevianceResiduals() []float64 {
+	r := make([]float64, len(g.y))
+	for i, v := range g.y {
+		r[i] = math.Sqrt(math.Max(0, g.w[i]*g.family.unitDev(v, g.mu[i])))
+		if v < g.mu[i] {
+			r[i] = -r[i]
+		}
+	}
+	return r
+}
+
+// Returns the Pearson residuals, (y - mu) * sqrt(w / V(mu)).
+func (g *GLM) PearsonResiduals() []float64 {
+	r := make([]float64, len(g.y))
+	for i, v := range g.y {
+		r[i] = (v - g.mu[i]) * math.Sqrt(g.w[i]/g.family.variance(g.mu[i]))
+	}
+	return r
+}
+
+// Returns the predicted mean response for the independent variables x
+// and the given offset.
+func (g *GLM) Predict(x []float64, offset float64) float64 {
+	b := g.coef
+	eta := offset
+	if g.intercept {
+		eta += b[0]
+		b = b[1:]
+	}
+	if len(x) != len(b) {
+		panic("regression: wrong number of independent variables")
+	}
+	for i, v := range x {
+		eta += b[i] * v
+	}
+	return g.family.linkinv(eta)
+}
+
+// LRTest returns the likelihood ratio test of the model against the null
+// model. See LikelihoodRatioTest.
+func (g *GLM) LRTest() (statistic float64, df int, pvalue float64) {
+	df = g.dfNull - g.ResidualDF()
+	statistic = (g.nullDev - g.deviance) / g.dispersion
+	return statistic, df, chiSquaredSurvival(statistic, df)
+}
+
+// LikelihoodRatioTest returns the likelihood ratio test of the reduced model
+// against the full model in which it is nested, fit on the same data with
+// the same family: the difference of the deviances, scaled by the dispersion
+// of the full model, compared to a chi-squared distribution with the
+// difference of the numbers of coefficients as degrees of freedom.
+func LikelihoodRatioTest(reduced, full *GLM) (statistic float64, df int, pvalue float64) {
+	if reduced.family.Name != full.family.Name || reduced.n != full.n {
+		panic("regression: the models are not fit on the same data with the same family")
+	}
+	df = full.p - reduced.p
+	if df < 1 {
+		panic("regression: the reduced model must have fewer coefficients")
+	}
+	statistic = (reduced.deviance - full.deviance) / full.dispersion
+	return statistic, df, chiSquaredSurvival(statistic, df)
+}
+
+func chiSquaredSurvival(x float64, df int) float64 {
+	if x <= 0 {
+		return 1
+	}
+	return specfunc.RegularizedGammaQ(float64(df)/2, x/2)
+}
@@ -0,0 +1,298 @@
+package regression
+
+import (
+	"github.com/mingzhi/gomath/random"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestGLMGaussian(t *testing.T) {
+	x, y := splitXY(longleyData)
+	o, err := NewOLS(x, y, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := NewGLM(x, y, true, GaussianIdentity(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g.Converged() {
+		t.Error("not converged")
+	}
+	b, se := g.Coefficients(), g.StdErrors()
+	eb, ese := o.Coefficients(), o.StdErrors()
+	for i := range eb {
+		if relErr(b[i], eb[i]) > 1e-8 || relErr(se[i], ese[i]) > 1e-8 {
+			t.Errorf("coefficient %d: %g (%g), expected %g (%g)", i, b[i], se[i], eb[i], ese[i])
+		}
+	}
+	if relErr(g.Deviance(), o.SumSquaredErrors()) > 1e-8 {
+		t.Errorf("deviance %g, expected %g", g.Deviance(), o.SumSquaredErrors())
+	}
+	if relErr(g.NullDeviance(), o.TotalSumSquares()) > 1e-12 {
+		t.Errorf("null deviance %g, expected %g", g.NullDeviance(), o.TotalSumSquares())
+	}
+	if relErr(g.Dispersion(), o.MeanSquareError()) > 1e-8 {
+		t.Errorf("dispersion %g, expected %g", g.Dispersion(), o.MeanSquareError())
+	}
+	if math.Abs(g.AIC()-o.AIC()) > 1e-6 {
+		t.Errorf("AIC %g, expected %g", g.AIC(), o.AIC())
+	}
+	pv, epv := g.PValues(), o.PValues()
+	for i := range pv {
+		if math.Abs(pv[i]-epv[i]) > 1e-8 {
+			t.Errorf("p-value %d: %g, expected %g", i, pv[i], epv[i])
+		}
+	}
+}
+
+// Dobson (1990), p. 93: randomized controlled trial, as in R's ?glm
+func dobson() (x [][]float64, y []float64) {
+	y = []float64{18, 17, 15, 20, 10, 20, 25, 13, 12}
+	for i := range y {
+		outcome, treatment := i%3, i/3
+		row := make([]float64, 4)
+		if outcome > 0 {
+			row[outcome-1] = 1
+		}
+		if treatment > 0 {
+			row[1+treatment] = 1
+		}
+		x = append(x, row)
+	}
+	return
+}
+
+func TestGLMPoisson(t *testing.T) {
+	x, y := dobson()
+	g, err := NewGLM(x, y, true, PoissonLog(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g.Converged() || g.Iterations() > 10 {
+		t.Errorf("converged %v after %d iterations", g.Converged(), g.Iterations())
+	}
+	// the treatments are balanced, so the fit is given by the outcome means
+	expected := []float64{math.Log(21), math.Log(40.0 / 3 / 21), math.Log(47.0 / 3 / 21), 0, 0}
+	b := g.Coefficients()
+	for i := range expected {
+		if math.Abs(b[i]-expected[i]) > 1e-8 {
+			t.Errorf("coefficient %d: %g, expected %g", i, b[i], expected[i])
+		}
+	}
+	// values from R
+	se := g.StdErrors()
+	for i, e := range []float64{0.1708987, 0.2021708, 0.1927423, 0.2, 0.2} {
+		if math.Abs(se[i]-e) > 1e-7 {
+			t.Errorf("standard error %d: %g, expected %g", i, se[i], e)
+		}
+	}
+	if math.Abs(g.Deviance()-5.129141) > 1e-6 || g.ResidualDF() != 4 {
+		t.Errorf("deviance %g on %d degrees of freedom", g.Deviance(), g.ResidualDF())
+	}
+	if math.Abs(g.NullDeviance()-10.58145) > 1e-5 || g.NullDF() != 8 {
+		t.Errorf("null deviance %g on %d degrees of freedom", g.NullDeviance(), g.NullDF())
+	}
+	if math.Abs(g.AIC()-56.76132) > 1e-5 {
+		t.Errorf("AIC %g", g.AIC())
+	}
+	if math.Abs(g.Dispersion()-1) > 0 {
+		t.Errorf("dispersion %g", g.Dispersion())
+	}
+	stat, df, p := g.LRTest()
+	if math.Abs(stat-(10.58145-5.129141)) > 1e-5 || df != 4 || math.Abs(p-0.2440) > 1e-4 {
+		t.Errorf("likelihood ratio test %g on %d df, p-value %g", stat, df, p)
+	}
+
+	// dropping the treatments does not change the deviance
+	reduced, err := NewGLM(colsOf(x, 0, 1), y, true, PoissonLog(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stat, df, p = LikelihoodRatioTest(reduced, g)
+	if math.Abs(stat) > 1e-8 || df != 2 || math.Abs(p-1) > 1e-8 {
+		t.Errorf("likelihood ratio test %g on %d df, p-value %g", stat, df, p)
+	}
+
+	// the residuals square to the deviance and the Pearson statistic
+	ss := 0.0
+	for _, r := range g.DevianceResiduals() {
+		ss += r * r
+	}
+	if math.Abs(ss-g.Deviance()) > 1e-10 {
+		t.Errorf("sum of squared deviance residuals %g, expected %g", ss, g.Deviance())
+	}
+	if math.Abs(g.Predict(x[4], 0)-g.Fitted()[4]) > 1e-10 {
+		t.Errorf("prediction %g, expected %g", g.Predict(x[4], 0), g.Fitted()[4])
+	}
+}
+
+func colsOf(x [][]float64, cols ...int) [][]float64 {
+	sub := make([][]float64, len(x))
+	for i, row := range x {
+		for _, j := range cols {
+			sub[i] = append(sub[i], row[j])
+		}
+	}
+	return sub
+}
+
+func TestGLMOffset(t *testing.T) {
+	// Poisson rates: counts over exposures
+	y := []float64{3, 7, 2, 11, 5}
+	exposure := []float64{10, 20, 5, 30, 15}
+	x := make([][]float64, len(y))
+	offset := make([]float64, len(y))
+	var sumY, sumT float64
+	for i := range y {
+		x[i] = []float64{}
+		offset[i] = math.Log(exposure[i])
+		sumY += y[i]
+		sumT += exposure[i]
+	}
+	g, err := NewGLM(x, y, true, PoissonLog(), &GLMOptions{Offset: offset})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := g.Coefficients()[0]; math.Abs(b-math.Log(sumY/sumT)) > 1e-10 {
+		t.Errorf("log rate %g, expected %g", b, math.Log(sumY/sumT))
+	}
+	// the null model is the model itself
+	if math.Abs(g.NullDeviance()-g.Deviance()) > 1e-10 {
+		t.Errorf("null deviance %g, expected %g", g.NullDeviance(), g.Deviance())
+	}
+}
+
+func TestGLMBinomial(t *testing.T) {
+	// a 2x2 table: 3/10 successes without treatment, 7/10 with
+	x := [][]float64{{0}, {1}}
+	y := []float64{0.3, 0.7}
+	grouped, err := NewGLM(x, y, true, BinomialLogit(), &GLMOptions{Weights: []float64{10, 10}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := grouped.Coefficients()
+	if math.Abs(b[0]-math.Log(3.0/7)) > 1e-8 || math.Abs(b[1]-2*math.Log(7.0/3)) > 1e-8 {
+		t.Errorf("coefficients %v", b)
+	}
+	// the standard error of a log odds ratio is sqrt(sum 1/cell)
+	se := grouped.StdErrors()
+	if math.Abs(se[1]-math.Sqrt(2*(1.0/3+1.0/7))) > 1e-7 {
+		t.Errorf("standard error %g", se[1])
+	}
+
+	// binary responses give the same fit
+	var bx [][]float64
+	var by []float64
+	for g := 0; g < 2; g++ {
+		for i := 0; i < 10; i++ {
+			bx = append(bx, []float64{float64(g)})
+			if i < 3+4*g {
+				by = append(by, 1)
+			} else {
+				by = append(by, 0)
+			}
+		}
+	}
+	binary, err := NewGLM(bx, by, true, BinomialLogit(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bb := binary.Coefficients()
+	if math.Abs(bb[0]-b[0]) > 1e-8 || math.Abs(bb[1]-b[1]) > 1e-8 {
+		t.Errorf("binary coefficients %v, expected %v", bb, b)
+	}
+	// the deviances differ, but not their differences
+	if math.Abs((binary.NullDeviance()-binary.Deviance())-(grouped.NullDeviance()-grouped.Deviance())) > 1e-8 {
+		t.Error("the likelihood ratio statistics differ")
+	}
+	// the grouped likelihood has the binomial coefficients C(10, 3) and C(10, 7)
+	if math.Abs(binary.AIC()-grouped.AIC()-4*math.Log(120)) > 1e-8 {
+		t.Errorf("AIC %g and %g", binary.AIC(), grouped.AIC())
+	}
+}
+
+func TestGLMScoreEquations(t *testing.T) {
+	// for canonical links the fit solves X'W(y - mu) = 0
+	rng := rand.New(rand.NewSource(3))
+	n := 200
+	x := make([][]float64, n)
+	for i := range x {
+		x[i] = []float64{rng.Float64(), rng.NormFloat64()}
+	}
+	ys := map[string][]float64{}
+	for _, name := range []string{"binomial", "poisson", "Gamma", "negative binomial"} {
+		ys[name] = make([]float64, n)
+	}
+	for i, row := range x {
+		eta := 0.5 + row[0] - 0.3*row[1]
+		mu := 1 / (1 + math.Exp(-eta))
+		if rng.Float64() < mu {
+			ys["binomial"][i] = 1
+		}
+		ys["poisson"][i] = float64(poisson(rng, math.Exp(eta)))
+		ys["negative binomial"][i] = float64(poisson(rng, math.Exp(eta)*rng.ExpFloat64()))
+		ys["Gamma"][i] = rng.ExpFloat64() / (1 + row[0])
+	}
+	for _, family := range []*Family{BinomialLogit(), PoissonLog(), GammaInverse(), NegativeBinomialLog(2)} {
+		y := ys[family.Name]
+		g, err := NewGLM(x, y, true, family, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", family.Name, err)
+		}
+		if !g.Converged() {
+			t.Errorf("%s: not converged", family.Name)
+		}
+		if family.Name == "negative binomial" {
+			continue // the log link is not canonical
+		}
+		mu := g.Fitted()
+		for j := 0; j < 3; j++ {
+			score := 0.0
+			for i, row := range x {
+				xij := 1.0
+				if j > 0 {
+					xij = row[j-1]
+				}
+				score += xij * (y[i] - mu[i])
+			}
+			if math.Abs(score) > 1e-6 {
+				t.Errorf("%s: score %d is %g", family.Name, j, score)
+			}
+		}
+	}
+
+	// the Gamma AIC counts the dispersion as a parameter,
+	// with the shape estimated as n / deviance as in R
+	gm, _ := NewGLM(x, ys["Gamma"], true, GammaInverse(), nil)
+	shape := float64(n) / gm.Deviance()
+	ll := 0.0
+	for i, m := range gm.Fitted() {
+		ll += random.Gamma{K: shape, Rate: shape / m}.LogPdf(ys["Gamma"][i])
+	}
+	if want := -2*ll + 2*4; math.Abs(gm.AIC()-want) > 1e-8*math.Abs(want) {
+		t.Errorf("Gamma AIC %g, expected %g", gm.AIC(), want)
+	}
+
+	// a large theta approaches the Poisson fit
+	p, _ := NewGLM(x, ys["poisson"], true, PoissonLog(), nil)
+	nb, _ := NewGLM(x, ys["poisson"], true, NegativeBinomialLog(1e8), nil)
+	pb, nbb := p.Coefficients(), nb.Coefficients()
+	for j := range pb {
+		if math.Abs(pb[j]-nbb[j]) > 1e-6 {
+			t.Errorf("negative binomial coefficient %d: %g, expected %g", j, nbb[j], pb[j])
+		}
+	}
+	if math.Abs(p.AIC()-nb.AIC()) > 1e-4 {
+		t.Errorf("negative binomial AIC %g, expected %g", nb.AIC(), p.AIC())
+	}
+}
+
+func poisson(rng *rand.Rand, mean float64) int {
+	k, p := 0, rng.Float64()
+	for l := math.Exp(-mean); p > l; k++ {
+		p *= rng.Float64()
+	}
+	return k
+}
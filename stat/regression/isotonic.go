@@ -0,0 +1,224 @@
+package regression
+
+import (
+	"sort"
+)
+
+// Ties selects how an isotonic regression treats observations with equal x
+// (de Leeuw, 1977).
+type Ties int
+
+const (
+	// PrimaryTies leaves tied observations unordered: their fitted values
+	// may differ, in the order of their y values.
+	PrimaryTies Ties = iota
+	// SecondaryTies requires tied observations to have equal fitted values;
+	// they are replaced by their weighted mean before fitting.
+	SecondaryTies
+)
+
+// Estimates an isotonic regression, the monotone step function f that
+// minimizes
+//
+// sum w_i * (y_i - f(x_i))^2,
+//
+// with the pool-adjacent-violators algorithm (PAVA): the observations are
+// visited in the order of x, and adjacent blocks whose means violate the
+// order are pooled into their weighted mean. PAVA takes O(n) time once the
+// observations are sorted, O(n log n) in all.
+//
+// The fitted values are the means of the blocks. Between the distinct x
+// values, Predict interpolates linearly, which suits calibrating classifier
+// scores into probabilities, and PredictStep keeps the value of the last
+// x value at or below; both are constant outside the range of x.
+type Isotonic struct {
+	x, y, w    []float64
+	increasing bool
+	fitted     []float64
+	knots      []float64 // distinct x values
+	values     []float64 // fitted value at each knot
+	blocks     int
+}
+
+// NewIsotonic fits an increasing or decreasing isotonic regression to the
+// observations with the given ties handling. w holds positive weights,
+// or is nil for unit weights.
+func NewIsotonic(x, y, w []float64, increasing bool, ties Ties) *Isotonic {
+	n := len(x)
+	if len(y) != n {
+		panic("regression: x and y have different lengths")
+	}
+	if n == 0 {
+		panic("regression: no observations")
+	}
+	if w == nil {
+		w = make([]float64, n)
+		for i := range w {
+			w[i] = 1
+		}
+	}
+	if len(w) != n {
+		panic("regression: weights must have one value per observation")
+	}
+	for _, v := range w {
+		if !(v > 0) {
+			panic("regression: weights must be positive")
+		}
+	}
+	if ties != PrimaryTies && ties != SecondaryTies {
+		panic("regression: unknown ties handling")
+	}
+
+	iso := &Isotonic{x: x, y: y, w: w, increasing: increasing}
+	// fit an increasing function to sign * y
+	sign := 1.0
+	if !increasing {
+		sign = -1
+	}
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool {
+		i, j := order[a], order[b]
+		if x[i] != x[j] {
+			return x[i] < x[j]
+		}
+		return sign*y[i] < sign*y[j]
+	})
+
+	// the points to fit: every observation, or the means of the ties
+	var py, pw []float64
+	group := make([]int, n) // point of each sorted observation
+	for k, i := range order {
+		if ties == PrimaryTies || k == 0 || x[i] != x[order[k-1]] {
+			py = append(py, 0)
+			pw = append(pw, 0)
+		}
+		p := len(py) - 1
+		group[k] = p
+		py[p] += w[i] * sign * y[i]
+		pw[p] += w[i]
+	}
+	for p := range py {
+		py[p] /= pw[p]
+	}
+
+	fit := pava(py, pw)
+	iso.fitted = make([]float64, n)
+	for k, i := range order {
+		iso.fitted[i] = sign * fit[group[k]]
+	}
+	for p, v := range fit {
+		if p == 0 || v != fit[p-1] {
+			iso.blocks++
+		}
+	}
+
+	// knots at the distinct x values, with the mean fitted value of ties
+	var sw float64
+	for k, i := range order {
+		if k == 0 || x[i] != x[order[k-1]] {
+			iso.knots = append(iso.knots, x[i])
+			iso.values = append(iso.values, 0)
+			sw = 0
+		}
+		m := len(iso.values) - 1
+		sw += w[i]
+		iso.values[m] += w[i] / sw * (iso.fitted[i] - iso.values[m])
+	}
+	return iso
+}
+
+// pava returns the increasing isotonic regression of y with weights w,
+// in the given order.
+func pava(y, w []float64) []float64 {
+	n := len(y)
+	// blocks on a stack: mean, weight and first index
+	mean := make([]float64, 0, n)
+	weight := make([]float64, 0, n)
+	start := make([]int, 0, n)
+	for i, v := range y {
+		mean = append(mean, v)
+		weight = append(weight, w[i])
+		start = append(start, i)
+		for k := len(mean) - 1; k > 0 && mean[k-1] >= mean[k]; k-- {
+			tw := weight[k-1] + weight[k]
+			mean[k-1] += weight[k] / tw * (mean[k] - mean[k-1])
+			weight[k-1] = tw
+			mean, weight, start = mean[:k], weight[:k], start[:k]
+		}
+	}
+	fit := make([]float64, n)
+	for k, s := range start {
+		end := n
+		if k+1 < len(start) {
+			end = start[k+1]
+		}
+		for i := s; i < end; i++ {
+			fit[i] = mean[k]
+		}
+	}
+	return fit
+}
+
+// Returns the number of observations.
+func (iso *Isotonic) N() int {
+	return len(iso.x)
+}
+
+// Returns true if the fitted function is increasing.
+func (iso *Isotonic) Increasing() bool {
+	return iso.increasing
+}
+
+// Returns the equivalent degrees of freedom, the number of distinct
+// fitted levels (Meyer and Woodroofe, 2000).
+func (iso *Isotonic) EDF() float64 {
+	return float64(iso.blocks)
+}
+
+// Returns the distinct x values and the fitted function at them, the points
+// that Predict interpolates.
+func (iso *Isotonic) Knots() (x, y []float64) {
+	return append([]float64(nil), iso.knots...), append([]float64(nil), iso.values...)
+}
+
+// Returns the fitted values of the observations.
+func (iso *Isotonic) Fitted() []float64 {
+	return append([]float64(nil), iso.fitted...)
+}
+
+// Returns the residuals y - fitted of the observations.
+func (iso *Isotonic) Residuals() []float64 {
+	r := make([]float64, len(iso.y))
+	for i, v := range iso.y {
+		r[i] = v - iso.fitted[i]
+	}
+	return r
+}
+
+// Returns the fitted function at x, interpolated linearly between
+// the knots.
+func (iso *Isotonic) Predict(x float64) float64 {
+	k, v := iso.knots, iso.values
+	i := sort.SearchFloat64s(k, x)
+	switch {
+	case i == len(k):
+		return v[len(v)-1]
+	case k[i] == x || i == 0:
+		return v[i]
+	}
+	t := (x - k[i-1]) / (k[i] - k[i-1])
+	return v[i-1] + t*(v[i]-v[i-1])
+}
+
+// Returns the fitted step function at x, the value at the largest knot
+// not above x, or at the first knot below the range.
+func (iso *Isotonic) PredictStep(x float64) float64 {
+	i := sort.Search(len(iso.knots), func(i int) bool { return iso.knots[i] > x }) - 1
+	if i < 0 {
+		i = 0
+	}
+	return iso.values[i]
+}
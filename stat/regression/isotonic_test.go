@@ -0,0 +1,125 @@
+package regression
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// naivePAVA pools the first adjacent violators until there are none.
+func naivePAVA(y, w []float64) []float64 {
+	type block struct {
+		mean, weight float64
+		n            int
+	}
+	var b []block
+	for i, v := range y {
+		b = append(b, block{v, w[i], 1})
+	}
+	for {
+		i := 0
+		for i+1 < len(b) && b[i].mean < b[i+1].mean {
+			i++
+		}
+		if i+1 == len(b) {
+			break
+		}
+		tw := b[i].weight + b[i+1].weight
+		b[i] = block{(b[i].mean*b[i].weight + b[i+1].mean*b[i+1].weight) / tw, tw, b[i].n + b[i+1].n}
+		b = append(b[:i+1], b[i+2:]...)
+	}
+	var fit []float64
+	for _, c := range b {
+		for j := 0; j < c.n; j++ {
+			fit = append(fit, c.mean)
+		}
+	}
+	return fit
+}
+
+func TestIsotonic(t *testing.T) {
+	x := []float64{1, 2, 3, 4, 5, 6}
+	y := []float64{1, 3, 2, 4, 3.5, 5}
+	iso := NewIsotonic(x, y, nil, true, SecondaryTies)
+	want := []float64{1, 2.5, 2.5, 3.75, 3.75, 5}
+	for i, v := range iso.Fitted() {
+		if math.Abs(v-want[i]) > 1e-12 {
+			t.Errorf("fitted %d: %g, expected %g", i, v, want[i])
+		}
+	}
+	if iso.EDF() != 4 {
+		t.Errorf("edf %g, expected 4", iso.EDF())
+	}
+	for _, c := range []struct{ x, interp, step float64 }{
+		{0, 1, 1}, {1.5, 1.75, 1}, {2, 2.5, 2.5}, {4.5, 3.75, 3.75}, {5.5, 4.375, 3.75}, {7, 5, 5},
+	} {
+		if p := iso.Predict(c.x); math.Abs(p-c.interp) > 1e-12 {
+			t.Errorf("prediction at %g: %g, expected %g", c.x, p, c.interp)
+		}
+		if p := iso.PredictStep(c.x); math.Abs(p-c.step) > 1e-12 {
+			t.Errorf("step prediction at %g: %g, expected %g", c.x, p, c.step)
+		}
+	}
+
+	// decreasing with weights
+	w := []float64{1, 1, 1, 1, 3, 1}
+	iso = NewIsotonic(x, []float64{5, 4, 4.5, 2, 3, 1}, w, false, SecondaryTies)
+	want = []float64{5, 4.25, 4.25, 2.75, 2.75, 1}
+	for i, v := range iso.Fitted() {
+		if math.Abs(v-want[i]) > 1e-12 {
+			t.Errorf("decreasing fitted %d: %g, expected %g", i, v, want[i])
+		}
+	}
+
+	// ties
+	x = []float64{2, 1, 2, 3}
+	y = []float64{3, 1, 0, 4}
+	iso = NewIsotonic(x, y, nil, true, SecondaryTies)
+	want = []float64{1.5, 1, 1.5, 4}
+	for i, v := range iso.Fitted() {
+		if math.Abs(v-want[i]) > 1e-12 {
+			t.Errorf("secondary fitted %d: %g, expected %g", i, v, want[i])
+		}
+	}
+	iso = NewIsotonic(x, y, nil, true, PrimaryTies)
+	want = []float64{3, 0.5, 0.5, 4}
+	for i, v := range iso.Fitted() {
+		if math.Abs(v-want[i]) > 1e-12 {
+			t.Errorf("primary fitted %d: %g, expected %g", i, v, want[i])
+		}
+	}
+	if kx, ky := iso.Knots(); len(kx) != 3 || ky[1] != 1.75 || iso.Predict(2) != 1.75 {
+		t.Errorf("knots %v, %v", kx, ky)
+	}
+
+	// against naive pooling
+	rng := rand.New(rand.NewSource(1))
+	n := 500
+	x = make([]float64, n)
+	y = make([]float64, n)
+	w = make([]float64, n)
+	for i := range x {
+		x[i] = float64(i)
+		y[i] = float64(i)/100 + rng.NormFloat64()
+		w[i] = 0.1 + rng.Float64()
+	}
+	rng.Shuffle(n, func(i, j int) {
+		x[i], x[j] = x[j], x[i]
+		y[i], y[j] = y[j], y[i]
+		w[i], w[j] = w[j], w[i]
+	})
+	iso = NewIsotonic(x, y, w, true, PrimaryTies)
+	ys := make([]float64, n)
+	ws := make([]float64, n)
+	for i, v := range x {
+		ys[int(v)], ws[int(v)] = y[i], w[i]
+	}
+	naive := naivePAVA(ys, ws)
+	f := iso.Fitted()
+	for i, v := range x {
+		if math.Abs(f[i]-naive[int(v)]) > 1e-9 {
+			t.Errorf("fitted at %g: %g, expected %g", v, f[i], naive[int(v)])
+			break
+		}
+	}
+}
@@ -0,0 +1,518 @@
+package regression
+
+import (
+	"math"
+	"sort"
+)
+
+// Estimates a local polynomial regression (LOESS) of y on x
+// (Cleveland, Grosse and Shyu, 1992): the fit at x0 is the value at x0 of
+// the polynomial of the given degree fit by weighted least squares to the
+// q = floor(n * span) observations nearest to x0, with the tricube weights
+//
+// w_i = (1 - (|x_i - x0| / h)^3)^3,
+//
+// where h is the distance to the q-th nearest observation,
+// multiplied by span if span > 1.
+//
+// With robustness iterations, as R's loess with family = "symmetric",
+// the observations are then reweighted by the bisquare of their residuals
+// divided by six times the median absolute residual, and the local fits
+// are repeated.
+//
+// The local fits are computed exactly at every point, as R's loess with
+// surface = "direct". Each fitted value is a linear combination l_i'y of the
+// observations; the equivalent number of parameters is the trace of the
+// operator L, and the residual standard error uses the degrees of freedom
+// tr((I-L)'(I-L)).
+type Loess struct {
+	x, y     []float64
+	xs, ys   []float64 // observations sorted by x
+	order    []int     // index of the sorted observations
+	span     float64
+	degree   int
+	q        int
+	rw       []float64 // robustness weights of the sorted observations
+	fitted   []float64
+	trace    float64
+	oneDelta float64
+}
+
+// NewLoess fits a local polynomial regression of degree 0, 1 or 2 with
+// the given span, followed by the given number of robustness iterations:
+// 0 for least squares local fits, 3 for R's family = "symmetric"
+// (R's default span and degree are 0.75 and 2).
+func NewLoess(x, y []float64, span float64, degree, iterations int) *Loess {
+	checkLineData(x, y)
+	if degree < 0 || degree > 2 {
+		panic("regression: degree must be 0, 1 or 2")
+	}
+	if span <= 0 {
+		panic("regression: span must be positive")
+	}
+	if iterations < 0 {
+		panic("regression: number of iterations must be non-negative")
+	}
+	n := len(x)
+	q := int(math.Floor(float64(n) * span))
+	if q > n {
+		q = n
+	}
+	if q < degree+1 {
+		panic("regression: span too small for the degree")
+	}
+
+	l := &Loess{x: x, y: y, span: span, degree: degree, q: q}
+	l.order = make([]int, n)
+	for i := range l.order {
+		l.order[i] = i
+	}
+	sort.SliceStable(l.order, func(i, j int) bool { return x[l.order[i]] < x[l.order[j]] })
+	l.xs = make([]float64, n)
+	l.ys = make([]float64, n)
+	for k, i := range l.order {
+		l.xs[k], l.ys[k] = x[i], y[i]
+	}
+	l.rw = make([]float64, n)
+	for i := range l.rw {
+		l.rw[i] = 1
+	}
+
+	l.fitted = make([]float64, n)
+	res := make([]float64, n)
+	for it := 0; ; it++ {
+		l.trace, l.oneDelta = 0, 0
+		for k, x0 := range l.xs {
+			v, lo, row := l.local(x0)
+			if row == nil {
+				// no observation with positive weight
+				v, lo, row = l.ys[k], k, []float64{1}
+			}
+			i := l.order[k]
+			l.fitted[i] = v
+			for j, lj := range row {
+				if lo+j == k {
+					l.trace += lj
+					l.oneDelta += 1 - 2*lj
+				}
+				l.oneDelta += lj * lj
+			}
+			res[k] = math.Abs(l.ys[k] - v)
+		}
+		if it == iterations {
+			break
+		}
+		cmad := 6 * median(append([]float64(nil), res...))
+		if cmad == 0 {
+			break
+		}
+		for k, r := range res {
+			l.rw[k] = 0
+			if u := r / cmad; u < 1 {
+				l.rw[k] = (1 - u*u) * (1 - u*u)
+			}
+		}
+	}
+	return l
+}
+
+// local returns the local fit at x0 and its weights row[j] of ys[lo+j].
+// row is nil if no observation has a positive weight.
+func (l *Loess) local(x0 float64) (value float64, lo int, row []float64) {
+	n := len(l.xs)
+	lo = sort.SearchFloat64s(l.xs, x0)
+	hi := lo
+	for hi-lo < l.q {
+		if hi == n || (lo > 0 && x0-l.xs[lo-1] <= l.xs[hi]-x0) {
+			lo--
+		} else {
+			hi++
+		}
+	}
+	h := math.Max(x0-l.xs[lo], l.xs[hi-1]-x0)
+	if l.span > 1 {
+		h *= l.span
+	}
+
+	// weighted design centered at x0, for the observations with positive weight
+	var rows [][]float64
+	var idx []int
+	var sw []float64
+	for k := lo; k < hi; k++ {
+		w := l.rw[k]
+		if h > 0 {
+			d := math.Abs(l.xs[k]-x0) / h
+			if d >= 1 {
+				continue
+			}
+			c := 1 - d*d*d
+			w *= c * c * c
+		}
+		if w <= 0 {
+			continue
+		}
+		s := math.Sqrt(w)
+		r := make([]float64, l.degree+1)
+		u := 1.0
+		for p := range r {
+			r[p] = s * u
+			u *= l.xs[k] - x0
+		}
+		rows = append(rows, r)
+		idx = append(idx, k)
+		sw = append(sw, s)
+	}
+	if len(rows) == 0 {
+		return math.NaN(), lo, nil
+	}
+
+	// the highest degree that the local design supports
+	var q *qr
+	for p := l.degree + 1; p > 0; p-- {
+		if p > len(rows) {
+			continue
+		}
+		for i, r := range rows {
+			rows[i] = r[:p]
+		}
+		if q = newQR(rows); q.fullRank() {
+			break
+		}
+	}
+
+	// the fit is e0' R^-1 Q' sqrt(W) y, so the weights are sqrt(W) Q R^-T e0
+	z := make([]float64, len(rows))
+	for i := 0; i < q.n; i++ {
+		s := 0.0
+		if i == 0 {
+			s = 1
+		}
+		for k := 0; k < i; k++ {
+			s -= q.r(k, i) * z[k]
+		}
+		z[i] = s / q.rdiag[i]
+	}
+	q.qMul(z)
+	row = make([]float64, hi-lo)
+	for i, k := range idx {
+		row[k-lo] = sw[i] * z[i]
+		value += row[k-lo] * l.ys[k]
+	}
+	return value, lo, row
+}
+
+// Returns the number of observations.
+func (l *Loess) N() int {
+	return len(l.x)
+}
+
+// Returns the equivalent number of parameters, the trace of the operator
+// that maps the observations to the fitted values.
+func (l *Loess) EDF() float64 {
+	return l.trace
+}
+
+// Returns the residual standard error, the square root of the residual sum
+// of squares divided by tr((I-L)'(I-L)).
+func (l *Loess) ResidualStdError() float64 {
+	sse := 0.0
+	for _, r := range l.Residuals() {
+		sse += r * r
+	}
+	return math.Sqrt(sse / l.oneDelta)
+}
+
+// Returns the robustness weights of the observations, all 1 without
+// robustness iterations.
+func (l *Loess) RobustnessWeights() []float64 {
+	w := make([]float64, len(l.rw))
+	for k, i := range l.order {
+		w[i] = l.rw[k]
+	}
+	return w
+}
+
+// Returns the fitted values of the observations.
+func (l *Loess) Fitted() []float64 {
+	return append([]float64(nil), l.fitted...)
+}
+
+// Returns the residuals y - fitted of the observations.
+func (l *Loess) Residuals() []float64 {
+	r := make([]float64, len(l.y))
+	for i, v := range l.y {
+		r[i] = v - l.fitted[i]
+	}
+	return r
+}
+
+// Returns the local fit at x, with the final robustness weights.
+// Outside the range of the observations the local polynomial at the
+// nearest observations is extrapolated.
+func (l *Loess) Predict(x float64) float64 {
+	v, _, _ := l.local(x)
+	return v
+}
+
+// Computes Cleveland's (1979) LOWESS smoother as R's lowess: local linear
+// fits to the ns = floor(f * n) observations nearest to each x with tricube
+// weights, followed by iter robustness iterations with bisquare weights.
+// To save computations, the local fits are skipped at observations within
+// delta of the last fitted observation and the fitted values there are
+// interpolated linearly; delta = 0 fits every observation.
+// R's defaults are f = 2/3, iter = 3 and delta = 0.01 * (max(x) - min(x)).
+type Lowess struct {
+	x, y   []float64
+	xs, ys []float64 // observations sorted by x
+	order  []int     // index of the sorted observations
+	ns     int
+	rw     []float64 // robustness weights of the last fits
+	robust bool      // whether the last fits used rw
+	fitted []float64 // fitted values of the sorted observations
+	trace  float64
+}
+
+// lowessRow holds the weights of a local fit on ys[lo:lo+len(w)].
+type lowessRow struct {
+	lo int
+	w  []float64
+}
+
+func (r lowessRow) at(j int) float64 {
+	if j < r.lo || j >= r.lo+len(r.w) {
+		return 0
+	}
+	return r.w[j-r.lo]
+}
+
+// NewLowess smooths the observations with LOWESS.
+func NewLowess(x, y []float64, f float64, iter int, delta float64) *Lowess {
+	checkLineData(x, y)
+	if f <= 0 {
+		panic("regression: smoother span must be positive")
+	}
+	if iter < 0 || delta < 0 {
+		panic("regression: iter and delta must be non-negative")
+	}
+	n := len(x)
+	l := &Lowess{x: x, y: y}
+	l.order = make([]int, n)
+	for i := range l.order {
+		l.order[i] = i
+	}
+	sort.SliceStable(l.order, func(i, j int) bool { return x[l.order[i]] < x[l.order[j]] })
+	l.xs = make([]float64, n)
+	l.ys = make([]float64, n)
+	for k, i := range l.order {
+		l.xs[k], l.ys[k] = x[i], y[i]
+	}
+	l.ns = int(f*float64(n) + 1e-7)
+	if l.ns > n {
+		l.ns = n
+	}
+	if l.ns < 2 {
+		l.ns = 2
+	}
+	l.rw = make([]float64, n)
+	l.fitted = make([]float64, n)
+	l.fit(iter, delta)
+	return l
+}
+
+// fit follows clowess in R's lowess.c.
+func (l *Lowess) fit(iter int, delta float64) {
+	x, y, ys := l.xs, l.ys, l.fitted
+	n := len(x)
+	diag := make([]float64, n)
+	res := make([]float64, n)
+	for iteration := 1; iteration <= iter+1; iteration++ {
+		nleft, nright, last, i := 0, l.ns-1, -1, 0
+		var lastRow lowessRow
+		for {
+			for nright < n-1 && x[i]-x[nleft] > x[nright+1]-x[i] {
+				nleft++
+				nright++
+			}
+			v, row, ok := l.lowest(x[i], nleft, nright)
+			if !ok {
+				v, row = y[i], lowessRow{lo: i, w: []float64{1}}
+			}
+			ys[i] = v
+			diag[i] = row.at(i)
+			if last < i-1 {
+				// interpolate the skipped observations
+				denom := x[i] - x[last]
+				for j := last + 1; j < i; j++ {
+					alpha := (x[j] - x[last]) / denom
+					ys[j] = alpha*ys[i] + (1-alpha)*ys[last]
+					diag[j] = alpha*row.at(j) + (1-alpha)*lastRow.at(j)
+				}
+			}
+			last, lastRow = i, row
+			cut := x[last] + delta
+			for i = last + 1; i < n; i++ {
+				if x[i] > cut {
+					break
+				}
+				if x[i] == x[last] {
+					ys[i] = ys[last]
+					diag[i] = lastRow.at(i)
+					last = i
+				}
+			}
+			if i = i - 1; i < last+1 {
+				i = last + 1
+			}
+			if last >= n-1 {
+				break
+			}
+		}
+
+		for j := range res {
+			res[j] = y[j] - ys[j]
+		}
+		if iteration > iter {
+			break
+		}
+		sc := 0.0
+		for _, r := range res {
+			sc += math.Abs(r)
+		}
+		sc /= float64(n)
+		abs := make([]float64, n)
+		for j, r := range res {
+			abs[j] = math.Abs(r)
+		}
+		cmad := 6 * median(abs)
+		if cmad < 1e-7*sc {
+			// the fit is exact up to rounding
+			break
+		}
+		c9, c1 := 0.999*cmad, 0.001*cmad
+		for j, r := range res {
+			r = math.Abs(r)
+			switch {
+			case r <= c1:
+				l.rw[j] = 1
+			case r <= c9:
+				u := r / cmad
+				l.rw[j] = (1 - u*u) * (1 - u*u)
+			default:
+				l.rw[j] = 0
+			}
+		}
+		l.robust = true
+	}
+	l.trace = 0
+	for _, d := range diag {
+		l.trace += d
+	}
+}
+
+// lowest returns the local linear fit at xs to the observations from
+// nleft to nright, as in R's lowess.c.
+func (l *Lowess) lowest(xs float64, nleft, nright int) (ys float64, row lowessRow, ok bool) {
+	x := l.xs
+	n := len(x)
+	rng := x[n-1] - x[0]
+	h := math.Max(xs-x[nleft], x[nright]-xs)
+	h9, h1 := 0.999*h, 0.001*h
+
+	var w []float64
+	a := 0.0
+	j := nleft
+	for ; j < n; j++ {
+		r := math.Abs(x[j] - xs)
+		wj := 0.0
+		if r <= h9 {
+			if r <= h1 {
+				wj = 1
+			} else {
+				c := r / h
+				c = 1 - c*c*c
+				wj = c * c * c
+			}
+			if l.robust {
+				wj *= l.rw[j]
+			}
+			a += wj
+		} else if x[j] > xs {
+			break
+		}
+		w = append(w, wj)
+	}
+	if a <= 0 {
+		return 0, row, false
+	}
+	for k := range w {
+		w[k] /= a
+	}
+	if h > 0 {
+		// weighted least squares line instead of the weighted mean
+		a = 0
+		for k, wk := range w {
+			a += wk * x[nleft+k]
+		}
+		b := xs - a
+		c := 0.0
+		for k, wk := range w {
+			c += wk * (x[nleft+k] - a) * (x[nleft+k] - a)
+		}
+		if math.Sqrt(c) > 0.001*rng {
+			b /= c
+			for k := range w {
+				w[k] *= b*(x[nleft+k]-a) + 1
+			}
+		}
+	}
+	for k, wk := range w {
+		ys += wk * l.ys[nleft+k]
+	}
+	return ys, lowessRow{lo: nleft, w: w}, true
+}
+
+// Returns the number of observations.
+func (l *Lowess) N() int {
+	return len(l.x)
+}
+
+// Returns the equivalent number of parameters, the trace of the linear
+// operator of the final fits, including the interpolation.
+func (l *Lowess) EDF() float64 {
+	return l.trace
+}
+
+// Returns the fitted values of the observations.
+func (l *Lowess) Fitted() []float64 {
+	f := make([]float64, len(l.x))
+	for k, i := range l.order {
+		f[i] = l.fitted[k]
+	}
+	return f
+}
+
+// Returns the residuals y - fitted of the observations.
+func (l *Lowess) Residuals() []float64 {
+	r := l.Fitted()
+	for i, v := range l.y {
+		r[i] = v - r[i]
+	}
+	return r
+}
+
+// Returns the local fit at x to its ns nearest observations, with the
+// robustness weights of the final fits. At the observations it equals the
+// fitted values when delta is 0.
+func (l *Lowess) Predict(x float64) float64 {
+	n := len(l.xs)
+	nleft, nright := 0, l.ns-1
+	for nright < n-1 && x-l.xs[nleft] > l.xs[nright+1]-x {
+		nleft++
+		nright++
+	}
+	v, _, ok := l.lowest(x, nleft, nright)
+	if !ok {
+		return math.NaN()
+	}
+	return v
+}
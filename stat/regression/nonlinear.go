@@ -0,0 +1,467 @@
+package regression
+
+import (
+	"math"
+)
+
+// A Model is a nonlinear regression function, the expected response
+// for the independent variables x of one observation and the parameters.
+type Model func(x, params []float64) float64
+
+// A Jacobian sets grad to the partial derivatives of a Model with respect
+// to the parameters, at the independent variables x of one observation.
+type Jacobian func(grad, x, params []float64)
+
+// CurveFitOptions holds the optional settings of NewCurveFit.
+// The zero value fits unweighted observations without bounds, with
+// forward difference derivatives.
+type CurveFitOptions struct {
+	// Jacobian of the model. If nil, it is approximated by forward
+	// differences.
+	Jacobian Jacobian
+	// Lower and Upper bound the parameters; either may be nil, and
+	// infinite values leave a parameter unbounded on that side.
+	Lower, Upper []float64
+	// Weights of the squared residuals, e.g. the inverse variances
+	// of the observations.
+	Weights []float64
+	// Tolerance on the relative reduction of the sum of squared errors,
+	// the relative size of the step and the cosine between the residuals
+	// and the columns of the Jacobian. The default is the square root of
+	// the machine epsilon, as in MINPACK.
+	Tolerance float64
+	// MaxIter limits the number of iterations. The default is 200.
+	MaxIter int
+}
+
+// A Termination tells why the iterations of a CurveFit stopped.
+type Termination int
+
+const (
+	// MaxIterations means that the fit did not converge within MaxIter iterations.
+	MaxIterations Termination = iota
+	// SmallReduction means that the relative actual and predicted
+	// reductions of the sum of squared errors were below the tolerance.
+	SmallReduction
+	// SmallStep means that the relative change of the parameters was
+	// below the tolerance.
+	SmallStep
+	// SmallGradient means that the residuals are orthogonal to the
+	// columns of the Jacobian, up to the tolerance.
+	SmallGradient
+)
+
+func (t Termination) String() string {
+	switch t {
+	case MaxIterations:
+		return "maximum number of iterations reached"
+	case SmallReduction:
+		return "relative reduction of the sum of squares below tolerance"
+	case SmallStep:
+		return "relative step size below tolerance"
+	case SmallGradient:
+		return "gradient below tolerance"
+	}
+	return "unknown termination"
+}
+
+// Estimates the parameters of a nonlinear regression model
+//
+// y = f(x, params) + e
+//
+// by (weighted) least squares with the Levenberg-Marquardt method.
+// Each iteration solves the damped Gauss-Newton system
+//
+// (J'WJ + mu * D) * step = J'W * (y - f)
+//
+// with the QR decomposition of the augmented Jacobian, where D holds the
+// largest diagonal of J'WJ seen so far (Marquardt's scaling) and mu is
+// updated from the ratio of the actual to the predicted reduction of the
+// sum of squares as in Nielsen (1999).
+//
+// Bounds are handled by projection: the step is computed for the
+// parameters that are not held at a bound by the gradient, and the new
+// parameters are clamped to the bounds.
+//
+// The covariance of the estimates is the asymptotic s^2 * (J'WJ)^-1 at the
+// solution, with s^2 the residual mean square; it is not meaningful for
+// parameters on a bound.
+type CurveFit struct {
+	f           Model
+	x           [][]float64
+	y, w        []float64
+	params      []float64
+	jac         [][]float64 // Jacobian at params, one row per observation
+	fitted      []float64
+	sse         float64
+	iterations  int
+	evaluations int
+	termination Termination
+}
+
+// NewCurveFit fits the model f to the observations y at the rows of x,
+// starting from the given parameters, which must lie within the bounds.
+// opts may be nil. An Error is returned if there are fewer observations than
+// parameters or the model cannot be evaluated at the start; a fit that does
+// not converge within MaxIter iterations is returned with Converged false.
+func NewCurveFit(f Model, x [][]float64, y []float64, start []float64, opts *CurveFitOptions) (*CurveFit, error) {
+	if opts == nil {
+		opts = &CurveFitOptions{}
+	}
+	n, p := len(y), len(start)
+	if len(x) != n {
+		panic("regression: x and y have different numbers of observations")
+	}
+	if p == 0 {
+		panic("regression: no parameters to estimate")
+	}
+	lower, upper := opts.Lower, opts.Upper
+	if lower == nil {
+		lower = make([]float64, p)
+		for i := range lower {
+			lower[i] = math.Inf(-1)
+		}
+	}
+	if upper == nil {
+		upper = make([]float64, p)
+		for i := range upper {
+			upper[i] = math.Inf(1)
+		}
+	}
+	if len(lower) != p || len(upper) != p {
+		panic("regression: bounds must have one value per parameter")
+	}
+	for i, v := range start {
+		if !(lower[i] <= v && v <= upper[i]) {
+			panic("regression: start parameters must lie within the bounds")
+		}
+	}
+	w := opts.Weights
+	if w == nil {
+		w = make([]float64, n)
+		for i := range w {
+			w[i] = 1
+		}
+	}
+	if len(w) != n {
+		panic("regression: weights must have one value per observation")
+	}
+	for _, v := range w {
+		if v < 0 {
+			panic("regression: weights must be non-negative")
+		}
+	}
+	tol := opts.Tolerance
+	if tol == 0 {
+		tol = math.Sqrt(2.220446049250313e-16)
+	}
+	maxIter := opts.MaxIter
+	if maxIter == 0 {
+		maxIter = 200
+	}
+	if n < p {
+		return nil, Error{Message: "regression: not enough observations for the number of parameters"}
+	}
+
+	c := &CurveFit{f: f, x: x, y: y, w: w, params: append([]float64(nil), start...)}
+	c.fitted = make([]float64, n)
+	c.sse = c.evaluate(c.params, c.fitted)
+	if math.IsNaN(c.sse) || math.IsInf(c.sse, 0) {
+		return nil, Error{Message: "regression: the model is not finite at the start parameters"}
+	}
+	c.jac = make([][]float64, n)
+	for i := range c.jac {
+		c.jac[i] = make([]float64, p)
+	}
+	c.fit(opts.Jacobian, lower, upper, tol, maxIter)
+	return c, nil
+}
+
+// evaluate sets fitted to the model values at params and returns the
+// weighted sum of squared errors.
+func (c *CurveFit) evaluate(params, fitted []float64) float64 {
+	c.evaluations++
+	sse := 0.0
+	for i, row := range c.x {
+		fitted[i] = c.f(row, params)
+		r := c.y[i] - fitted[i]
+		sse += c.w[i] * r * r
+	}
+	return sse
+}
+
+// jacobian sets c.jac at c.params, by forward differences if jac is nil.
+func (c *CurveFit) jacobian(jac Jacobian, upper []float64) {
+	if jac != nil {
+		for i, row := range c.x {
+			jac(c.jac[i], row, c.params)
+		}
+		return
+	}
+	const eps = 1.4901161193847656e-08 // sqrt(machine epsilon)
+	params := append([]float64(nil), c.params...)
+	for j, v := range c.params {
+		h := eps * math.Abs(v)
+		if h == 0 {
+			h = eps
+		}
+		if v+h > upper[j] {
+			h = -h
+		}
+		params[j] = v + h
+		h = params[j] - v // exactly representable
+		c.evaluations++
+		for i, row := range c.x {
+			c.jac[i][j] = (c.f(row, params) - c.fitted[i]) / h
+		}
+		params[j] = v
+	}
+}
+
+func (c *CurveFit) fit(jac Jacobian, lower, upper []float64, tol float64, maxIter int) {
+	n, p := len(c.y), len(c.params)
+	scale := make([]float64, p) // Marquardt's scaling, the largest diag(J'WJ)
+	grad := make([]float64, p)  // J'W(y - f), the steepest descent direction
+	free := make([]int, 0, p)
+	trial := make([]float64, p)
+	trialFitted := make([]float64, n)
+	mu, nu := 1e-3, 2.0
+
+	c.jacobian(jac, upper)
+	for c.iterations < maxIter {
+		// projected gradient and scaled gradient test
+		free = free[:0]
+		gmax := 0.0
+		for j := range c.params {
+			g, jj := 0.0, 0.0
+			for i, row := range c.jac {
+				g += c.w[i] * row[j] * (c.y[i] - c.fitted[i])
+				jj += c.w[i] * row[j] * row[j]
+			}
+			grad[j] = g
+			scale[j] = math.Max(scale[j], jj)
+			if (c.params[j] <= lower[j] && g < 0) || (c.params[j] >= upper[j] && g > 0) {
+				continue
+			}
+			free = append(free, j)
+			if jj > 0 && c.sse > 0 {
+				gmax = math.Max(gmax, math.Abs(g)/math.Sqrt(jj*c.sse))
+			}
+		}
+		if gmax <= tol {
+			c.termination = SmallGradient
+			return
+		}
+
+		// damped step for the free parameters
+		c.iterations++
+		rows := make([][]float64, n+len(free))
+		rhs := make([]float64, n+len(free))
+		for i, row := range c.jac {
+			sw := math.Sqrt(c.w[i])
+			rows[i] = make([]float64, len(free))
+			for k, j := range free {
+				rows[i][k] = sw * row[j]
+			}
+			rhs[i] = sw * (c.y[i] - c.fitted[i])
+		}
+		for k, j := range free {
+			d := scale[j]
+			if d == 0 {
+				d = 1
+			}
+			rows[n+k] = make([]float64, len(free))
+			rows[n+k][k] = math.Sqrt(mu * d)
+		}
+		step := newQR(rows).solve(rhs)
+
+		copy(trial, c.params)
+		for k, j := range free {
+			trial[j] = math.Min(upper[j], math.Max(lower[j], c.params[j]+step[k]))
+		}
+		// predicted reduction of the linear model for the projected step
+		predicted, stepNorm, paramNorm := 0.0, 0.0, 0.0
+		for i, row := range c.jac {
+			js := 0.0
+			for j, v := range trial {
+				js += row[j] * (v - c.params[j])
+			}
+			r := c.y[i] - c.fitted[i]
+			predicted += c.w[i] * (r*r - (r-js)*(r-js))
+		}
+		for j, v := range trial {
+			d := math.Sqrt(math.Max(scale[j], 1e-300))
+			stepNorm += (v - c.params[j]) * (v - c.params[j]) * d * d
+			paramNorm += c.params[j] * c.params[j] * d * d
+		}
+		stepNorm, paramNorm = math.Sqrt(stepNorm), math.Sqrt(paramNorm)
+
+		sse := c.evaluate(trial, trialFitted)
+		actual := c.sse - sse
+		rho := -1.0
+		if predicted > 0 && !math.IsNaN(sse) {
+			rho = actual / predicted
+		}
+		if rho > 0 {
+			copy(c.params, trial)
+			copy(c.fitted, trialFitted)
+			c.sse = sse
+			c.jacobian(jac, upper)
+			mu *= math.Max(1.0/3, 1-math.Pow(2*rho-1, 3))
+			nu = 2
+			if actual <= tol*(c.sse+actual) && predicted <= tol*(c.sse+actual) {
+				c.termination = SmallReduction
+				return
+			}
+		} else {
+			mu *= nu
+			nu *= 2
+		}
+		if stepNorm <= tol*(paramNorm+tol) {
+			c.termination = SmallStep
+			return
+		}
+	}
+	c.termination = MaxIterations
+}
+
+// Returns the number of observations.
+func (c *CurveFit) N() int {
+	return len(c.y)
+}
+
+// Returns the number of parameters.
+func (c *CurveFit) NumParameters() int {
+	return len(c.params)
+}
+
+// Returns the estimated parameters.
+func (c *CurveFit) Parameters() []float64 {
+	return append([]float64(nil), c.params...)
+}
+
+// Returns true if one of the convergence criteria was met.
+func (c *CurveFit) Converged() bool {
+	return c.termination != MaxIterations
+}
+
+// Returns why the iterations stopped.
+func (c *CurveFit) Termination() Termination {
+	return c.termination
+}
+
+// Returns the number of iterations, each solving one damped system.
+func (c *CurveFit) Iterations() int {
+	return c.iterations
+}
+
+// Returns the number of evaluations of the model at all the observations,
+// including those for finite difference derivatives.
+func (c *CurveFit) Evaluations() int {
+	return c.evaluations
+}
+
+// Returns the (weighted) sum of squared errors at the solution.
+func (c *CurveFit) SumSquaredErrors() float64 {
+	return c.sse
+}
+
+// Returns the residual degrees of freedom, n - p.
+func (c *CurveFit) ResidualDF() int {
+	return len(c.y) - len(c.params)
+}
+
+// Returns the residual mean square, the sum of squared errors divided by
+// the residual degrees of freedom.
+func (c *CurveFit) MeanSquareError() float64 {
+	return c.sse / float64(c.ResidualDF())
+}
+
+// Returns the Jacobian of the model at the solution, one row per observation.
+func (c *CurveFit) Jacobian() [][]float64 {
+	jac := make([][]float64, len(c.jac))
+	for i, row := range c.jac {
+		jac[i] = append([]float64(nil), row...)
+	}
+	return jac
+}
+
+// Returns the estimated covariance matrix of the parameters,
+// s^2 * (J'WJ)^-1. An Error is returned if the Jacobian is rank deficient,
+// or if there are no residual degrees of freedom to estimate s^2.
+func (c *CurveFit) Covariance() ([][]float64, error) {
+	if c.ResidualDF() <= 0 {
+		return nil, Error{Message: "regression: no residual degrees of freedom"}
+	}
+	rows := make([][]float64, len(c.jac))
+	for i, row := range c.jac {
+		sw := math.Sqrt(c.w[i])
+		rows[i] = make([]float64, len(row))
+		for j, v := range row {
+			rows[i][j] = sw * v
+		}
+	}
+	q := newQR(rows)
+	if !q.fullRank() {
+		return nil, Error{Message: "regression: the Jacobian is rank deficient at the solution"}
+	}
+	cov := q.xtxInverse()
+	mse := c.MeanSquareError()
+	for _, row := range cov {
+		for j := range row {
+			row[j] *= mse
+		}
+	}
+	return cov, nil
+}
+
+// Returns the standard errors of the parameters.
+func (c *CurveFit) StdErrors() ([]float64, error) {
+	cov, err := c.Covariance()
+	if err != nil {
+		return nil, err
+	}
+	se := make([]float64, len(cov))
+	for i := range se {
+		se[i] = math.Sqrt(cov[i][i])
+	}
+	return se, nil
+}
+
+// Returns the asymptotic 1-alpha confidence intervals of the parameters,
+// params +/- t(1-alpha/2, n-p) * se. alpha must be in (0, 1).
+// An Error is returned if the covariance cannot be estimated.
+func (c *CurveFit) ConfidenceIntervals(alpha float64) ([][2]float64, error) {
+	if alpha <= 0 || alpha >= 1 {
+		panic("regression: alpha must be in (0, 1)")
+	}
+	se, err := c.StdErrors()
+	if err != nil {
+		return nil, err
+	}
+	t := studentTQuantile(1-alpha/2, float64(c.ResidualDF()))
+	ci := make([][2]float64, len(se))
+	for i, b := range c.params {
+		ci[i] = [2]float64{b - t*se[i], b + t*se[i]}
+	}
+	return ci, nil
+}
+
+// Returns the fitted values of the observations.
+func (c *CurveFit) Fitted() []float64 {
+	return append([]float64(nil), c.fitted...)
+}
+
+// Returns the residuals y - f(x, params) of the observations.
+func (c *CurveFit) Residuals() []float64 {
+	r := make([]float64, len(c.y))
+	for i, v := range c.y {
+		r[i] = v - c.fitted[i]
+	}
+	return r
+}
+
+// Returns the predicted response for the independent variables x.
+func (c *CurveFit) Predict(x []float64) float64 {
+	return c.f(x, c.params)
+}
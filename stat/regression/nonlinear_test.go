@@ -0,0 +1,183 @@
+package regression
+
+import (
+	"math"
+	"testing"
+)
+
+// NIST StRD Misra1a, y = b1 * (1 - exp(-b2 * x)), lower difficulty
+var misra1a = [][2]float64{
+	{10.07, 77.6}, {14.73, 114.9}, {17.94, 141.1}, {23.93, 190.8},
+	{29.61, 239.9}, {35.18, 289.0}, {40.02, 332.8}, {44.82, 378.4},
+	{50.76, 434.8}, {55.05, 477.3}, {61.01, 536.8}, {66.40, 593.1},
+	{75.47, 689.1}, {81.78, 760.0},
+}
+
+func misra1aModel(x, b []float64) float64 {
+	return b[0] * (1 - math.Exp(-b[1]*x[0]))
+}
+
+func misra1aJacobian(grad, x, b []float64) {
+	e := math.Exp(-b[1] * x[0])
+	grad[0] = 1 - e
+	grad[1] = b[0] * x[0] * e
+}
+
+func TestCurveFitMisra1a(t *testing.T) {
+	x := make([][]float64, len(misra1a))
+	y := make([]float64, len(misra1a))
+	for i, d := range misra1a {
+		y[i], x[i] = d[0], []float64{d[1]}
+	}
+	b := []float64{2.3894212918e+02, 5.5015643181e-04}
+	se := []float64{2.7070075241e+00, 7.2668688436e-06}
+	for _, start := range [][]float64{{500, 1e-4}, {250, 5e-4}} {
+		for _, jac := range []Jacobian{misra1aJacobian, nil} {
+			opts := &CurveFitOptions{Jacobian: jac, Tolerance: 1e-12}
+			c, err := NewCurveFit(misra1aModel, x, y, start, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !c.Converged() {
+				t.Errorf("start %v: not converged after %d iterations", start, c.Iterations())
+			}
+			cse, err := c.StdErrors()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, v := range c.Parameters() {
+				if relErr(v, b[i]) > 1e-6 || relErr(cse[i], se[i]) > 1e-5 {
+					t.Errorf("start %v, parameter %d: %g (%g), expected %g (%g)",
+						start, i, v, cse[i], b[i], se[i])
+				}
+			}
+			if relErr(c.SumSquaredErrors(), 1.2455138894e-01) > 1e-8 {
+				t.Errorf("sse %g, expected %g", c.SumSquaredErrors(), 1.2455138894e-01)
+			}
+			if relErr(math.Sqrt(c.MeanSquareError()), 1.0187876330e-01) > 1e-8 {
+				t.Errorf("residual sd %g", math.Sqrt(c.MeanSquareError()))
+			}
+		}
+	}
+}
+
+// Michaelis-Menten kinetics of the treated Puromycin data, as in R's ?nls
+var puromycin = struct{ conc, rate []float64 }{
+	[]float64{0.02, 0.02, 0.06, 0.06, 0.11, 0.11, 0.22, 0.22, 0.56, 0.56, 1.10, 1.10},
+	[]float64{76, 47, 97, 107, 123, 139, 159, 152, 191, 201, 207, 200},
+}
+
+func michaelisMenten(x, b []float64) float64 {
+	return b[0] * x[0] / (b[1] + x[0])
+}
+
+func TestCurveFitPuromycin(t *testing.T) {
+	x := make([][]float64, len(puromycin.conc))
+	for i, v := range puromycin.conc {
+		x[i] = []float64{v}
+	}
+	c, err := NewCurveFit(michaelisMenten, x, puromycin.rate, []float64{200, 0.1}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.Converged() {
+		t.Errorf("not converged: %v", c.Termination())
+	}
+	// R: nls(rate ~ Vm * conc/(K + conc), Puromycin, subset = state == "treated")
+	b, se := []float64{2.126837e+02, 6.412123e-02}, []float64{6.947155, 8.280965e-03}
+	cse, err := c.StdErrors()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range c.Parameters() {
+		if relErr(v, b[i]) > 1e-5 || relErr(cse[i], se[i]) > 1e-4 {
+			t.Errorf("parameter %d: %g (%g), expected %g (%g)", i, v, cse[i], b[i], se[i])
+		}
+	}
+	if math.Abs(math.Sqrt(c.MeanSquareError())-10.93) > 0.005 {
+		t.Errorf("residual standard error %g, expected 10.93", math.Sqrt(c.MeanSquareError()))
+	}
+	if c.ResidualDF() != 10 {
+		t.Errorf("residual df %d", c.ResidualDF())
+	}
+	ci, err := c.ConfidenceIntervals(0.05)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tq := studentTQuantile(0.975, 10)
+	if math.Abs(ci[0][1]-ci[0][0]-2*tq*cse[0]) > 1e-9 {
+		t.Errorf("confidence interval %v", ci[0])
+	}
+	for _, alpha := range []float64{0, 1, -0.1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("no panic for alpha = %g", alpha)
+				}
+			}()
+			c.ConfidenceIntervals(alpha)
+		}()
+	}
+	if p := c.Predict([]float64{0.5}); math.Abs(p-b[0]*0.5/(b[1]+0.5)) > 1e-3 {
+		t.Errorf("prediction %g", p)
+	}
+
+	// with Vm bounded below its estimate, K is the best fit for Vm = 190
+	opts := &CurveFitOptions{Upper: []float64{190, math.Inf(1)}}
+	bounded, err := NewCurveFit(michaelisMenten, x, puromycin.rate, []float64{150, 0.1}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixed, err := NewCurveFit(func(x, b []float64) float64 {
+		return michaelisMenten(x, []float64{190, b[0]})
+	}, x, puromycin.rate, []float64{0.1}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pb := bounded.Parameters()
+	if !bounded.Converged() || pb[0] != 190 || relErr(pb[1], fixed.Parameters()[0]) > 1e-6 {
+		t.Errorf("bounded fit %v (%v), expected [190 %g]", pb, bounded.Termination(), fixed.Parameters()[0])
+	}
+}
+
+func TestCurveFitExact(t *testing.T) {
+	// logistic growth without noise
+	logistic := func(x, b []float64) float64 {
+		return b[0] / (1 + math.Exp(-b[1]*(x[0]-b[2])))
+	}
+	want := []float64{50, 0.8, 6}
+	var x [][]float64
+	var y []float64
+	for i := 0; i <= 15; i++ {
+		x = append(x, []float64{float64(i)})
+		y = append(y, logistic(x[i], want))
+	}
+	c, err := NewCurveFit(logistic, x, y, []float64{40, 0.5, 5}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.Converged() {
+		t.Errorf("not converged: %v", c.Termination())
+	}
+	for i, v := range c.Parameters() {
+		if relErr(v, want[i]) > 1e-6 {
+			t.Errorf("parameter %d: %g, expected %g", i, v, want[i])
+		}
+	}
+
+	if _, err := NewCurveFit(logistic, x[:2], y[:2], []float64{40, 0.5, 5}, nil); err == nil {
+		t.Error("no error for fewer observations than parameters")
+	}
+
+	// as many observations as parameters leaves no residual degrees of freedom
+	e, err := NewCurveFit(logistic, x[5:8], y[5:8], []float64{40, 0.5, 5}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.ConfidenceIntervals(0.05); err == nil {
+		t.Error("no error for zero residual degrees of freedom")
+	}
+	if _, err := e.StdErrors(); err == nil {
+		t.Error("no error for standard errors with zero residual degrees of freedom")
+	}
+}
@@ -0,0 +1,443 @@
+package regression
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// An Error is returned when a model cannot be estimated from the data.
+type Error struct {
+	Message string
+}
+
+func (err Error) Error() string {
+	return err.Message
+}
+
+// Estimates a multiple linear regression model by ordinary least squares,
+//
+// y = b0 + b1 * x1 + ... + bk * xk + e,
+//
+// with or without the intercept b0. The coefficients are computed from the
+// QR decomposition of the design matrix, which is more accurate than
+// solving the normal equations.
+//
+// Standard errors, t tests and confidence intervals of the coefficients
+// are available as well as the overall F test, r-square, and the
+// likelihood based AIC and BIC. Summary prints all of them like R's lm.
+type OLS struct {
+	n, p      int  // number of observations and of coefficients
+	intercept bool // whether coefficient 0 is the intercept
+	coef      []float64
+	xtxInv    [][]float64 // (X'X)^-1
+	hat       []float64   // diagonal of the hat matrix X(X'X)^-1X'
+	fitted    []float64
+	residuals []float64
+	sse       float64 // sum of squared residuals
+	sst       float64 // total sum of squares, about the mean with an intercept
+}
+
+// NewOLS fits y on the rows of x. Each row holds the k independent variables
+// of one observation; with intercept, the coefficients are b0, b1, ..., bk,
+// otherwise b1, ..., bk. There must be more observations than coefficients,
+// and an Error is returned if the columns of the design are collinear.
+func NewOLS(x [][]float64, y []float64, intercept bool) (*OLS, error) {
+	n := len(y)
+	if len(x) != n {
+		panic("regression: x and y have different numbers of observations")
+	}
+	if n == 0 {
+		panic("regression: no observations")
+	}
+	k := len(x[0])
+	p := k
+	if intercept {
+		p++
+	}
+	if p == 0 {
+		panic("regression: no coefficients to estimate")
+	}
+	if n <= p {
+		return nil, Error{Message: "regression: not enough observations for the number of coefficients"}
+	}
+
+	design := make([][]float64, n)
+	for i, row := range x {
+		if len(row) != k {
+			panic("regression: rows of x have different lengths")
+		}
+		if intercept {
+			design[i] = append([]float64{1}, row...)
+		} else {
+			design[i] = row
+		}
+	}
+	q := newQR(design)
+	if !q.fullRank() {
+		return nil, Error{Message: "regression: design matrix is rank deficient"}
+	}
+
+	o := &OLS{n: n, p: p, intercept: intercept}
+	o.coef = q.solve(y)
+	o.xtxInv = q.xtxInverse()
+	o.hat = make([]float64, n)
+	for i, row := range design {
+		for j, c := range o.xtxInv {
+			for k, v := range c {
+				o.hat[i] += row[j] * v * row[k]
+			}
+		}
+	}
+
+	// fitted values are the projection Q*Q'y
+	o.fitted = append([]float64(nil), y...)
+	q.qtMul(o.fitted)
+	for i := p; i < n; i++ {
+		o.fitted[i] = 0
+	}
+	q.qMul(o.fitted)
+
+	o.residuals = make([]float64, n)
+	ybar := 0.0
+	for _, v := range y {
+		ybar += v
+	}
+	ybar /= float64(n)
+	for i, v := range y {
+		e := v - o.fitted[i]
+		o.residuals[i] = e
+		o.sse += e * e
+		d := v
+		if intercept {
+			d -= ybar
+		}
+		o.sst += d * d
+	}
+	return o, nil
+}
+
+// Returns the number of observations.
+func (o *OLS) N() int {
+	return o.n
+}
+
+// Returns the number of estimated coefficients, including the intercept.
+func (o *OLS) NumCoefficients() int {
+	return o.p
+}
+
+// Returns true if the model has an intercept.
+func (o *OLS) HasIntercept() bool {
+	return o.intercept
+}
+
+// Returns the estimated coefficients, starting with the intercept if any.
+func (o *OLS) Coefficients() []float64 {
+	return append([]float64(nil), o.coef...)
+}
+
+// Returns the residual degrees of freedom, n - p.
+func (o *OLS) ResidualDF() int {
+	return o.n - o.p
+}
+
+// Returns the sum of squared residuals.
+func (o *OLS) SumSquaredErrors() float64 {
+	return o.sse
+}
+
+// Returns the total sum of squares of y, about its mean if the model has an
+// intercept and about zero otherwise, as in R.
+func (o *OLS) TotalSumSquares() float64 {
+	return o.sst
+}
+
+// Returns the sum of squares explained by the regression.
+func (o *OLS) RegressionSumSquares() float64 {
+	return o.sst - o.sse
+}
+
+// Returns the unbiased estimate of the error variance, SSE / (n - p).
+func (o *OLS) MeanSquareError() float64 {
+	return o.sse / float64(o.n-o.p)
+}
+
+// Returns the estimated covariance matrix of the coefficients,
+// MSE * (X'X)^-1.
+func (o *OLS) Covariance() [][]float64 {
+	mse := o.MeanSquareError()
+	c := make([][]float64, o.p)
+	for i, row := range o.xtxInv {
+		c[i] = make([]float64, o.p)
+		for j, v := range row {
+			c[i][j] = mse * v
+		}
+	}
+	return c
+}
+
+// Returns the standard errors of the coefficients.
+func (o *OLS) StdErrors() []float64 {
+	mse := o.MeanSquareError()
+	se := make([]float64, o.p)
+	for i := range se {
+		se[i] = math.Sqrt(mse * o.xtxInv[i][i])
+	}
+	return se
+}
+
+// Returns the t statistics of the coefficients, for the hypotheses
+// that each coefficient is zero.
+func (o *OLS) TValues() []float64 {
+	t := o.StdErrors()
+	for i, b := range o.coef {
+		t[i] = b / t[i]
+	}
+	return t
+}
+
+// Returns the two-sided p-values of the t statistics.
+func (o *OLS) PValues() []float64 {
+	pv := o.TValues()
+	df := float64(o.n - o.p)
+	for i, t := range pv {
+		pv[i] = studentTTwoSided(t, df)
+	}
+	return pv
+}
+
+// Returns the 1-alpha confidence intervals of the coefficients.
+func (o *OLS) ConfidenceIntervals(alpha float64) [][2]float64 {
+	if alpha <= 0 || alpha >= 1 {
+		panic("regression: alpha must be in (0, 1)")
+	}
+	t := studentTQuantile(1-alpha/2, float64(o.n-o.p))
+	se := o.StdErrors()
+	ci := make([][2]float64, o.p)
+	for i, b := range o.coef {
+		ci[i] = [2]float64{b - t*se[i], b + t*se[i]}
+	}
+	return ci
+}
+
+// Returns the coefficient of determination, 1 - SSE/SST.
+func (o *OLS) RSquare() float64 {
+	return 1 - o.sse/o.sst
+}
+
+// Returns the r-square adjusted for the number of coefficients,
+// 1 - (1 - r^2) * (n - i) / (n - p), where i is 1 with an intercept
+// and 0 otherwise.
+func (o *OLS) AdjustedRSquare() float64 {
+	return 1 - (1-o.RSquare())*float64(o.n-o.dfIntercept())/float64(o.n-o.p)
+}
+
+func (o *OLS) dfIntercept() int {
+	if o.intercept {
+		return 1
+	}
+	return 0
+}
+
+// Returns the F statistic of the hypothesis that all the coefficients but
+// the intercept are zero, with its numerator and denominator degrees of
+// freedom. The statistic is NaN for a model with only an intercept.
+func (o *OLS) FStatistic() (f float64, df1, df2 int) {
+	df1 = o.p - o.dfIntercept()
+	df2 = o.n - o.p
+	if df1 == 0 {
+		return math.NaN(), df1, df2
+	}
+	f = (o.RegressionSumSquares() / float64(df1)) / o.MeanSquareError()
+	return
+}
+
+// Returns the p-value of the F statistic.
+func (o *OLS) FPValue() float64 {
+	f, df1, df2 := o.FStatistic()
+	if math.IsNaN(f) {
+		return math.NaN()
+	}
+	return fisherFSurvival(f, float64(df1), float64(df2))
+}
+
+// Returns the maximized log-likelihood of the model with normal errors,
+// -n/2 * (log(2*pi) + log(SSE/n) + 1).
+func (o *OLS) LogLikelihood() float64 {
+	n := float64(o.n)
+	return -n / 2 * (math.Log(2*math.Pi) + math.Log(o.sse/n) + 1)
+}
+
+// Returns Akaike's information criterion, -2*logL + 2*(p+1).
+// As in R, the error variance counts as a parameter.
+func (o *OLS) AIC() float64 {
+	return -2*o.LogLikelihood() + 2*float64(o.p+1)
+}
+
+// Returns the Bayesian information criterion, -2*logL + log(n)*(p+1).
+func (o *OLS) BIC() float64 {
+	return -2*o.LogLikelihood() + math.Log(float64(o.n))*float64(o.p+1)
+}
+
+// Returns the fitted values of the observations.
+func (o *OLS) Fitted() []float64 {
+	return append([]float64(nil), o.fitted...)
+}
+
+// Returns the residuals y - fitted of the observations.
+func (o *OLS) Residuals() []float64 {
+	return append([]float64(nil), o.residuals...)
+}
+
+// Returns the predicted y value for the independent variables x,
+// without the intercept term.
+func (o *OLS) Predict(x []float64) float64 {
+	b := o.coef
+	y := 0.0
+	if o.intercept {
+		y = b[0]
+		b = b[1:]
+	}
+	if len(x) != len(b) {
+		panic("regression: wrong number of independent variables")
+	}
+	for i, v := range x {
+		y += b[i] * v
+	}
+	return y
+}
+
+// Returns a summary of the fit in the layout of R's summary.lm: quartiles of
+// the residuals, the table of coefficients with significance codes, and the
+// overall statistics. names labels the independent variables; if it is nil
+// they are called x1, x2, ...
+func (o *OLS) Summary(names []string) string {
+	k := o.p - o.dfIntercept()
+	if names == nil {
+		names = make([]string, k)
+		for i := range names {
+			names[i] = fmt.Sprintf("x%d", i+1)
+		}
+	}
+	if len(names) != k {
+		panic("regression: wrong number of names")
+	}
+	if o.intercept {
+		names = append([]string{"(Intercept)"}, names...)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("Residuals:\n")
+	sorted := append([]float64(nil), o.residuals...)
+	sort.Float64s(sorted)
+	header := []string{"Min", "1Q", "Median", "3Q", "Max"}
+	var qs []string
+	for _, p := range []float64{0, 0.25, 0.5, 0.75, 1} {
+		qs = append(qs, fmt.Sprintf("%.4g", quantile(sorted, p)))
+	}
+	widths := columnWidths([][]string{header, qs})
+	writeRow(&buf, "", header, widths)
+	writeRow(&buf, "", qs, widths)
+
+	buf.WriteString("\nCoefficients:\n")
+	se, t, pv := o.StdErrors(), o.TValues(), o.PValues()
+	cols := [][]string{{"Estimate"}, {"Std. Error"}, {"t value"}, {"Pr(>|t|)"}}
+	for i := range o.coef {
+		cols[0] = append(cols[0], fmt.Sprintf("%.4g", o.coef[i]))
+		cols[1] = append(cols[1], fmt.Sprintf("%.4g", se[i]))
+		cols[2] = append(cols[2], fmt.Sprintf("%.3f", t[i]))
+		cols[3] = append(cols[3], formatPValue(pv[i]))
+	}
+	rows := make([][]string, o.p+1)
+	for r := range rows {
+		for _, col := range cols {
+			rows[r] = append(rows[r], col[r])
+		}
+	}
+	widths = columnWidths(rows)
+	width := 0
+	for _, name := range names {
+		if len(name) > width {
+			width = len(name)
+		}
+	}
+	for r := 0; r <= o.p; r++ {
+		label, code := "", signifCode(math.NaN())
+		if r > 0 {
+			label, code = names[r-1], signifCode(pv[r-1])
+		}
+		writeRow(&buf, fmt.Sprintf("%-*s", width, label), rows[r], widths)
+		buf.Truncate(buf.Len() - 1)
+		fmt.Fprintf(&buf, " %-3s\n", code)
+	}
+	buf.WriteString("---\nSignif. codes:  0 '***' 0.001 '**' 0.01 '*' 0.05 '.' 0.1 ' ' 1\n\n")
+
+	fmt.Fprintf(&buf, "Residual standard error: %.4g on %d degrees of freedom\n",
+		math.Sqrt(o.MeanSquareError()), o.n-o.p)
+	fmt.Fprintf(&buf, "Multiple R-squared:  %.4g,\tAdjusted R-squared:  %.4g\n",
+		o.RSquare(), o.AdjustedRSquare())
+	if f, df1, df2 := o.FStatistic(); !math.IsNaN(f) {
+		fmt.Fprintf(&buf, "F-statistic: %.4g on %d and %d DF,  p-value: %s\n",
+			f, df1, df2, formatPValue(o.FPValue()))
+	}
+	fmt.Fprintf(&buf, "AIC: %.6g,\tBIC: %.6g\n", o.AIC(), o.BIC())
+	return buf.String()
+}
+
+// writeRow writes the label followed by the fields right aligned
+// in columns of the given widths.
+func writeRow(buf *bytes.Buffer, label string, fields []string, widths []int) {
+	buf.WriteString(label)
+	for i, f := range fields {
+		fmt.Fprintf(buf, " %*s", widths[i], f)
+	}
+	buf.WriteString("\n")
+}
+
+// columnWidths returns the width of the longest field in each column
+// of the given rows.
+func columnWidths(rows [][]string) []int {
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, f := range row {
+			if len(f) > widths[i] {
+				widths[i] = len(f)
+			}
+		}
+	}
+	return widths
+}
+
+func formatPValue(p float64) string {
+	if p < 2.2e-16 {
+		return "<2e-16"
+	}
+	return fmt.Sprintf("%.3g", p)
+}
+
+func signifCode(p float64) string {
+	switch {
+	case p < 0.001:
+		return "***"
+	case p < 0.01:
+		return "**"
+	case p < 0.05:
+		return "*"
+	case p < 0.1:
+		return "."
+	}
+	return ""
+}
+
+// quantile returns the p-quantile of the sorted values, interpolating
+// linearly between order statistics (R's default type 7).
+func quantile(sorted []float64, p float64) float64 {
+	h := p * float64(len(sorted)-1)
+	lo := int(math.Floor(h))
+	if lo+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[lo] + (h-float64(lo))*(sorted[lo+1]-sorted[lo])
+}
@@ -0,0 +1,193 @@
+package regression
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+/*
+ * NIST "Longley" reference data set from
+ * http://www.itl.nist.gov/div898/strd/lls/data/LINKS/DATA/Longley.dat
+ * Order is {y, x1, ..., x6}
+ */
+var longleyData = [][]float64{
+	{60323, 83.0, 234289, 2356, 1590, 107608, 1947},
+	{61122, 88.5, 259426, 2325, 1456, 108632, 1948},
+	{60171, 88.2, 258054, 3682, 1616, 109773, 1949},
+	{61187, 89.5, 284599, 3351, 1650, 110929, 1950},
+	{63221, 96.2, 328975, 2099, 3099, 112075, 1951},
+	{63639, 98.1, 346999, 1932, 3594, 113270, 1952},
+	{64989, 99.0, 365385, 1870, 3547, 115094, 1953},
+	{63761, 100.0, 363112, 3578, 3350, 116219, 1954},
+	{66019, 101.2, 397469, 2904, 3048, 117388, 1955},
+	{67857, 104.6, 419180, 2822, 2857, 118734, 1956},
+	{68169, 108.4, 442769, 2936, 2798, 120445, 1957},
+	{66513, 110.8, 444546, 4681, 2637, 121950, 1958},
+	{68655, 112.6, 482704, 3813, 2552, 123366, 1959},
+	{69564, 114.2, 502601, 3931, 2514, 125368, 1960},
+	{69331, 115.7, 518173, 4806, 2572, 127852, 1961},
+	{70551, 116.9, 554894, 4007, 2827, 130081, 1962},
+}
+
+func splitXY(data [][]float64) (x [][]float64, y []float64) {
+	for _, row := range data {
+		y = append(y, row[0])
+		x = append(x, row[1:])
+	}
+	return
+}
+
+func relErr(actual, expected float64) float64 {
+	return math.Abs(actual-expected) / math.Abs(expected)
+}
+
+func TestOLSLongley(t *testing.T) {
+	x, y := splitXY(longleyData)
+	o, err := NewOLS(x, y, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// certified values
+	coef := []float64{-3482258.63459582, 15.0618722713733, -0.358191792925910e-01,
+		-2.02022980381683, -1.03322686717359, -0.511041056535807e-01, 1829.15146461355}
+	se := []float64{890420.383607373, 84.9149257747669, 0.334910077722432e-01,
+		0.488399681651699, 0.214274163161675, 0.226073200069370, 455.478499142212}
+	b, s := o.Coefficients(), o.StdErrors()
+	for i := range coef {
+		if relErr(b[i], coef[i]) > 1e-8 {
+			t.Errorf("coefficient %d: %g, expected %g", i, b[i], coef[i])
+		}
+		if relErr(s[i], se[i]) > 1e-8 {
+			t.Errorf("standard error %d: %g, expected %g", i, s[i], se[i])
+		}
+	}
+	if v := math.Sqrt(o.MeanSquareError()); relErr(v, 304.854073561965) > 1e-9 {
+		t.Errorf("residual sd: %g", v)
+	}
+	if v := o.RSquare(); relErr(v, 0.995479004577296) > 1e-12 {
+		t.Errorf("r-square: %g", v)
+	}
+	if v := o.RegressionSumSquares(); relErr(v, 184172401.944494) > 1e-9 {
+		t.Errorf("regression sum of squares: %g", v)
+	}
+	if v := o.SumSquaredErrors(); relErr(v, 836424.055505915) > 1e-9 {
+		t.Errorf("sum of squared errors: %g", v)
+	}
+	f, df1, df2 := o.FStatistic()
+	if relErr(f, 330.285339234588) > 1e-8 || df1 != 6 || df2 != 9 {
+		t.Errorf("F statistic: %g on %d and %d", f, df1, df2)
+	}
+
+	// values from R's lm
+	if v := o.AdjustedRSquare(); math.Abs(v-0.992465007628826) > 1e-12 {
+		t.Errorf("adjusted r-square: %g", v)
+	}
+	if v := o.AIC(); math.Abs(v-235.2348696) > 1e-6 {
+		t.Errorf("AIC: %g", v)
+	}
+	if v := o.BIC(); math.Abs(v-241.4155794) > 1e-6 {
+		t.Errorf("BIC: %g", v)
+	}
+	pv := o.PValues()
+	if math.Abs(pv[0]-0.003560) > 1e-6 || math.Abs(pv[1]-0.863141) > 1e-6 {
+		t.Errorf("p-values: %v", pv)
+	}
+
+	// the residuals and fitted values add up to y and the residuals are
+	// orthogonal to the fitted values
+	res, fit := o.Residuals(), o.Fitted()
+	dot := 0.0
+	for i := range y {
+		if math.Abs(res[i]+fit[i]-y[i]) > 1e-8 {
+			t.Errorf("residual %d: %g + %g != %g", i, res[i], fit[i], y[i])
+		}
+		if p := o.Predict(x[i]); math.Abs(p-fit[i]) > 1e-6 {
+			t.Errorf("prediction %d: %g, expected %g", i, p, fit[i])
+		}
+		dot += res[i] * fit[i]
+	}
+	if math.Abs(dot) > 1e-3 {
+		t.Errorf("residuals are not orthogonal to the fit: %g", dot)
+	}
+
+	ci := o.ConfidenceIntervals(0.05)
+	tq := 2.262157162798205 // qt(0.975, 9)
+	for i := range ci {
+		if relErr(ci[i][1]-b[i], tq*s[i]) > 1e-10 || relErr(b[i]-ci[i][0], tq*s[i]) > 1e-10 {
+			t.Errorf("confidence interval %d: %v", i, ci[i])
+		}
+	}
+
+	summary := o.Summary([]string{"GNP.deflator", "GNP", "Unemployed", "Armed.Forces", "Population", "Year"})
+	for _, want := range []string{"(Intercept)", "Armed.Forces", "Signif. codes",
+		"Residual standard error: 304.9 on 9 degrees of freedom", "F-statistic: 330.3 on 6 and 9 DF"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("summary does not contain %q:\n%s", want, summary)
+		}
+	}
+}
+
+func TestOLSSimple(t *testing.T) {
+	x, y := splitXY(norrisData)
+	o, err := NewOLS(x, y, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewSimple()
+	for _, d := range norrisData {
+		s.Add(d[1], d[0])
+	}
+	b, se := o.Coefficients(), o.StdErrors()
+	if math.Abs(b[0]-s.Intercept()) > TOLERANCE9 || math.Abs(b[1]-s.Slope()) > TOLERANCE9 {
+		t.Errorf("coefficients %v, expected %g and %g", b, s.Intercept(), s.Slope())
+	}
+	if math.Abs(se[0]-s.InterceptStdErr()) > TOLERANCE9 || math.Abs(se[1]-s.SlopeStdErr()) > TOLERANCE9 {
+		t.Errorf("standard errors %v, expected %g and %g", se, s.InterceptStdErr(), s.SlopeStdErr())
+	}
+	if math.Abs(o.RSquare()-s.RSquare()) > TOLERANCE9 {
+		t.Errorf("r-square %g, expected %g", o.RSquare(), s.RSquare())
+	}
+}
+
+func TestOLSNoIntercept(t *testing.T) {
+	// y = 2x exactly plus small noise; R: lm(y ~ x - 1)
+	x := [][]float64{{1}, {2}, {3}, {4}, {5}}
+	y := []float64{2.1, 3.9, 6.2, 7.8, 10.1}
+	o, err := NewOLS(x, y, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sxy, sxx, syy float64
+	for i, row := range x {
+		sxy += row[0] * y[i]
+		sxx += row[0] * row[0]
+		syy += y[i] * y[i]
+	}
+	b := sxy / sxx
+	if math.Abs(o.Coefficients()[0]-b) > 1e-14 {
+		t.Errorf("slope %g, expected %g", o.Coefficients()[0], b)
+	}
+	// uncentered total sum of squares
+	if math.Abs(o.TotalSumSquares()-syy) > 1e-12 {
+		t.Errorf("total sum of squares %g, expected %g", o.TotalSumSquares(), syy)
+	}
+	if _, df1, df2 := o.FStatistic(); df1 != 1 || df2 != 4 {
+		t.Errorf("F degrees of freedom %d and %d", df1, df2)
+	}
+	if o.Summary(nil) == "" || !strings.Contains(o.Summary(nil), "x1") {
+		t.Errorf("summary without names:\n%s", o.Summary(nil))
+	}
+}
+
+func TestOLSRankDeficient(t *testing.T) {
+	x := [][]float64{{1, 2}, {2, 4}, {3, 6}, {4, 8}}
+	y := []float64{1, 2, 3, 5}
+	if _, err := NewOLS(x, y, true); err == nil {
+		t.Error("expected an error for collinear columns")
+	}
+	if _, err := NewOLS(x[:2], y[:2], true); err == nil {
+		t.Error("expected an error for too few observations")
+	}
+}
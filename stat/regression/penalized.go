@@ -0,0 +1,425 @@
+package regression
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// A Path holds the coefficients of a penalized linear regression
+//
+// y = intercept + b1 * x1 + ... + bk * xk
+//
+// for a decreasing sequence of penalties, on the scale of the original data.
+type Path struct {
+	Lambda    []float64   // penalties, in decreasing order
+	Intercept []float64   // Intercept[i] is the intercept at Lambda[i]
+	Coef      [][]float64 // Coef[i] holds the coefficients at Lambda[i]
+	Converged []bool      // Converged[i] is false if the fit at Lambda[i] stopped at the iteration limit
+}
+
+// Predict returns the prediction for x of the model at Lambda[i].
+func (p *Path) Predict(i int, x []float64) float64 {
+	b := p.Coef[i]
+	if len(x) != len(b) {
+		panic("regression: wrong number of independent variables")
+	}
+	y := p.Intercept[i]
+	for j, v := range x {
+		y += b[j] * v
+	}
+	return y
+}
+
+// NonZero returns the number of non-zero coefficients at Lambda[i],
+// the degrees of freedom of a lasso fit.
+func (p *Path) NonZero(i int) int {
+	k := 0
+	for _, b := range p.Coef[i] {
+		if b != 0 {
+			k++
+		}
+	}
+	return k
+}
+
+// A PathFitter fits a penalized regression for a sequence of penalties.
+// If lambdas is nil, a default sequence is chosen from the data.
+type PathFitter interface {
+	Path(x [][]float64, y []float64, lambdas []float64) *Path
+}
+
+// Ridge estimates ridge regressions, which minimize
+//
+// 1/(2n) * sum (y - intercept - x*b)^2 + lambda/2 * sum b^2,
+//
+// with the scaling of glmnet. The intercept is not penalized.
+// The solutions for all penalties come from a single singular value
+// decomposition of the centered design, and are unique even when there are
+// more variables than observations.
+type Ridge struct {
+	// Standardize scales the variables to unit variance before fitting.
+	// The coefficients are always returned on the original scale.
+	Standardize bool
+}
+
+// Path returns the ridge coefficients for each penalty in lambdas.
+// The default sequence has 100 penalties from the one that shrinks the
+// coefficients almost to zero down to a small fraction of it.
+func (r Ridge) Path(x [][]float64, y []float64, lambdas []float64) *Path {
+	d := newCentered(x, y, r.Standardize)
+	if lambdas == nil {
+		// as glmnet, the largest penalty of a lasso with alpha = 0.001
+		lambdas = d.defaultLambdas(0.001)
+	}
+	lambdas = sortedLambdas(lambdas)
+
+	u, s, v := svd(d.x)
+	uty := make([]float64, len(s))
+	for k := range s {
+		for i, yi := range d.y {
+			uty[k] += u[i][k] * yi
+		}
+	}
+	n := float64(d.n)
+	path := &Path{Lambda: lambdas}
+	for _, lambda := range lambdas {
+		b := make([]float64, d.p)
+		for k, sk := range s {
+			if sk <= 1e-12*s[0] {
+				continue
+			}
+			f := sk / (sk*sk + n*lambda) * uty[k]
+			for j := range b {
+				b[j] += v[j][k] * f
+			}
+		}
+		path.add(d, b, true)
+	}
+	return path
+}
+
+// ElasticNet estimates elastic net regressions, which minimize
+//
+// 1/(2n) * sum (y - intercept - x*b)^2 + lambda * ((1-alpha)/2 * sum b^2 + alpha * sum |b|),
+//
+// with the scaling of glmnet: alpha = 1 is the lasso and alpha = 0 is ridge
+// regression. The intercept is not penalized.
+//
+// The coefficients are computed by cyclic coordinate descent over an active
+// set (Friedman, Hastie and Tibshirani, 2010), starting each penalty from the
+// solution of the previous, larger one.
+type ElasticNet struct {
+	Alpha float64 // mixing of the l1 and l2 penalties, in [0, 1]
+	// Standardize scales the variables to unit variance before fitting.
+	// The coefficients are always returned on the original scale.
+	Standardize bool
+	// Tolerance on the largest change of the objective in a pass,
+	// relative to the variance of y. The default is 1e-7.
+	Tolerance float64
+	// MaxIter limits the number of passes over the variables per penalty.
+	// The default is 100000. A penalty that reaches it is marked as not
+	// converged in the Path.
+	MaxIter int
+}
+
+// NewLasso returns the lasso, an ElasticNet with alpha = 1,
+// on standardized variables.
+func NewLasso() ElasticNet {
+	return ElasticNet{Alpha: 1, Standardize: true}
+}
+
+// Path returns the coefficients for each penalty in lambdas.
+// The default sequence has 100 penalties, log-spaced from the smallest one
+// for which all the coefficients are zero down to 1e-4 times it,
+// or 1e-2 times it when there are more variables than observations.
+func (e ElasticNet) Path(x [][]float64, y []float64, lambdas []float64) *Path {
+	if e.Alpha < 0 || e.Alpha > 1 {
+		panic("regression: alpha must be in [0, 1]")
+	}
+	tol := e.Tolerance
+	if tol == 0 {
+		tol = 1e-7
+	}
+	maxIter := e.MaxIter
+	if maxIter == 0 {
+		maxIter = 100000
+	}
+
+	d := newCentered(x, y, e.Standardize)
+	if lambdas == nil {
+		lambdas = d.defaultLambdas(math.Max(e.Alpha, 0.001))
+	}
+	lambdas = sortedLambdas(lambdas)
+
+	n := float64(d.n)
+	// vars[j] is 1/n * sum of squares of centered column j
+	vars := make([]float64, d.p)
+	cols := transpose(d.x)
+	for j, col := range cols {
+		for _, v := range col {
+			vars[j] += v * v
+		}
+		vars[j] /= n
+	}
+	nullDev := 0.0
+	for _, v := range d.y {
+		nullDev += v * v
+	}
+	nullDev /= n
+
+	b := make([]float64, d.p)
+	r := append([]float64(nil), d.y...)
+	active := make([]bool, d.p)
+	// update runs one pass of coordinate descent over the active variables,
+	// or over all of them, and returns the largest weighted squared change
+	// of a coefficient.
+	update := func(lambda float64, all bool) float64 {
+		l1, l2 := lambda*e.Alpha, lambda*(1-e.Alpha)
+		maxChange := 0.0
+		for j, col := range cols {
+			if !all && !active[j] || vars[j] == 0 {
+				continue
+			}
+			z := vars[j] * b[j]
+			for i, v := range col {
+				z += v * r[i] / n
+			}
+			bj := softThreshold(z, l1) / (vars[j] + l2)
+			if delta := bj - b[j]; delta != 0 {
+				for i, v := range col {
+					r[i] -= v * delta
+				}
+				b[j] = bj
+				maxChange = math.Max(maxChange, vars[j]*delta*delta)
+				if bj != 0 {
+					active[j] = true
+				}
+			}
+		}
+		return maxChange
+	}
+
+	path := &Path{Lambda: lambdas}
+	for _, lambda := range lambdas {
+		converged := false
+		for iter := 0; iter < maxIter; iter++ {
+			if update(lambda, true) < tol*nullDev {
+				converged = true
+				break
+			}
+			// iterate on the active set to convergence,
+			// then check all the variables again
+			for ; iter < maxIter; iter++ {
+				if update(lambda, false) < tol*nullDev {
+					break
+				}
+			}
+		}
+		path.add(d, append([]float64(nil), b...), converged)
+	}
+	return path
+}
+
+func softThreshold(z, gamma float64) float64 {
+	switch {
+	case z > gamma:
+		return z - gamma
+	case z < -gamma:
+		return z + gamma
+	}
+	return 0
+}
+
+// centered holds a design and response centered on their means,
+// with the columns optionally scaled to unit variance.
+type centered struct {
+	n, p  int
+	x     [][]float64
+	y     []float64
+	xbar  []float64
+	scale []float64
+	ybar  float64
+}
+
+func newCentered(x [][]float64, y []float64, standardize bool) *centered {
+	n := len(y)
+	if len(x) != n {
+		panic("regression: x and y have different numbers of observations")
+	}
+	if n < 2 {
+		panic("regression: at least two observations are needed")
+	}
+	p := len(x[0])
+	d := &centered{n: n, p: p, xbar: make([]float64, p), scale: make([]float64, p)}
+	for _, row := range x {
+		if len(row) != p {
+			panic("regression: rows of x have different lengths")
+		}
+		for j, v := range row {
+			d.xbar[j] += v
+		}
+	}
+	for j := range d.xbar {
+		d.xbar[j] /= float64(n)
+	}
+	d.x = make([][]float64, n)
+	for i, row := range x {
+		d.x[i] = make([]float64, p)
+		for j, v := range row {
+			d.x[i][j] = v - d.xbar[j]
+			d.scale[j] += d.x[i][j] * d.x[i][j]
+		}
+	}
+	for j := range d.scale {
+		d.scale[j] = math.Sqrt(d.scale[j] / float64(n))
+		if !standardize || d.scale[j] == 0 {
+			d.scale[j] = 1
+		}
+	}
+	if standardize {
+		for _, row := range d.x {
+			for j := range row {
+				row[j] /= d.scale[j]
+			}
+		}
+	}
+	for _, v := range y {
+		d.ybar += v
+	}
+	d.ybar /= float64(n)
+	d.y = make([]float64, n)
+	for i, v := range y {
+		d.y[i] = v - d.ybar
+	}
+	return d
+}
+
+// defaultLambdas returns 100 log-spaced penalties from the smallest one
+// that zeroes all the coefficients of an elastic net with the given alpha.
+func (d *centered) defaultLambdas(alpha float64) []float64 {
+	max := 0.0
+	for j := 0; j < d.p; j++ {
+		z := 0.0
+		for i, row := range d.x {
+			z += row[j] * d.y[i]
+		}
+		max = math.Max(max, math.Abs(z)/float64(d.n))
+	}
+	max /= alpha
+	ratio := 1e-4
+	if d.p > d.n {
+		ratio = 1e-2
+	}
+	const count = 100
+	lambdas := make([]float64, count)
+	for i := range lambdas {
+		lambdas[i] = max * math.Pow(ratio, float64(i)/float64(count-1))
+	}
+	return lambdas
+}
+
+func sortedLambdas(lambdas []float64) []float64 {
+	if len(lambdas) == 0 {
+		panic("regression: no penalties")
+	}
+	s := append([]float64(nil), lambdas...)
+	for _, l := range s {
+		if l < 0 {
+			panic("regression: penalties must be non-negative")
+		}
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(s)))
+	return s
+}
+
+// add appends the coefficients b of the centered problem,
+// transformed back to the original scale.
+func (p *Path) add(d *centered, b []float64, converged bool) {
+	intercept := d.ybar
+	for j := range b {
+		b[j] /= d.scale[j]
+		intercept -= b[j] * d.xbar[j]
+	}
+	p.Intercept = append(p.Intercept, intercept)
+	p.Coef = append(p.Coef, b)
+	p.Converged = append(p.Converged, converged)
+}
+
+// A CrossValidation holds the k-fold cross-validated prediction error of a
+// penalized regression along a path.
+type CrossValidation struct {
+	Path      *Path     // fit on all the data
+	MeanError []float64 // mean squared prediction error at each penalty
+	StdError  []float64 // standard error of MeanError across the folds
+	Best      int       // index of the penalty with the smallest error
+	// OneSE is the index of the largest penalty whose error is within one
+	// standard error of the smallest, which gives a sparser model.
+	OneSE int
+}
+
+// CrossValidate estimates the prediction error of the models fit by f for
+// each penalty in lambdas by k-fold cross-validation. The observations are
+// assigned to the folds at random, using src. If lambdas is nil, the default
+// sequence of f on all the data is used.
+func CrossValidate(f PathFitter, x [][]float64, y []float64, lambdas []float64, k int, src rand.Source) *CrossValidation {
+	n := len(y)
+	if k < 2 || k > n {
+		panic("regression: the number of folds must be in [2, n]")
+	}
+	cv := &CrossValidation{Path: f.Path(x, y, lambdas)}
+	lambdas = cv.Path.Lambda
+	m := len(lambdas)
+
+	perm := rand.New(src).Perm(n)
+	errs := make([][]float64, k) // errs[fold][i] is the mean squared error at lambdas[i]
+	for fold := 0; fold < k; fold++ {
+		var trainX, testX [][]float64
+		var trainY, testY []float64
+		for pos, i := range perm {
+			if pos%k == fold {
+				testX = append(testX, x[i])
+				testY = append(testY, y[i])
+			} else {
+				trainX = append(trainX, x[i])
+				trainY = append(trainY, y[i])
+			}
+		}
+		path := f.Path(trainX, trainY, lambdas)
+		errs[fold] = make([]float64, m)
+		for i := range lambdas {
+			for t, xt := range testX {
+				e := testY[t] - path.Predict(i, xt)
+				errs[fold][i] += e * e
+			}
+			errs[fold][i] /= float64(len(testY))
+		}
+	}
+
+	cv.MeanError = make([]float64, m)
+	cv.StdError = make([]float64, m)
+	for i := range lambdas {
+		mean := 0.0
+		for _, e := range errs {
+			mean += e[i]
+		}
+		mean /= float64(k)
+		ss := 0.0
+		for _, e := range errs {
+			ss += (e[i] - mean) * (e[i] - mean)
+		}
+		cv.MeanError[i] = mean
+		cv.StdError[i] = math.Sqrt(ss / float64(k-1) / float64(k))
+		if mean < cv.MeanError[cv.Best] {
+			cv.Best = i
+		}
+	}
+	limit := cv.MeanError[cv.Best] + cv.StdError[cv.Best]
+	for i := range lambdas {
+		if cv.MeanError[i] <= limit {
+			cv.OneSE = i
+			break
+		}
+	}
+	return cv
+}
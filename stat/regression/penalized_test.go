@@ -0,0 +1,223 @@
+package regression
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestSVD(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, dims := range [][2]int{{6, 3}, {3, 6}, {5, 5}, {1, 4}} {
+		m, n := dims[0], dims[1]
+		a := make([][]float64, m)
+		for i := range a {
+			a[i] = make([]float64, n)
+			for j := range a[i] {
+				a[i][j] = rng.NormFloat64()
+			}
+		}
+		u, s, v := svd(a)
+		for k := 1; k < len(s); k++ {
+			if s[k] > s[k-1] {
+				t.Errorf("%dx%d: singular values are not decreasing: %v", m, n, s)
+			}
+		}
+		for i := 0; i < m; i++ {
+			for j := 0; j < n; j++ {
+				sum := 0.0
+				for k := range s {
+					sum += u[i][k] * s[k] * v[j][k]
+				}
+				if math.Abs(sum-a[i][j]) > 1e-12 {
+					t.Errorf("%dx%d: (USV')[%d][%d] = %g, expected %g", m, n, i, j, sum, a[i][j])
+				}
+			}
+		}
+		// V has orthonormal columns
+		for k := range s {
+			for l := range s {
+				dot := 0.0
+				for j := 0; j < n; j++ {
+					dot += v[j][k] * v[j][l]
+				}
+				expected := 0.0
+				if k == l {
+					expected = 1
+				}
+				if math.Abs(dot-expected) > 1e-12 {
+					t.Errorf("%dx%d: V'V[%d][%d] = %g", m, n, k, l, dot)
+				}
+			}
+		}
+	}
+}
+
+func TestRidge(t *testing.T) {
+	x, y := splitXY(longleyData)
+
+	// without penalty, ridge is least squares
+	ols, err := NewOLS(x, y, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := Ridge{Standardize: true}.Path(x, y, []float64{0})
+	b := ols.Coefficients()
+	if relErr(path.Intercept[0], b[0]) > 1e-6 {
+		t.Errorf("intercept %g, expected %g", path.Intercept[0], b[0])
+	}
+	for j, c := range path.Coef[0] {
+		if relErr(c, b[j+1]) > 1e-6 {
+			t.Errorf("coefficient %d: %g, expected %g", j, c, b[j+1])
+		}
+	}
+
+	// with a penalty, it agrees with coordinate descent
+	lambdas := []float64{10, 1, 0.1}
+	ridge := Ridge{Standardize: true}.Path(x, y, lambdas)
+	cd := ElasticNet{Alpha: 0, Standardize: true, Tolerance: 1e-16}.Path(x, y, lambdas)
+	for i := range lambdas {
+		if relErr(ridge.Intercept[i], cd.Intercept[i]) > 1e-6 {
+			t.Errorf("lambda %g: intercept %g, expected %g", lambdas[i], ridge.Intercept[i], cd.Intercept[i])
+		}
+		for j := range ridge.Coef[i] {
+			if relErr(ridge.Coef[i][j], cd.Coef[i][j]) > 1e-6 {
+				t.Errorf("lambda %g: coefficient %d is %g, expected %g", lambdas[i], j, ridge.Coef[i][j], cd.Coef[i][j])
+			}
+		}
+	}
+
+	// the penalized coefficients shrink
+	norm := func(b []float64) float64 {
+		s := 0.0
+		for j, v := range b {
+			// on the standardized scale
+			v *= standardDeviation(x, j)
+			s += v * v
+		}
+		return s
+	}
+	for i := 1; i < len(lambdas); i++ {
+		if norm(ridge.Coef[i]) < norm(ridge.Coef[i-1]) {
+			t.Errorf("the coefficients grow with the penalty")
+		}
+	}
+}
+
+func standardDeviation(x [][]float64, j int) float64 {
+	mean, ss := 0.0, 0.0
+	for _, row := range x {
+		mean += row[j]
+	}
+	mean /= float64(len(x))
+	for _, row := range x {
+		ss += (row[j] - mean) * (row[j] - mean)
+	}
+	return math.Sqrt(ss / float64(len(x)))
+}
+
+func TestLassoOrthogonal(t *testing.T) {
+	// for centered orthogonal columns with 1/n * x'x = 1, the lasso
+	// soft-thresholds the least squares coefficients
+	x := [][]float64{
+		{1, 1, 1}, {1, 1, -1}, {1, -1, 1}, {1, -1, -1},
+		{-1, 1, 1}, {-1, 1, -1}, {-1, -1, 1}, {-1, -1, -1},
+	}
+	y := []float64{5.1, 3.2, 2.8, 1.2, 0.3, -1.1, -2.2, -3.9}
+	ybar, z := 0.0, make([]float64, 3)
+	for i, row := range x {
+		ybar += y[i] / 8
+		for j, v := range row {
+			z[j] += v * y[i] / 8
+		}
+	}
+	lasso := NewLasso()
+	lambdas := []float64{3, 1, 0.5, 0.1}
+	path := lasso.Path(x, y, lambdas)
+	for i, lambda := range path.Lambda {
+		if math.Abs(path.Intercept[i]-ybar) > 1e-12 {
+			t.Errorf("lambda %g: intercept %g, expected %g", lambda, path.Intercept[i], ybar)
+		}
+		for j := range z {
+			expected := softThreshold(z[j], lambda)
+			if math.Abs(path.Coef[i][j]-expected) > 1e-9 {
+				t.Errorf("lambda %g: coefficient %d is %g, expected %g", lambda, j, path.Coef[i][j], expected)
+			}
+		}
+	}
+	if path.NonZero(0) != 0 {
+		t.Errorf("%d non-zero coefficients at the largest penalty", path.NonZero(0))
+	}
+
+	// the default path starts where all the coefficients vanish
+	path = lasso.Path(x, y, nil)
+	if len(path.Lambda) != 100 || path.NonZero(0) != 0 || path.NonZero(1) == 0 {
+		t.Errorf("default path with %d penalties, %d and %d non-zero coefficients", len(path.Lambda), path.NonZero(0), path.NonZero(1))
+	}
+}
+
+func TestLassoWide(t *testing.T) {
+	// more variables than observations, with a sparse true model
+	rng := rand.New(rand.NewSource(7))
+	n, p := 40, 100
+	x := make([][]float64, n)
+	y := make([]float64, n)
+	for i := range x {
+		x[i] = make([]float64, p)
+		for j := range x[i] {
+			x[i][j] = rng.NormFloat64()
+		}
+		y[i] = 2 + 3*x[i][0] - 2*x[i][1] + 1.5*x[i][2] + 0.5*rng.NormFloat64()
+	}
+	cv := CrossValidate(NewLasso(), x, y, nil, 5, rand.NewSource(1))
+	best := cv.Path.Coef[cv.Best]
+	for j := 0; j < 3; j++ {
+		if best[j] == 0 {
+			t.Errorf("variable %d is not selected", j)
+		}
+	}
+	if math.Abs(best[0]-3) > 0.5 || math.Abs(best[1]+2) > 0.5 {
+		t.Errorf("coefficients %g and %g, expected about 3 and -2", best[0], best[1])
+	}
+	if cv.OneSE > cv.Best {
+		t.Errorf("the one standard error rule picks a smaller penalty %d than the best %d", cv.OneSE, cv.Best)
+	}
+	if cv.Path.NonZero(cv.OneSE) > cv.Path.NonZero(cv.Best) {
+		t.Errorf("the one standard error model is not sparser")
+	}
+	for i, e := range cv.MeanError {
+		if e < cv.MeanError[cv.Best] {
+			t.Errorf("error %g at %d is smaller than the best", e, i)
+		}
+	}
+	for i, c := range cv.Path.Converged {
+		if !c {
+			t.Errorf("lasso not converged at penalty %d", i)
+		}
+	}
+
+	// one pass per penalty does not reach the tolerance on correlated data
+	short := NewLasso()
+	short.MaxIter = 1
+	path := short.Path(x, y, nil)
+	if len(path.Converged) != len(path.Lambda) {
+		t.Fatalf("%d convergence flags for %d penalties", len(path.Converged), len(path.Lambda))
+	}
+	stopped := 0
+	for _, c := range path.Converged {
+		if !c {
+			stopped++
+		}
+	}
+	if stopped == 0 {
+		t.Error("no penalty stopped at the iteration limit")
+	}
+
+	// elastic net and ridge also work with more variables than observations
+	net := CrossValidate(ElasticNet{Alpha: 0.5, Standardize: true}, x, y, nil, 5, rand.NewSource(1))
+	ridge := CrossValidate(Ridge{Standardize: true}, x, y, nil, 5, rand.NewSource(1))
+	if net.MeanError[net.Best] >= net.MeanError[0]/4 || ridge.MeanError[ridge.Best] >= ridge.MeanError[0] {
+		t.Errorf("elastic net error %g of %g, ridge error %g of %g", net.MeanError[net.Best], net.MeanError[0],
+			ridge.MeanError[ridge.Best], ridge.MeanError[0])
+	}
+}
@@ -0,0 +1,210 @@
+package regression
+
+import (
+	"math"
+)
+
+// Estimates a polynomial regression model of degree k
+//
+// y = b0 + b1 * x + ... + bk * x^k
+//
+// by least squares on the orthogonal polynomials of x, as R's poly:
+// the polynomials P_0 = 1, P_1 = x - a_0 and
+//
+// P_{j+1}(x) = (x - a_j) * P_j(x) - (|P_j|^2 / |P_{j-1}|^2) * P_{j-1}(x),
+//
+// with a_j the mean of x weighted by P_j^2, are orthogonal at the
+// observations, so that each coefficient is a single inner product and the
+// fit stays accurate for degrees at which the powers of x are nearly
+// collinear.
+type Polynomial struct {
+	x, y   []float64
+	alpha  []float64 // a_j of the recurrence
+	norm2  []float64 // |P_j|^2 at the observations
+	coef   []float64 // coefficients of P_j / |P_j|, coef[0] the mean of y
+	fitted []float64
+}
+
+// NewPolynomial fits a polynomial of the given degree to the observations.
+// An Error is returned if x has no more than degree distinct values.
+func NewPolynomial(x, y []float64, degree int) (*Polynomial, error) {
+	if len(x) != len(y) {
+		panic("regression: x and y have different lengths")
+	}
+	if degree < 0 {
+		panic("regression: degree must be non-negative")
+	}
+	n := len(x)
+	if n == 0 {
+		panic("regression: no observations")
+	}
+	distinct := make(map[float64]bool)
+	for _, v := range x {
+		distinct[v] = true
+	}
+	if len(distinct) <= degree {
+		return nil, Error{Message: "regression: degree must be less than the number of distinct x values"}
+	}
+
+	p := &Polynomial{x: x, y: y}
+	p.alpha = make([]float64, degree)
+	p.norm2 = make([]float64, degree+1)
+	p.coef = make([]float64, degree+1)
+	p.fitted = make([]float64, n)
+	prev := make([]float64, n)
+	cur := make([]float64, n)
+	for i := range cur {
+		cur[i] = 1
+	}
+	for j := 0; j <= degree; j++ {
+		var nrm, xp, yp float64
+		for i, v := range cur {
+			nrm += v * v
+			xp += x[i] * v * v
+			yp += y[i] * v
+		}
+		p.norm2[j] = nrm
+		c := yp / math.Sqrt(nrm)
+		p.coef[j] = c
+		for i, v := range cur {
+			p.fitted[i] += c * v / math.Sqrt(nrm)
+		}
+		if j == degree {
+			break
+		}
+		p.alpha[j] = xp / nrm
+		beta := 0.0
+		if j > 0 {
+			beta = nrm / p.norm2[j-1]
+		}
+		for i, v := range cur {
+			prev[i], cur[i] = v, (x[i]-p.alpha[j])*v-beta*prev[i]
+		}
+	}
+	p.coef[0] /= math.Sqrt(float64(n)) // the mean of y
+	return p, nil
+}
+
+// basis returns the orthogonal polynomials at x, P_j(x) / |P_j|,
+// with the constant term 1.
+func (p *Polynomial) basis(x float64) []float64 {
+	b := make([]float64, len(p.norm2))
+	prev, cur := 0.0, 1.0
+	b[0] = 1
+	for j := range p.alpha {
+		beta := 0.0
+		if j > 0 {
+			beta = p.norm2[j] / p.norm2[j-1]
+		}
+		prev, cur = cur, (x-p.alpha[j])*cur-beta*prev
+		b[j+1] = cur / math.Sqrt(p.norm2[j+1])
+	}
+	return b
+}
+
+// Returns the number of observations.
+func (p *Polynomial) N() int {
+	return len(p.x)
+}
+
+// Returns the degree of the polynomial.
+func (p *Polynomial) Degree() int {
+	return len(p.alpha)
+}
+
+// Returns the coefficients on the orthogonal polynomials normalized to
+// unit length at the observations, starting with the mean of y. They are the
+// coefficients of R's lm(y ~ poly(x, k)). Because the basis is orthonormal,
+// each coefficient has standard error sqrt(MeanSquareError()), and
+// its square is the regression sum of squares of its degree.
+func (p *Polynomial) OrthogonalCoefficients() []float64 {
+	return append([]float64(nil), p.coef...)
+}
+
+// Returns the coefficients b0, ..., bk of the powers of x.
+// They are expanded from the orthogonal polynomials and may lose accuracy
+// for high degrees or x far from zero; prefer Predict for evaluation.
+func (p *Polynomial) Coefficients() []float64 {
+	k := len(p.alpha)
+	b := make([]float64, k+1)
+	b[0] = p.coef[0]
+	prev := make([]float64, k+1)
+	cur := make([]float64, k+1)
+	cur[0] = 1
+	for j := 0; j < k; j++ {
+		beta := 0.0
+		if j > 0 {
+			beta = p.norm2[j] / p.norm2[j-1]
+		}
+		next := make([]float64, k+1)
+		for i := 0; i <= j; i++ {
+			next[i+1] += cur[i]
+			next[i] -= p.alpha[j]*cur[i] + beta*prev[i]
+		}
+		prev, cur = cur, next
+		s := p.coef[j+1] / math.Sqrt(p.norm2[j+1])
+		for i, v := range cur {
+			b[i] += s * v
+		}
+	}
+	return b
+}
+
+// Returns the equivalent degrees of freedom of the fit, the trace of the
+// hat matrix, k + 1.
+func (p *Polynomial) EDF() float64 {
+	return float64(len(p.coef))
+}
+
+// Returns the residual degrees of freedom, n - k - 1.
+func (p *Polynomial) ResidualDF() int {
+	return len(p.x) - len(p.coef)
+}
+
+// Returns the sum of squared residuals.
+func (p *Polynomial) SumSquaredErrors() float64 {
+	sse := 0.0
+	for _, r := range p.Residuals() {
+		sse += r * r
+	}
+	return sse
+}
+
+// Returns the sum of squared residuals divided by the residual degrees of
+// freedom.
+func (p *Polynomial) MeanSquareError() float64 {
+	return p.SumSquaredErrors() / float64(p.ResidualDF())
+}
+
+// Returns the coefficient of determination, the fraction of the total
+// variation of y about its mean explained by the polynomial.
+func (p *Polynomial) RSquare() float64 {
+	ssr := 0.0
+	for _, c := range p.coef[1:] {
+		ssr += c * c
+	}
+	return ssr / (ssr + p.SumSquaredErrors())
+}
+
+// Returns the fitted values of the observations.
+func (p *Polynomial) Fitted() []float64 {
+	return append([]float64(nil), p.fitted...)
+}
+
+// Returns the residuals y - fitted of the observations.
+func (p *Polynomial) Residuals() []float64 {
+	r := make([]float64, len(p.y))
+	for i, v := range p.y {
+		r[i] = v - p.fitted[i]
+	}
+	return r
+}
+
+// Returns the predicted y value at x.
+func (p *Polynomial) Predict(x float64) float64 {
+	y := 0.0
+	for j, b := range p.basis(x) {
+		y += p.coef[j] * b
+	}
+	return y
+}
@@ -0,0 +1,158 @@
+package regression
+
+import (
+	"math"
+)
+
+// qr is the Householder QR decomposition X = Q*R of an m by n matrix
+// with m >= n, stored compactly as in JAMA: the columns of a hold R above
+// the diagonal and the Householder vectors on and below it.
+type qr struct {
+	m, n  int
+	a     [][]float64 // a[j] is column j
+	rdiag []float64
+}
+
+// newQR decomposes the matrix with the given rows. x is not modified.
+func newQR(x [][]float64) *qr {
+	m, n := len(x), len(x[0])
+	q := &qr{m: m, n: n, a: make([][]float64, n), rdiag: make([]float64, n)}
+	for j := range q.a {
+		q.a[j] = make([]float64, m)
+		for i, row := range x {
+			q.a[j][i] = row[j]
+		}
+	}
+	for k, col := range q.a {
+		nrm := 0.0
+		for _, v := range col[k:] {
+			nrm = math.Hypot(nrm, v)
+		}
+		if nrm != 0 {
+			if col[k] < 0 {
+				nrm = -nrm
+			}
+			for i := k; i < m; i++ {
+				col[i] /= nrm
+			}
+			col[k]++
+			for _, cj := range q.a[k+1:] {
+				s := 0.0
+				for i := k; i < m; i++ {
+					s += col[i] * cj[i]
+				}
+				s = -s / col[k]
+				for i := k; i < m; i++ {
+					cj[i] += s * col[i]
+				}
+			}
+		}
+		q.rdiag[k] = -nrm
+	}
+	return q
+}
+
+// fullRank reports whether the columns of X are linearly independent,
+// up to a relative tolerance on the diagonal of R.
+func (q *qr) fullRank() bool {
+	max := 0.0
+	for _, d := range q.rdiag {
+		max = math.Max(max, math.Abs(d))
+	}
+	for _, d := range q.rdiag {
+		if math.Abs(d) <= 1e-10*max {
+			return false
+		}
+	}
+	return true
+}
+
+// qtMul replaces y by Q'y.
+func (q *qr) qtMul(y []float64) {
+	for k, col := range q.a {
+		if col[k] == 0 {
+			continue
+		}
+		s := 0.0
+		for i := k; i < q.m; i++ {
+			s += col[i] * y[i]
+		}
+		s = -s / col[k]
+		for i := k; i < q.m; i++ {
+			y[i] += s * col[i]
+		}
+	}
+}
+
+// qMul replaces y by Qy.
+func (q *qr) qMul(y []float64) {
+	for k := q.n - 1; k >= 0; k-- {
+		col := q.a[k]
+		if col[k] == 0 {
+			continue
+		}
+		s := 0.0
+		for i := k; i < q.m; i++ {
+			s += col[i] * y[i]
+		}
+		s = -s / col[k]
+		for i := k; i < q.m; i++ {
+			y[i] += s * col[i]
+		}
+	}
+}
+
+// r returns R[i][j] for i <= j.
+func (q *qr) r(i, j int) float64 {
+	if i == j {
+		return q.rdiag[i]
+	}
+	return q.a[j][i]
+}
+
+// solve returns the least squares solution b of X*b = y.
+func (q *qr) solve(y []float64) []float64 {
+	qty := append([]float64(nil), y...)
+	q.qtMul(qty)
+	b := qty[:q.n]
+	for i := q.n - 1; i >= 0; i-- {
+		for j := i + 1; j < q.n; j++ {
+			b[i] -= q.r(i, j) * b[j]
+		}
+		b[i] /= q.rdiag[i]
+	}
+	return append([]float64(nil), b...)
+}
+
+// xtxInverse returns (X'X)^-1 = R^-1 * R^-T.
+func (q *qr) xtxInverse() [][]float64 {
+	n := q.n
+	// upper triangular inverse of R
+	ri := make([][]float64, n)
+	for i := range ri {
+		ri[i] = make([]float64, n)
+	}
+	for j := 0; j < n; j++ {
+		ri[j][j] = 1 / q.rdiag[j]
+		for i := j - 1; i >= 0; i-- {
+			s := 0.0
+			for k := i + 1; k <= j; k++ {
+				s += q.r(i, k) * ri[k][j]
+			}
+			ri[i][j] = -s / q.rdiag[i]
+		}
+	}
+	c := make([][]float64, n)
+	for i := range c {
+		c[i] = make([]float64, n)
+		for j := 0; j <= i; j++ {
+			s := 0.0
+			for k := i; k < n; k++ {
+				s += ri[i][k] * ri[j][k]
+			}
+			c[i][j] = s
+			c[j][i] = s
+		}
+	}
+	return c
+}
@@ -0,0 +1,315 @@
+package regression
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// line is a fitted straight line y = intercept + slope * x
+// with the data it was fit on.
+type line struct {
+	x, y             []float64
+	intercept, slope float64
+}
+
+func (l *line) N() int {
+	return len(l.x)
+}
+
+func (l *line) Intercept() float64 {
+	return l.intercept
+}
+
+func (l *line) Slope() float64 {
+	return l.slope
+}
+
+// Returns the predicted y value, intercept + slope * x.
+func (l *line) Predict(x float64) float64 {
+	return l.intercept + l.slope*x
+}
+
+// Returns the residuals y - predict(x) of the observations.
+func (l *line) Residuals() []float64 {
+	r := make([]float64, len(l.x))
+	for i, x := range l.x {
+		r[i] = l.y[i] - l.Predict(x)
+	}
+	return r
+}
+
+// Returns the sum of squared residuals.
+func (l *line) SumSquaredErrors() float64 {
+	sse := 0.0
+	for _, r := range l.Residuals() {
+		sse += r * r
+	}
+	return sse
+}
+
+func checkLineData(x, y []float64) {
+	if len(x) != len(y) {
+		panic("regression: x and y have different lengths")
+	}
+	if len(x) < 2 {
+		panic("regression: at least two observations are needed")
+	}
+}
+
+// Estimates a straight line robustly by M-estimation: the coefficients
+// minimize sum rho(r_i / s) for a loss rho that grows slower than the square,
+// so that outlying observations have little or no influence.
+// They are computed by iteratively reweighted least squares, with the scale s
+// re-estimated at each iteration by the median absolute residual / 0.6745,
+// as in R's MASS::rlm.
+type MEstimate struct {
+	line
+	weights    []float64
+	scale      float64
+	iterations int
+	converged  bool
+}
+
+// NewHuber fits a line with Huber's loss, quadratic for residuals smaller
+// than k scales and linear beyond. k = 1.345 gives 95% efficiency for normal
+// errors and is used if k is 0. The fit starts from least squares.
+func NewHuber(x, y []float64, k float64) *MEstimate {
+	checkLineData(x, y)
+	if k == 0 {
+		k = 1.345
+	}
+	if k < 0 {
+		panic("regression: tuning constant must be positive")
+	}
+	m := &MEstimate{line: line{x: x, y: y}}
+	s := NewSimple()
+	for i, v := range x {
+		s.Add(v, y[i])
+	}
+	m.intercept, m.slope = s.Intercept(), s.Slope()
+	m.irls(func(u float64) float64 {
+		if math.Abs(u) <= k {
+			return 1
+		}
+		return k / math.Abs(u)
+	})
+	return m
+}
+
+// NewTukey fits a line with Tukey's bisquare loss, which ignores residuals
+// larger than c scales. c = 4.685 gives 95% efficiency for normal errors and
+// is used if c is 0. As the loss is not convex, the fit starts from the Huber
+// estimate.
+func NewTukey(x, y []float64, c float64) *MEstimate {
+	if c == 0 {
+		c = 4.685
+	}
+	if c < 0 {
+		panic("regression: tuning constant must be positive")
+	}
+	h := NewHuber(x, y, 0)
+	m := &MEstimate{line: h.line}
+	m.irls(func(u float64) float64 {
+		if math.Abs(u) >= c {
+			return 0
+		}
+		v := 1 - (u/c)*(u/c)
+		return v * v
+	})
+	return m
+}
+
+// irls iterates weighted least squares with the weight function of the
+// scaled residuals from the current line.
+func (m *MEstimate) irls(weight func(u float64) float64) {
+	const maxIter = 100
+	const tol = 1e-10
+	m.weights = make([]float64, m.N())
+	r := m.Residuals()
+	for m.iterations = 1; m.iterations <= maxIter; m.iterations++ {
+		m.scale = mad(r)
+		if m.scale == 0 {
+			// more than half the points are on the line
+			for i, v := range r {
+				m.weights[i] = 0
+				if v == 0 {
+					m.weights[i] = 1
+				}
+			}
+			m.converged = true
+			return
+		}
+		s := NewSimple()
+		for i, v := range r {
+			m.weights[i] = weight(v / m.scale)
+			if m.weights[i] > 0 {
+				s.AddWeighted(m.x[i], m.y[i], m.weights[i])
+			}
+		}
+		if s.XSumSquares() == 0 || math.IsNaN(s.Slope()) {
+			return
+		}
+		m.intercept, m.slope = s.Intercept(), s.Slope()
+
+		old := r
+		r = m.Residuals()
+		var diff, norm float64
+		for i := range r {
+			diff += (r[i] - old[i]) * (r[i] - old[i])
+			norm += old[i] * old[i]
+		}
+		if math.Sqrt(diff) <= tol*math.Max(1e-20, math.Sqrt(norm)) {
+			m.converged = true
+			return
+		}
+	}
+	m.iterations = maxIter
+}
+
+// Returns the robust scale of the residuals at the last iteration.
+func (m *MEstimate) Scale() float64 {
+	return m.scale
+}
+
+// Returns the final weights of the observations, from 1 for observations
+// fit as in least squares down to 0 for ignored ones.
+func (m *MEstimate) Weights() []float64 {
+	return append([]float64(nil), m.weights...)
+}
+
+// Returns the number of iterations used.
+func (m *MEstimate) Iterations() int {
+	return m.iterations
+}
+
+// Returns true if the iterations converged.
+func (m *MEstimate) Converged() bool {
+	return m.converged
+}
+
+// mad returns the median absolute value / 0.6745, a consistent estimate of
+// the standard deviation of normal residuals.
+func mad(r []float64) float64 {
+	a := make([]float64, len(r))
+	for i, v := range r {
+		a[i] = math.Abs(v)
+	}
+	return median(a) / 0.6745
+}
+
+// median returns the median of the values, which are reordered.
+func median(a []float64) float64 {
+	sort.Float64s(a)
+	n := len(a)
+	if n%2 == 1 {
+		return a[n/2]
+	}
+	return (a[n/2-1] + a[n/2]) / 2
+}
+
+// Estimates a straight line by random sample consensus (RANSAC).
+// Lines through random pairs of observations are scored by their number of
+// inliers, the observations within a threshold of the line, and the line
+// with the most inliers is refit to them by least squares, selecting the
+// inliers of the new line until they do not change.
+type RANSAC struct {
+	line
+	inliers    []bool
+	iterations int
+}
+
+// NewRANSAC fits a line to the observations with absolute residuals at most
+// threshold, drawing at most maxIter pairs from src. The number of draws is
+// reduced adaptively once a line is found that, with 99% confidence,
+// no other line has more inliers.
+func NewRANSAC(x, y []float64, threshold float64, maxIter int, src rand.Source) *RANSAC {
+	checkLineData(x, y)
+	if threshold <= 0 {
+		panic("regression: threshold must be positive")
+	}
+	if maxIter < 1 {
+		panic("regression: maxIter must be positive")
+	}
+	rng := rand.New(src)
+	n := len(x)
+	r := &RANSAC{line: line{x: x, y: y}, inliers: make([]bool, n)}
+
+	best, bestSSE := 0, math.Inf(1)
+	needed := maxIter
+	for r.iterations = 0; r.iterations < needed; r.iterations++ {
+		i, j := rng.Intn(n), rng.Intn(n-1)
+		if j >= i {
+			j++
+		}
+		if x[i] == x[j] {
+			continue
+		}
+		slope := (y[j] - y[i]) / (x[j] - x[i])
+		intercept := y[i] - slope*x[i]
+		count, sse := 0, 0.0
+		for k, v := range x {
+			e := y[k] - intercept - slope*v
+			if math.Abs(e) <= threshold {
+				count++
+				sse += e * e
+			}
+		}
+		if count > best || (count == best && sse < bestSSE) {
+			best, bestSSE = count, sse
+			r.intercept, r.slope = intercept, slope
+			// draws needed to sample an all-inlier pair with 99% confidence
+			w := float64(count) / float64(n)
+			if w == 1 {
+				needed = r.iterations + 1
+			} else if k := math.Log(0.01) / math.Log(1-w*w); k < float64(needed) {
+				needed = int(math.Ceil(k))
+			}
+		}
+	}
+	if best == 0 {
+		panic("regression: all the x values are equal")
+	}
+
+	// refit to the inliers until they do not change
+	for iter := 0; iter < 10; iter++ {
+		s := NewSimple()
+		changed := false
+		for k, v := range x {
+			in := math.Abs(y[k]-r.Predict(v)) <= threshold
+			if in != r.inliers[k] {
+				changed = true
+				r.inliers[k] = in
+			}
+			if in {
+				s.Add(v, y[k])
+			}
+		}
+		if !changed || s.XSumSquares() == 0 {
+			break
+		}
+		r.intercept, r.slope = s.Intercept(), s.Slope()
+	}
+	return r
+}
+
+// Returns whether each observation is an inlier of the fitted line.
+func (r *RANSAC) Inliers() []bool {
+	return append([]bool(nil), r.inliers...)
+}
+
+// Returns the number of inliers.
+func (r *RANSAC) NumInliers() int {
+	k := 0
+	for _, in := range r.inliers {
+		if in {
+			k++
+		}
+	}
+	return k
+}
+
+// Returns the number of pairs drawn.
+func (r *RANSAC) Iterations() int {
+	return r.iterations
+}
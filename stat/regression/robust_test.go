@@ -0,0 +1,207 @@
+package regression
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// lineWithOutliers returns points on y = 2 + 0.5x with small noise,
+// and a few gross outliers.
+func lineWithOutliers(rng *rand.Rand, n, outliers int) (x, y []float64) {
+	for i := 0; i < n; i++ {
+		xi := 10 * rng.Float64()
+		yi := 2 + 0.5*xi + 0.1*rng.NormFloat64()
+		if i < outliers {
+			yi += 20 + 10*rng.Float64()
+		}
+		x = append(x, xi)
+		y = append(y, yi)
+	}
+	return
+}
+
+func TestMEstimate(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	x, y := lineWithOutliers(rng, 100, 10)
+	s := NewSimple()
+	for i := range x {
+		s.Add(x[i], y[i])
+	}
+	if math.Abs(s.Intercept()-2) < 1 {
+		t.Fatalf("least squares is not affected by the outliers")
+	}
+	huber := NewHuber(x, y, 0)
+	tukey := NewTukey(x, y, 0)
+	for _, m := range []*MEstimate{huber, tukey} {
+		if !m.Converged() {
+			t.Errorf("not converged after %d iterations", m.Iterations())
+		}
+		if math.Abs(m.Slope()-0.5) > 0.1 || math.Abs(m.Intercept()-2) > 0.5 {
+			t.Errorf("line %g + %g x, expected 2 + 0.5 x", m.Intercept(), m.Slope())
+		}
+		if math.Abs(m.Scale()-0.1) > 0.05 {
+			t.Errorf("scale %g, expected 0.1", m.Scale())
+		}
+		if m.N() != 100 || math.Abs(m.Predict(4)-(m.Intercept()+4*m.Slope())) > 1e-12 {
+			t.Errorf("wrong accessors")
+		}
+	}
+	// the bisquare rejects the outliers completely
+	w := tukey.Weights()
+	for i := 0; i < 10; i++ {
+		if w[i] != 0 {
+			t.Errorf("outlier %d has weight %g", i, w[i])
+		}
+	}
+
+	// without outliers, Huber's estimate is close to least squares
+	x, y = lineWithOutliers(rng, 200, 0)
+	s.Clear()
+	for i := range x {
+		s.Add(x[i], y[i])
+	}
+	huber = NewHuber(x, y, 0)
+	if math.Abs(huber.Slope()-s.Slope()) > 3*s.SlopeStdErr() {
+		t.Errorf("slope %g, least squares %g", huber.Slope(), s.Slope())
+	}
+}
+
+func TestRANSAC(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	x, y := lineWithOutliers(rng, 100, 40)
+	r := NewRANSAC(x, y, 0.3, 1000, rand.NewSource(5))
+	if math.Abs(r.Slope()-0.5) > 0.05 || math.Abs(r.Intercept()-2) > 0.2 {
+		t.Errorf("line %g + %g x, expected 2 + 0.5 x", r.Intercept(), r.Slope())
+	}
+	inliers := r.Inliers()
+	for i := 0; i < 40; i++ {
+		if inliers[i] {
+			t.Errorf("outlier %d is an inlier", i)
+		}
+	}
+	if r.NumInliers() < 55 {
+		t.Errorf("%d inliers", r.NumInliers())
+	}
+	if r.Iterations() >= 1000 {
+		t.Errorf("the number of iterations was not adapted: %d", r.Iterations())
+	}
+	// the same seed gives the same fit
+	again := NewRANSAC(x, y, 0.3, 1000, rand.NewSource(5))
+	if again.Slope() != r.Slope() || again.Iterations() != r.Iterations() {
+		t.Error("the fit is not reproducible")
+	}
+}
+
+func bruteForceSlopes(x, y []float64) []float64 {
+	var slopes []float64
+	for i := range x {
+		for j := i + 1; j < len(x); j++ {
+			if x[i] != x[j] {
+				slopes = append(slopes, (y[j]-y[i])/(x[j]-x[i]))
+			}
+		}
+	}
+	sort.Float64s(slopes)
+	return slopes
+}
+
+func TestTheilSen(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	for _, n := range []int{2, 3, 10, 101, 500, 2000} {
+		for _, ties := range []bool{false, true} {
+			x := make([]float64, n)
+			y := make([]float64, n)
+			for i := range x {
+				if ties {
+					// integer data with many repeated x values and slopes
+					x[i] = float64(rng.Intn(n/3 + 2))
+					y[i] = float64(rng.Intn(20)) + x[i]
+				} else {
+					x[i] = rng.NormFloat64()
+					y[i] = 1 - 2*x[i] + rng.NormFloat64()
+				}
+			}
+			slopes := bruteForceSlopes(x, y)
+			if len(slopes) == 0 {
+				continue
+			}
+			m := len(slopes)
+			expected := slopes[m/2]
+			if m%2 == 0 {
+				expected = (slopes[m/2-1] + slopes[m/2]) / 2
+			}
+			ts := NewTheilSen(x, y)
+			if ts.Slope() != expected {
+				t.Errorf("n=%d ties=%v: slope %g, expected %g", n, ties, ts.Slope(), expected)
+			}
+			for _, k := range []int{0, m / 7, m - 1} {
+				if s := ts.s.selectSlope(int64(k)); s != slopes[k] {
+					t.Errorf("n=%d ties=%v: slope of rank %d is %g, expected %g", n, ties, k, s, slopes[k])
+				}
+			}
+			if n >= 10 {
+				lower, upper := ts.SlopeInterval(0.05)
+				if !(lower <= ts.Slope() && ts.Slope() <= upper) || lower == upper && !ties {
+					t.Errorf("n=%d ties=%v: interval [%g, %g] around %g", n, ties, lower, upper, ts.Slope())
+				}
+			}
+		}
+	}
+
+	// collinear points have a single slope
+	x := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13}
+	y := make([]float64, len(x))
+	for i, v := range x {
+		y[i] = 3 + 0.25*v
+	}
+	ts := NewTheilSen(x, y)
+	if ts.Slope() != 0.25 || ts.Intercept() != 3 {
+		t.Errorf("line %g + %g x, expected 3 + 0.25 x", ts.Intercept(), ts.Slope())
+	}
+
+	// the outliers do not move the line
+	x, y = lineWithOutliers(rng, 200, 40)
+	ts = NewTheilSen(x, y)
+	lower, upper := ts.SlopeInterval(0.05)
+	if math.Abs(ts.Slope()-0.5) > 0.1 || lower > 0.5 || upper < 0.5 {
+		t.Errorf("slope %g in [%g, %g], expected 0.5", ts.Slope(), lower, upper)
+	}
+}
+
+func TestTheilSenInterval(t *testing.T) {
+	// Sen's interval: ranks (N -+ C)/2 of the sorted slopes
+	rng := rand.New(rand.NewSource(4))
+	n := 30
+	x := make([]float64, n)
+	y := make([]float64, n)
+	for i := range x {
+		x[i] = float64(i)
+		y[i] = 0.3*x[i] + rng.NormFloat64()
+	}
+	slopes := bruteForceSlopes(x, y)
+	nf := float64(n)
+	c := 1.959963984540054 * math.Sqrt(nf*(nf-1)*(2*nf+5)/18)
+	m1 := int(math.Floor((float64(len(slopes)) - c) / 2))
+	m2 := int(math.Ceil((float64(len(slopes)) + c) / 2))
+	lower, upper := NewTheilSen(x, y).SlopeInterval(0.05)
+	if lower != slopes[m1-1] || upper != slopes[m2] {
+		t.Errorf("interval [%g, %g], expected [%g, %g]", lower, upper, slopes[m1-1], slopes[m2])
+	}
+}
+
+func BenchmarkTheilSen(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	n := 100000
+	x := make([]float64, n)
+	y := make([]float64, n)
+	for i := range x {
+		x[i] = rng.NormFloat64()
+		y[i] = x[i] + rng.NormFloat64()
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		NewTheilSen(x, y)
+	}
+}
@@ -11,55 +11,103 @@ import (
 //
 // Standard errors for interception and slope are
 // available as well as ANOVA, r-square and Pearson's r statistics.
+//
+// Observations may be weighted, e.g. by their inverse variances,
+// and the intercept may be constrained to be zero
+// (see NewSimpleNoIntercept).
 type Simple struct {
-	sumX  float64 // sum of x values
-	sumXX float64 // total variation in x (sum of squared deviations from xbar)
-	sumY  float64 // sum of y values
-	sumYY float64 // total variation in y (sum of squared deviations from ybar)
-	sumXY float64 // sum of product
-	n     int     // number of observations
-	xbar  float64 // mean of accumulated x values, used in updating formulas
-	ybar  float64 // mean of accumulated y values, used in updating formulas
+	sumX        float64 // sum of weighted x values
+	sumXX       float64 // total variation in x (sum of weighted squared deviations from xbar)
+	sumY        float64 // sum of weighted y values
+	sumYY       float64 // total variation in y (sum of weighted squared deviations from ybar)
+	sumXY       float64 // sum of weighted product
+	sumW        float64 // sum of weights
+	n           int     // number of observations
+	nWeighted   int     // number of observations with a weight other than 1
+	xbar        float64 // weighted mean of accumulated x values, used in updating formulas
+	ybar        float64 // weighted mean of accumulated y values, used in updating formulas
+	noIntercept bool    // whether the model is constrained through the origin
 }
 
 func NewSimple() *Simple {
 	return &Simple{}
 }
 
+// Returns a regression through the origin,
+//
+// y = slope * x
+//
+// In this mode sumXX, sumYY and sumXY are sums of squares and products
+// about zero instead of about the means.
+func NewSimpleNoIntercept() *Simple {
+	return &Simple{noIntercept: true}
+}
+
+// Returns true if the model has an intercept.
+func (s *Simple) HasIntercept() bool {
+	return !s.noIntercept
+}
+
 // Adds the observation (x, y) to the regression data set.
 // x is the independent variable value
 // y is the dependent variable value
 func (s *Simple) Add(x, y float64) {
-	if s.n == 0 {
+	s.AddWeighted(x, y, 1)
+}
+
+// Adds the observation (x, y) with weight w to the regression data set.
+// The weights are precision weights, as in weighted least squares:
+// the variance of y is assumed proportional to 1/w.
+func (s *Simple) AddWeighted(x, y, w float64) {
+	if w <= 0 {
+		panic("regression: weight must be positive")
+	}
+	if w != 1 {
+		s.nWeighted++
+	}
+	if s.noIntercept {
+		s.sumXX += w * x * x
+		s.sumYY += w * y * y
+		s.sumXY += w * x * y
+	} else if s.n == 0 {
 		s.xbar = x
 		s.ybar = y
 	} else {
-		fact1 := 1.0 + float64(s.n)
-		fact2 := float64(s.n) / (1.0 + float64(s.n))
+		fact1 := w / (s.sumW + w)
+		fact2 := w * s.sumW / (s.sumW + w)
 		dx := x - s.xbar
 		dy := y - s.ybar
 		s.sumXX += dx * dx * fact2
 		s.sumYY += dy * dy * fact2
 		s.sumXY += dx * dy * fact2
-		s.xbar += dx / fact1
-		s.ybar += dy / fact1
+		s.xbar += dx * fact1
+		s.ybar += dy * fact1
 	}
-	s.sumX += x
-	s.sumY += y
+	s.sumX += w * x
+	s.sumY += w * y
+	s.sumW += w
 	s.n++
 }
 
 // Appends data from another regression calculation to this one.
+// Both regressions must have the same intercept mode.
 func (s *Simple) Append(reg Simple) {
-	if s.n == 0 {
+	if s.noIntercept != reg.noIntercept {
+		panic("regression: appending regressions with different intercept modes")
+	}
+	if s.noIntercept {
+		s.sumXX += reg.sumXX
+		s.sumYY += reg.sumYY
+		s.sumXY += reg.sumXY
+	} else if s.n == 0 {
 		s.xbar = reg.xbar
 		s.ybar = reg.ybar
 		s.sumXX = reg.sumXX
 		s.sumYY = reg.sumYY
 		s.sumXY = reg.sumXY
-	} else {
-		fact1 := float64(reg.n) / float64(reg.n+s.n)
-		fact2 := float64(s.n*reg.n) / float64(s.n+reg.n)
+	} else if reg.n > 0 {
+		fact1 := reg.sumW / (reg.sumW + s.sumW)
+		fact2 := s.sumW * reg.sumW / (s.sumW + reg.sumW)
 		dx := reg.xbar - s.xbar
 		dy := reg.ybar - s.ybar
 		s.sumXX += reg.sumXX + dx*dx*fact2
@@ -71,7 +119,9 @@ func (s *Simple) Append(reg Simple) {
 
 	s.sumX += reg.sumX
 	s.sumY += reg.sumY
+	s.sumW += reg.sumW
 	s.n += reg.n
+	s.nWeighted += reg.nWeighted
 }
 
 // Removes the observation (x,y) from the regression data set.
@@ -80,38 +130,69 @@ func (s *Simple) Append(reg Simple) {
 // in streaming mode where the regression is applied
 // to a sliding window of observations.
 func (s *Simple) Remove(x, y float64) {
-	if s.n > 0 {
-		fact1 := float64(s.n) - 1.0
-		fact2 := float64(s.n) / (float64(s.n) - 1.0)
+	s.RemoveWeighted(x, y, 1)
+}
+
+// Removes the observation (x, y) that was added with weight w
+// from the regression data set.
+func (s *Simple) RemoveWeighted(x, y, w float64) {
+	if s.n == 0 {
+		return
+	}
+	if s.n == 1 {
+		s.Clear()
+		return
+	}
+	if s.noIntercept {
+		s.sumXX -= w * x * x
+		s.sumYY -= w * y * y
+		s.sumXY -= w * x * y
+	} else {
+		fact1 := w / (s.sumW - w)
+		fact2 := w * s.sumW / (s.sumW - w)
 		dx := x - s.xbar
 		dy := y - s.ybar
 		s.sumXX -= dx * dx * fact2
 		s.sumYY -= dy * dy * fact2
 		s.sumXY -= dx * dy * fact2
-		s.xbar -= dx / fact1
-		s.ybar -= dy / fact1
+		s.xbar -= dx * fact1
+		s.ybar -= dy * fact1
 	}
-	s.sumX -= x
-	s.sumY -= y
+	s.sumX -= w * x
+	s.sumY -= w * y
+	s.sumW -= w
 	s.n--
+	if w != 1 {
+		s.nWeighted--
+	}
 }
 
 // Clear all data from the model.
+// The intercept mode is kept.
 func (s *Simple) Clear() {
 	s.sumX = 0
 	s.sumXX = 0
 	s.sumY = 0
 	s.sumYY = 0
 	s.sumXY = 0
+	s.sumW = 0
 	s.n = 0
+	s.nWeighted = 0
+	s.xbar = 0
+	s.ybar = 0
 }
 
 func (s *Simple) N() int {
 	return s.n
 }
 
+// Returns the intercept of the regression line,
+// which is 0 for a regression through the origin.
 func (s *Simple) Intercept() float64 {
-	return (s.sumY - s.Slope()*s.sumX) / float64(s.n)
+	if s.noIntercept {
+		return 0
+	}
+	return (s.sumY - s.Slope()*s.sumX) / s.sumW
 }
 
 func (s *Simple) Slope() float64 {
@@ -121,6 +202,15 @@ func (s *Simple) Slope() float64 {
 	return s.sumXY / s.sumXX
 }
 
+// residualDF returns the degrees of freedom of the residuals,
+// n-2 with an intercept and n-1 without.
+func (s *Simple) residualDF() int {
+	if s.noIntercept {
+		return s.n - 1
+	}
+	return s.n - 2
+}
+
 // Returns the sum of squared errors (SSE) associated with the regression models.
 // The sum is computed using the computational formula:
 // SSE = SYY - (SXY * SXY / SXX)
@@ -136,7 +226,8 @@ func (s *Simple) SumSquaredErrors() float64 {
 	return math.Max(0, s.sumYY-(s.sumXY*s.sumXY/s.sumXX))
 }
 
-// Returns the sum of squared deviations of the y values about their mean
+// Returns the sum of squared deviations of the y values about their mean,
+// or about zero for a regression through the origin.
 func (s *Simple) TotalSumSquares() float64 {
 	if s.N() < 2 {
 		return math.NaN()
@@ -144,7 +235,8 @@ func (s *Simple) TotalSumSquares() float64 {
 	return s.sumYY
 }
 
-// Returns the sum of squared deviations of the x values about their mean
+// Returns the sum of squared deviations of the x values about their mean,
+// or about zero for a regression through the origin.
 func (s *Simple) XSumSquares() float64 {
 	if s.N() < 2 {
 		return math.NaN()
@@ -158,14 +250,16 @@ func (s *Simple) SumOfCrossProducts() float64 {
 }
 
 // Returns the sum of squared errors divided by the degrees of freedom,
-// usually abbreviated MSE.
-// If there are fewer than three data pairs in the model,
-// or if there is no variation in x, this returns NaN
+// usually abbreviated MSE. The degrees of freedom are n-2,
+// or n-1 for a regression through the origin.
+// If there are no residual degrees of freedom (fewer than three data pairs,
+// or two through the origin), or if there is no variation in x,
+// this returns NaN.
 func (s *Simple) MeanSquareError() float64 {
-	if s.N() < 3 {
+	if s.residualDF() < 1 {
 		return math.NaN()
 	}
-	return s.SumSquaredErrors() / float64(s.N()-2)
+	return s.SumSquaredErrors() / float64(s.residualDF())
 }
 
 // Returns the "predicted" y value associated with
@@ -216,20 +310,20 @@ func (s *Simple) RSquare() float64 {
 
 // Returns the standard error of the intercept estimate,
 // usually denoted s(b0).
-// If there are fewer that <strong>three</strong> observations in the
-// model, or if there is no variation in x, this returns
-// <code>Double.NaN</code>.</p> Additionally, a <code>Double.NaN</code> is
-// returned when the intercept is constrained to be zero.
+// If there are no residual degrees of freedom, or if there is
+// no variation in x, this returns NaN.
+// NaN is also returned when the intercept is constrained to be zero.
 func (s *Simple) InterceptStdErr() float64 {
-	return math.Sqrt(s.MeanSquareError() * ((1.0 / float64(s.N())) + (s.xbar*s.xbar)/s.sumXX))
+	if s.noIntercept {
+		return math.NaN()
+	}
+	return math.Sqrt(s.MeanSquareError() * ((1.0 / s.sumW) + (s.xbar*s.xbar)/s.sumXX))
 }
 
 // Returns the standard error of the slope estimate,
 // usually denoted s(b1).
-// If there are fewer that <strong>three</strong> observations in the
-// model, or if there is no variation in x, this returns
-// <code>Double.NaN</code>.</p> Additionally, a <code>Double.NaN</code> is
-// returned when the intercept is constrained to be zero.
+// If there are no residual degrees of freedom, or if there is
+// no variation in x, this returns NaN.
 func (s *Simple) SlopeStdErr() float64 {
 	return math.Sqrt(s.MeanSquareError() / s.sumXX)
 }
@@ -238,3 +332,84 @@ func (s *Simple) SlopeStdErr() float64 {
 func (s *Simple) RegressionSumSquares() float64 {
 	return s.Slope() * s.Slope() * s.sumXX
 }
+
+// Returns the half-width of a (100-100*alpha)% confidence interval for the
+// slope estimate, usually denoted s(b1) * t(1-alpha/2, n-2).
+// The interval is slope +/- the returned value.
+// For a regression through the origin, t has n-1 degrees of freedom.
+// If there are no residual degrees of freedom, or if there is
+// no variation in x, this returns NaN.
+// alpha must be in (0, 1).
+func (s *Simple) SlopeConfidenceInterval(alpha float64) float64 {
+	if alpha <= 0 || alpha >= 1 {
+		panic("regression: alpha must be in (0, 1)")
+	}
+	if s.residualDF() < 1 {
+		return math.NaN()
+	}
+	return s.SlopeStdErr() * studentTQuantile(1-alpha/2, float64(s.residualDF()))
+}
+
+// Returns the significance level of the slope (equiv) correlation,
+// the two-sided p-value of the t test of the hypothesis that the slope
+// is zero, t = slope / s(b1) with n-2 degrees of freedom
+// (n-1 for a regression through the origin).
+// If there are no residual degrees of freedom, or if there is
+// no variation in x, this returns NaN.
+func (s *Simple) Significance() float64 {
+	if s.residualDF() < 1 {
+		return math.NaN()
+	}
+	return studentTTwoSided(s.Slope()/s.SlopeStdErr(), float64(s.residualDF()))
+}
+
+// Returns the F statistic of the regression, SSR / MSE,
+// with 1 and n-2 (n-1 through the origin) degrees of freedom.
+// It is the square of the t statistic
+// of the slope.
+func (s *Simple) FStatistic() float64 {
+	return s.RegressionSumSquares() / s.MeanSquareError()
+}
+
+// Returns the p-value of the F statistic, which equals Significance.
+func (s *Simple) FSignificance() float64 {
+	if s.residualDF() < 1 {
+		return math.NaN()
+	}
+	return fisherFSurvival(s.FStatistic(), 1, float64(s.residualDF()))
+}
+
+// Returns the (100-100*alpha)% confidence interval of the mean response
+// at x, and the prediction interval of a new observation at x:
+//
+// predict(x) +/- t(1-alpha/2, n-2) * sqrt(MSE * (c + 1/n + (x-xbar)^2/SXX)),
+//
+// with c = 0 for the mean and c = 1 for a new observation.
+// For weighted data, n is the sum of the weights and the new observation has
+// weight 1. For a regression through the origin, the variance of the mean
+// response is MSE * x^2/SXX and t has n-1 degrees of freedom.
+// If there are no residual degrees of freedom, or if there is
+// no variation in x, the intervals are NaN.
+func (s *Simple) PredictInterval(x, alpha float64) (mean, observation [2]float64) {
+	if alpha <= 0 || alpha >= 1 {
+		panic("regression: alpha must be in (0, 1)")
+	}
+	if s.residualDF() < 1 {
+		nan := [2]float64{math.NaN(), math.NaN()}
+		return nan, nan
+	}
+	y := s.Predict(x)
+	t := studentTQuantile(1-alpha/2, float64(s.residualDF()))
+	var v float64
+	if s.noIntercept {
+		v = s.MeanSquareError() * x * x / s.sumXX
+	} else {
+		dx := x - s.xbar
+		v = s.MeanSquareError() * (1/s.sumW + dx*dx/s.sumXX)
+	}
+	h := t * math.Sqrt(v)
+	mean = [2]float64{y - h, y + h}
+	h = t * math.Sqrt(v+s.MeanSquareError())
+	observation = [2]float64{y - h, y + h}
+	return
+}
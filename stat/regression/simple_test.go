@@ -287,3 +287,234 @@ func TestReomve(t *testing.T) {
 		t.Errorf("intercept std err %g does not match the expected value %g, the distance is %g, but the tolerance is %g\n", interceptStdErr, expectedInterceptStdErr, interceptStdErrDistance, 1E-8)
 	}
 }
+
+func TestInference(t *testing.T) {
+	// R-verified values from Apache Commons Math
+	simple := NewSimple()
+	for _, d := range infData {
+		simple.Add(d[0], d[1])
+	}
+	significance := simple.Significance()
+	expectedSignificance := 4.596e-07
+	if math.Abs(significance-expectedSignificance) > 1E-8 {
+		t.Errorf("significance %g does not match the expected value %g\n", significance, expectedSignificance)
+	}
+	halfWidth := simple.SlopeConfidenceInterval(0.05)
+	expectedHalfWidth := 0.0270713794287
+	if math.Abs(halfWidth-expectedHalfWidth) > 1E-8 {
+		t.Errorf("slope confidence interval half-width %g does not match the expected value %g\n", halfWidth, expectedHalfWidth)
+	}
+	if simple.SlopeConfidenceInterval(0.01) <= halfWidth {
+		t.Error("a smaller alpha should give a wider confidence interval")
+	}
+
+	tstat := simple.Slope() / simple.SlopeStdErr()
+	if math.Abs(simple.FStatistic()-tstat*tstat) > 1E-8*tstat*tstat {
+		t.Errorf("F statistic %g is not the square of the t statistic %g\n", simple.FStatistic(), tstat)
+	}
+	if math.Abs(simple.FSignificance()-significance) > 1E-15 {
+		t.Errorf("F significance %g does not match the t significance %g\n", simple.FSignificance(), significance)
+	}
+
+	// intervals agree with the OLS fit, widen away from the mean of x
+	// and the prediction interval contains the confidence interval.
+	n := float64(simple.N())
+	tq := studentTQuantile(0.975, n-2)
+	xbar := 0.0
+	for _, d := range infData {
+		xbar += d[0]
+	}
+	xbar /= n
+	mean, obs := simple.PredictInterval(xbar, 0.05)
+	expected := tq * math.Sqrt(simple.MeanSquareError()/n)
+	if math.Abs((mean[1]-mean[0])/2-expected) > 1E-10 {
+		t.Errorf("mean response half-width at xbar %g does not match the expected value %g\n", (mean[1]-mean[0])/2, expected)
+	}
+	expected = tq * math.Sqrt(simple.MeanSquareError()*(1+1/n))
+	if math.Abs((obs[1]-obs[0])/2-expected) > 1E-10 {
+		t.Errorf("prediction half-width at xbar %g does not match the expected value %g\n", (obs[1]-obs[0])/2, expected)
+	}
+	far, _ := simple.PredictInterval(xbar+10, 0.05)
+	if far[1]-far[0] <= mean[1]-mean[0] {
+		t.Error("the confidence interval should widen away from the mean of x")
+	}
+	if math.Abs((mean[0]+mean[1])/2-simple.Predict(xbar)) > 1E-10 {
+		t.Error("the confidence interval is not centered on the prediction")
+	}
+
+	simple.Clear()
+	simple.Add(1, 2)
+	simple.Add(2, 3)
+	if !math.IsNaN(simple.Significance()) || !math.IsNaN(simple.SlopeConfidenceInterval(0.05)) {
+		t.Error("inference with two observations should be NaN")
+	}
+}
+
+func TestNoIntercept(t *testing.T) {
+	simple := NewSimpleNoIntercept()
+	var x [][]float64
+	var y []float64
+	for _, d := range infData {
+		simple.Add(d[0], d[1])
+		x = append(x, []float64{d[0]})
+		y = append(y, d[1])
+	}
+	if simple.HasIntercept() || !NewSimple().HasIntercept() {
+		t.Error("wrong intercept mode")
+	}
+	ols, err := NewOLS(x, y, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareSimple(t, simple, ols.Coefficients()[0], 0, ols.StdErrors()[0], math.NaN(), ols)
+	if simple.Intercept() != 0 || !math.IsNaN(simple.InterceptStdErr()) {
+		t.Errorf("intercept %g with std err %g, expected 0 and NaN", simple.Intercept(), simple.InterceptStdErr())
+	}
+
+	// Append and Remove keep the model through the origin
+	other := NewSimpleNoIntercept()
+	half := NewSimpleNoIntercept()
+	for i, d := range infData {
+		if i < len(infData)/2 {
+			half.Add(d[0], d[1])
+		} else {
+			other.Add(d[0], d[1])
+		}
+	}
+	half.Append(*other)
+	half.Add(100, -3)
+	half.Remove(100, -3)
+	compareSimple(t, half, ols.Coefficients()[0], 0, ols.StdErrors()[0], math.NaN(), ols)
+
+	mean, _ := simple.PredictInterval(0, 0.05)
+	if mean[0] != 0 || mean[1] != 0 {
+		t.Errorf("the mean response at the origin should be exactly 0, got %v", mean)
+	}
+
+	// two observations through the origin leave one residual degree of freedom
+	two := NewSimpleNoIntercept()
+	two.Add(1, 2)
+	two.Add(2, 3)
+	if mse := two.MeanSquareError(); math.Abs(mse-0.2) > 1e-15 {
+		t.Errorf("mean square error %g, expected 0.2", mse)
+	}
+	if se := two.SlopeStdErr(); math.Abs(se-0.2) > 1e-15 {
+		t.Errorf("slope std err %g, expected 0.2", se)
+	}
+	if h, want := two.SlopeConfidenceInterval(0.05), 0.2*studentTQuantile(0.975, 1); math.Abs(h-want) > 1e-12 {
+		t.Errorf("slope confidence interval %g, expected %g", h, want)
+	}
+	if p, q := two.Significance(), two.FSignificance(); math.IsNaN(p) || math.Abs(p-q) > 1e-12 {
+		t.Errorf("significance %g and %g, expected equal p-values", p, q)
+	}
+	if m, o := two.PredictInterval(1, 0.05); math.IsNaN(m[0]) || math.IsNaN(o[1]) {
+		t.Errorf("prediction intervals %v and %v", m, o)
+	}
+	two.Remove(2, 3)
+	if !math.IsNaN(two.MeanSquareError()) || !math.IsNaN(two.Significance()) {
+		t.Errorf("a single observation through the origin has no residual degrees of freedom")
+	}
+}
+
+func TestWeighted(t *testing.T) {
+	weights := []float64{1, 2, 0.5, 4, 1.5, 3, 2.5, 1, 0.25}
+	simple := NewSimple()
+	var x [][]float64
+	var y []float64
+	sumW, sumWY := 0.0, 0.0
+	for i, d := range infData {
+		w := weights[i]
+		simple.AddWeighted(d[0], d[1], w)
+		// weighted least squares is least squares on rows scaled by sqrt(w)
+		sw := math.Sqrt(w)
+		x = append(x, []float64{sw, sw * d[0]})
+		y = append(y, sw*d[1])
+		sumW += w
+		sumWY += w * d[1]
+	}
+	ols, err := NewOLS(x, y, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, se := ols.Coefficients(), ols.StdErrors()
+	compareSimple(t, simple, b[1], b[0], se[1], se[0], ols)
+	ybar := sumWY / sumW
+	sst := 0.0
+	for i, d := range infData {
+		sst += weights[i] * (d[1] - ybar) * (d[1] - ybar)
+	}
+	if math.Abs(simple.TotalSumSquares()-sst) > 1E-10*sst {
+		t.Errorf("total sum of squares %g does not match the expected value %g\n", simple.TotalSumSquares(), sst)
+	}
+
+	// integer weights give the same line as repeated observations
+	repeated := NewSimple()
+	doubled := NewSimple()
+	for _, d := range infData {
+		repeated.Add(d[0], d[1])
+		repeated.Add(d[0], d[1])
+		doubled.AddWeighted(d[0], d[1], 2)
+	}
+	if math.Abs(repeated.Slope()-doubled.Slope()) > TOLERANCE || math.Abs(repeated.Intercept()-doubled.Intercept()) > TOLERANCE {
+		t.Errorf("doubled weights give %g, %g but repeated observations %g, %g\n", doubled.Intercept(), doubled.Slope(), repeated.Intercept(), repeated.Slope())
+	}
+
+	// Append and RemoveWeighted
+	first, second := NewSimple(), NewSimple()
+	for i, d := range infData {
+		if i%2 == 0 {
+			first.AddWeighted(d[0], d[1], weights[i])
+		} else {
+			second.AddWeighted(d[0], d[1], weights[i])
+		}
+	}
+	first.AddWeighted(50, 7, 3)
+	first.RemoveWeighted(50, 7, 3)
+	first.Append(*second)
+	compareSimple(t, first, b[1], b[0], se[1], se[0], ols)
+	if first.nWeighted != 7 {
+		t.Errorf("%d observations with weights other than 1, expected 7\n", first.nWeighted)
+	}
+
+	// removing the weighted observations leaves an unweighted fit
+	mixed := NewSimple()
+	mixed.Add(1, 2)
+	mixed.AddWeighted(2, 3, 0.5)
+	mixed.Add(3, 5)
+	mixed.RemoveWeighted(2, 3, 0.5)
+	if mixed.nWeighted != 0 {
+		t.Errorf("%d weighted observations after removing the only one\n", mixed.nWeighted)
+	}
+
+	// removing the last observation empties the model
+	single := NewSimple()
+	single.AddWeighted(1, 2, 3)
+	single.RemoveWeighted(1, 2, 3)
+	if single.N() != 0 || !math.IsNaN(single.Slope()) {
+		t.Errorf("model should be empty after removing its only observation")
+	}
+}
+
+func compareSimple(t *testing.T, simple *Simple, slope, intercept, slopeStdErr, interceptStdErr float64, ols *OLS) {
+	if math.Abs(simple.Slope()-slope) > TOLERANCE9 {
+		t.Errorf("slope %g does not match the expected value %g\n", simple.Slope(), slope)
+	}
+	if math.Abs(simple.Intercept()-intercept) > TOLERANCE9 {
+		t.Errorf("intercept %g does not match the expected value %g\n", simple.Intercept(), intercept)
+	}
+	if math.Abs(simple.SlopeStdErr()-slopeStdErr) > TOLERANCE9 {
+		t.Errorf("slope std err %g does not match the expected value %g\n", simple.SlopeStdErr(), slopeStdErr)
+	}
+	if !math.IsNaN(interceptStdErr) && math.Abs(simple.InterceptStdErr()-interceptStdErr) > TOLERANCE9 {
+		t.Errorf("intercept std err %g does not match the expected value %g\n", simple.InterceptStdErr(), interceptStdErr)
+	}
+	if math.Abs(simple.SumSquaredErrors()-ols.SumSquaredErrors()) > TOLERANCE9 {
+		t.Errorf("SSE %g does not match the expected value %g\n", simple.SumSquaredErrors(), ols.SumSquaredErrors())
+	}
+	if math.Abs(simple.MeanSquareError()-ols.MeanSquareError()) > TOLERANCE9 {
+		t.Errorf("MSE %g does not match the expected value %g\n", simple.MeanSquareError(), ols.MeanSquareError())
+	}
+	if simple.HasIntercept() == ols.HasIntercept() && math.Abs(simple.RSquare()-ols.RSquare()) > TOLERANCE9 {
+		t.Errorf("r-square %g does not match the expected value %g\n", simple.RSquare(), ols.RSquare())
+	}
+}
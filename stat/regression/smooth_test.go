@@ -0,0 +1,388 @@
+package regression
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// R's cars data: speed (mph) and stopping distance (ft)
+var carsSpeed = []float64{4, 4, 7, 7, 8, 9, 10, 10, 10, 11, 11, 12, 12, 12, 12, 13, 13, 13, 13,
+	14, 14, 14, 14, 15, 15, 15, 16, 16, 17, 17, 17, 18, 18, 18, 18, 19, 19, 19, 20, 20, 20, 20, 20,
+	22, 23, 24, 24, 24, 24, 25}
+var carsDist = []float64{2, 10, 4, 22, 16, 10, 18, 26, 34, 17, 28, 14, 20, 24, 28, 26, 34, 34, 46,
+	26, 36, 60, 80, 20, 26, 54, 32, 40, 32, 40, 50, 42, 56, 76, 84, 36, 46, 68, 32, 48, 52, 56, 64,
+	66, 54, 70, 92, 93, 120, 85}
+
+func TestPolynomial(t *testing.T) {
+	p, err := NewPolynomial(carsSpeed, carsDist, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// R: lm(dist ~ poly(speed, 2), cars)
+	want := []float64{42.98, 145.552, 22.996}
+	for i, c := range p.OrthogonalCoefficients() {
+		if math.Abs(c-want[i]) > 5e-4 {
+			t.Errorf("orthogonal coefficient %d: %g, expected %g", i, c, want[i])
+		}
+	}
+
+	// the same fit as least squares on the powers of x
+	x := make([][]float64, len(carsSpeed))
+	for i, v := range carsSpeed {
+		x[i] = []float64{v, v * v}
+	}
+	o, err := NewOLS(x, carsDist, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, eb := p.Coefficients(), o.Coefficients()
+	for i := range eb {
+		if relErr(b[i], eb[i]) > 1e-9 {
+			t.Errorf("coefficient %d: %g, expected %g", i, b[i], eb[i])
+		}
+	}
+	if relErr(p.SumSquaredErrors(), o.SumSquaredErrors()) > 1e-12 ||
+		relErr(p.RSquare(), o.RSquare()) > 1e-12 {
+		t.Errorf("sse %g, r2 %g, expected %g, %g",
+			p.SumSquaredErrors(), p.RSquare(), o.SumSquaredErrors(), o.RSquare())
+	}
+	f := p.Fitted()
+	for i, v := range carsSpeed {
+		if math.Abs(p.Predict(v)-f[i]) > 1e-10 {
+			t.Errorf("prediction %g, fitted %g", p.Predict(v), f[i])
+		}
+	}
+	if p.EDF() != 3 || p.ResidualDF() != 47 {
+		t.Errorf("edf %g, residual df %d", p.EDF(), p.ResidualDF())
+	}
+
+	// a polynomial of high degree far from the origin
+	var xs, ys []float64
+	for i := 0; i < 30; i++ {
+		v := 1000 + float64(i)/10
+		xs = append(xs, v)
+		u := v - 1001
+		ys = append(ys, 2-u+0.5*u*u*u-0.1*u*u*u*u*u)
+	}
+	p, err = NewPolynomial(xs, ys, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []float64{1000.05, 1001.5, 1002.9} {
+		u := v - 1001
+		if e := 2 - u + 0.5*u*u*u - 0.1*u*u*u*u*u; math.Abs(p.Predict(v)-e) > 1e-8 {
+			t.Errorf("prediction at %g: %g, expected %g", v, p.Predict(v), e)
+		}
+	}
+
+	if _, err := NewPolynomial([]float64{1, 2, 2}, []float64{1, 2, 3}, 2); err == nil {
+		t.Error("no error for too few distinct x")
+	}
+}
+
+// denseSpline returns the smoothing spline fit and hat matrix diagonal
+// by solving (W + lambda * Q R^-1 Q') g = W y with dense matrices.
+func denseSpline(x, y, w []float64, lambda float64) (g, lev []float64) {
+	m := len(x)
+	h := make([]float64, m-1)
+	for i := range h {
+		h[i] = x[i+1] - x[i]
+	}
+	q := make([][]float64, m)
+	for i := range q {
+		q[i] = make([]float64, m-2)
+	}
+	r := make([][]float64, m-2)
+	for j := range r {
+		r[j] = make([]float64, m-2)
+	}
+	for j := range r {
+		q[j][j] = 1 / h[j]
+		q[j+1][j] = -1/h[j] - 1/h[j+1]
+		q[j+2][j] = 1 / h[j+1]
+		r[j][j] = (h[j] + h[j+1]) / 3
+		if j+1 < m-2 {
+			r[j][j+1] = h[j+1] / 6
+			r[j+1][j] = h[j+1] / 6
+		}
+	}
+	// K = Q R^-1 Q'
+	rinvqt := make([][]float64, m-2)
+	for j := range rinvqt {
+		rinvqt[j] = make([]float64, m)
+	}
+	for i := 0; i < m; i++ {
+		col := solveDense(r, q[i])
+		for j := range col {
+			rinvqt[j][i] = col[j]
+		}
+	}
+	a := make([][]float64, m)
+	for i := range a {
+		a[i] = make([]float64, m)
+		for k := 0; k < m; k++ {
+			for j := 0; j < m-2; j++ {
+				a[i][k] += lambda * q[i][j] * rinvqt[j][k]
+			}
+		}
+		a[i][i] += w[i]
+	}
+	wy := make([]float64, m)
+	for i := range wy {
+		wy[i] = w[i] * y[i]
+	}
+	g = solveDense(a, wy)
+	lev = make([]float64, m)
+	for i := range lev {
+		e := make([]float64, m)
+		e[i] = w[i]
+		lev[i] = solveDense(a, e)[i]
+	}
+	return g, lev
+}
+
+// solveDense solves a*x = b by Gaussian elimination with partial pivoting.
+func solveDense(a [][]float64, b []float64) []float64 {
+	n := len(b)
+	m := make([][]float64, n)
+	for i := range m {
+		m[i] = append(append([]float64(nil), a[i]...), b[i])
+	}
+	for k := 0; k < n; k++ {
+		p := k
+		for i := k + 1; i < n; i++ {
+			if math.Abs(m[i][k]) > math.Abs(m[p][k]) {
+				p = i
+			}
+		}
+		m[k], m[p] = m[p], m[k]
+		for i := k + 1; i < n; i++ {
+			f := m[i][k] / m[k][k]
+			for j := k; j <= n; j++ {
+				m[i][j] -= f * m[k][j]
+			}
+		}
+	}
+	x := make([]float64, n)
+	for i := n - 1; i >= 0; i-- {
+		s := m[i][n]
+		for j := i + 1; j < n; j++ {
+			s -= m[i][j] * x[j]
+		}
+		x[i] = s / m[i][i]
+	}
+	return x
+}
+
+func TestSmoothingSpline(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	n := 40
+	x := make([]float64, n)
+	y := make([]float64, n)
+	w := make([]float64, n)
+	for i := range x {
+		x[i] = float64(i)/4 + rng.Float64()/8
+		y[i] = math.Sin(x[i]) + rng.NormFloat64()*0.3
+		w[i] = 0.5 + rng.Float64()
+	}
+	for _, lambda := range []float64{1e-3, 0.1, 10} {
+		s, err := NewSmoothingSpline(x, y, &SplineOptions{Weights: w, Lambda: lambda})
+		if err != nil {
+			t.Fatal(err)
+		}
+		g, lev := denseSpline(x, y, w, lambda)
+		f, sl := s.Fitted(), s.Leverages()
+		df := 0.0
+		for i := range g {
+			df += lev[i]
+			if math.Abs(f[i]-g[i]) > 1e-8 || math.Abs(sl[i]-lev[i]) > 1e-8 {
+				t.Errorf("lambda %g, observation %d: %g (%g), expected %g (%g)",
+					lambda, i, f[i], sl[i], g[i], lev[i])
+			}
+			if math.Abs(s.Predict(x[i])-f[i]) > 1e-10 {
+				t.Errorf("prediction %g at knot, expected %g", s.Predict(x[i]), f[i])
+			}
+		}
+		if math.Abs(s.EDF()-df) > 1e-8 {
+			t.Errorf("lambda %g: edf %g, expected %g", lambda, s.EDF(), df)
+		}
+	}
+
+	// a very large lambda gives the weighted least squares line
+	s, err := NewSmoothingSpline(x, y, &SplineOptions{Weights: w, Lambda: 1e12})
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := NewSimple()
+	for i, v := range x {
+		line.AddWeighted(v, y[i], w[i])
+	}
+	for _, v := range []float64{-1, 3, 12} {
+		if math.Abs(s.Predict(v)-line.Predict(v)) > 1e-6 {
+			t.Errorf("prediction at %g: %g, expected %g", v, s.Predict(v), line.Predict(v))
+		}
+	}
+	if math.Abs(s.EDF()-2) > 1e-6 {
+		t.Errorf("edf %g, expected 2", s.EDF())
+	}
+
+	// target degrees of freedom
+	s, err = NewSmoothingSpline(x, y, &SplineOptions{DF: 6.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(s.EDF()-6.5) > 1e-6 {
+		t.Errorf("edf %g, expected 6.5", s.EDF())
+	}
+
+	// GCV is minimal at the chosen lambda
+	s, err = NewSmoothingSpline(x, y, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []float64{1e-3, 0.01, 0.1, 0.8, 1.25, 10, 100, 1000} {
+		o, _ := NewSmoothingSpline(x, y, &SplineOptions{Lambda: s.Lambda() * f})
+		if o.GCV() < s.GCV() {
+			t.Errorf("GCV %g at lambda %g below %g at the chosen %g",
+				o.GCV(), o.Lambda(), s.GCV(), s.Lambda())
+		}
+	}
+
+	// tied x values are combined
+	xt := append(append([]float64(nil), x...), x[3], x[3])
+	yt := append(append([]float64(nil), y...), y[3]+1, y[3]-1)
+	s, err = NewSmoothingSpline(xt, yt, &SplineOptions{Lambda: 0.1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Knots()) != n || s.N() != n+2 {
+		t.Errorf("%d knots, %d observations", len(s.Knots()), s.N())
+	}
+	wt := make([]float64, n)
+	for i := range wt {
+		wt[i] = 1
+	}
+	wt[3] = 3
+	g, _ := denseSpline(x, y, wt, 0.1)
+	if f := s.Fitted(); math.Abs(f[n]-g[3]) > 1e-8 {
+		t.Errorf("fitted value at tie %g, expected %g", f[n], g[3])
+	}
+}
+
+func TestLowess(t *testing.T) {
+	// R: lowess(cars)
+	want := []float64{4.965459, 4.965459, 13.124495, 13.124495, 15.858633, 18.579691,
+		21.280313, 21.280313, 21.280313, 24.129277}
+	delta := 0.01 * (carsSpeed[len(carsSpeed)-1] - carsSpeed[0])
+	l := NewLowess(carsSpeed, carsDist, 2.0/3, 3, delta)
+	f := l.Fitted()
+	for i, v := range want {
+		if math.Abs(f[i]-v) > 1e-5 {
+			t.Errorf("fitted %d: %g, expected %g", i, f[i], v)
+		}
+	}
+	if p := l.Predict(carsSpeed[0]); math.Abs(p-want[0]) > 1e-5 {
+		t.Errorf("prediction %g, expected %g", p, want[0])
+	}
+
+	// the trace by perturbation of a linear smoother, with interpolation
+	rng := rand.New(rand.NewSource(2))
+	n := 60
+	x := make([]float64, n)
+	y := make([]float64, n)
+	for i := range x {
+		x[i] = math.Floor(rng.Float64()*200) / 10
+		y[i] = math.Cos(x[i]/3) + rng.NormFloat64()*0.2
+	}
+	l = NewLowess(x, y, 0.3, 0, 0.5)
+	f = l.Fitted()
+	trace := 0.0
+	for i := range y {
+		y[i]++
+		trace += NewLowess(x, y, 0.3, 0, 0.5).Fitted()[i] - f[i]
+		y[i]--
+	}
+	if math.Abs(l.EDF()-trace) > 1e-8 {
+		t.Errorf("edf %g, expected %g", l.EDF(), trace)
+	}
+}
+
+func TestLoess(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	n := 80
+	x := make([]float64, n)
+	y := make([]float64, n)
+	for i := range x {
+		x[i] = rng.Float64() * 10
+		y[i] = math.Sin(x[i]) + rng.NormFloat64()*0.2
+	}
+
+	// local linear fits without robustness are those of lowess
+	lo := NewLoess(x, y, 0.4, 1, 0)
+	lw := NewLowess(x, y, 0.4, 0, 0)
+	f, fw := lo.Fitted(), lw.Fitted()
+	for i := range f {
+		if math.Abs(f[i]-fw[i]) > 1e-6 {
+			t.Errorf("fitted %d: %g, lowess %g", i, f[i], fw[i])
+		}
+	}
+	if math.Abs(lo.EDF()-lw.EDF()) > 1e-5 {
+		t.Errorf("edf %g, lowess %g", lo.EDF(), lw.EDF())
+	}
+
+	// the trace and residual degrees of freedom by perturbation
+	l := NewLoess(x, y, 0.5, 2, 0)
+	f = l.Fitted()
+	var trace, oneDelta float64
+	for i := range y {
+		y[i]++
+		fi := NewLoess(x, y, 0.5, 2, 0).Fitted()
+		y[i]--
+		for j := range fi {
+			lij := fi[j] - f[j]
+			if i == j {
+				trace += lij
+				lij = 1 - lij
+			}
+			oneDelta += lij * lij
+		}
+	}
+	if math.Abs(l.EDF()-trace) > 1e-8 {
+		t.Errorf("edf %g, expected %g", l.EDF(), trace)
+	}
+	sse := 0.0
+	for _, r := range l.Residuals() {
+		sse += r * r
+	}
+	if rse := math.Sqrt(sse / oneDelta); math.Abs(l.ResidualStdError()-rse) > 1e-8 {
+		t.Errorf("residual standard error %g, expected %g", l.ResidualStdError(), rse)
+	}
+	for i, v := range x {
+		if math.Abs(l.Predict(v)-f[i]) > 1e-10 {
+			t.Errorf("prediction %g, fitted %g", l.Predict(v), f[i])
+		}
+	}
+
+	// quadratic local fits reproduce a quadratic
+	q := make([]float64, n)
+	for i, v := range x {
+		q[i] = 1 + v - 0.3*v*v
+	}
+	l = NewLoess(x, q, 0.3, 2, 0)
+	for _, v := range []float64{-1, 0.5, 5, 11} {
+		if e := 1 + v - 0.3*v*v; math.Abs(l.Predict(v)-e) > 1e-9 {
+			t.Errorf("prediction at %g: %g, expected %g", v, l.Predict(v), e)
+		}
+	}
+
+	// robustness iterations discount an outlier
+	y[10] += 20
+	r := NewLoess(x, y, 0.5, 2, 3)
+	if w := r.RobustnessWeights(); w[10] != 0 {
+		t.Errorf("outlier weight %g", w[10])
+	}
+	if math.Abs(r.Predict(x[10])-math.Sin(x[10])) > 0.3 {
+		t.Errorf("robust fit %g at the outlier, expected about %g", r.Predict(x[10]), math.Sin(x[10]))
+	}
+}
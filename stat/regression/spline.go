@@ -0,0 +1,389 @@
+package regression
+
+import (
+	"math"
+	"sort"
+)
+
+// SplineOptions holds the optional settings of NewSmoothingSpline.
+// The zero value fits unweighted observations with the smoothing parameter
+// chosen by generalized cross-validation.
+type SplineOptions struct {
+	// Weights of the observations, e.g. their inverse variances.
+	Weights []float64
+	// Lambda fixes the smoothing parameter if positive.
+	Lambda float64
+	// DF chooses the smoothing parameter that gives these equivalent
+	// degrees of freedom, in (2, m] for m distinct x values, if positive
+	// and Lambda is not set.
+	DF float64
+}
+
+// Estimates a cubic smoothing spline, the function g that minimizes
+//
+// sum w_i * (y_i - g(x_i))^2 + lambda * integral (d2g/dt2)^2 dt.
+//
+// The minimizer is a natural cubic spline with knots at the distinct x
+// values; it is computed in O(m) time with the Reinsch algorithm
+// (Green and Silverman, 1994, chapter 2), and the diagonal of the hat
+// matrix with the band of the inverse of the Reinsch matrix
+// (Hutchinson and de Hoog, 1985).
+//
+// Observations with the same x are combined into their weighted mean with
+// the sum of their weights, as in R's smooth.spline. As in R, lambda is
+// searched as r * 256^(3*spar - 1) for spar in [-1.5, 1.5], where r balances
+// the traces of the two terms of the criterion, and the default choice
+// minimizes the generalized cross-validation score
+//
+// GCV = m * RSS / (m - df)^2
+//
+// over the m distinct x values, with RSS the weighted residual sum of
+// squares and df the trace of the hat matrix.
+type SmoothingSpline struct {
+	x, y   []float64 // the observations
+	t      []float64 // distinct x values, increasing
+	ybar   []float64 // weighted mean of y at t
+	w      []float64 // sum of the weights at t
+	index  []int     // index in t of each observation
+	h      []float64 // t[i+1] - t[i]
+	q      [3][]float64
+	r      [2][]float64
+	qwq    [3][]float64 // band of Q'W^-1Q
+	ratio  float64
+	lambda float64
+	g      []float64 // fitted values at t
+	gamma  []float64 // second derivatives at t
+	lev    []float64 // diagonal of the hat matrix at t
+	df     float64
+	gcv    float64
+}
+
+// NewSmoothingSpline fits a smoothing spline to the observations.
+// opts may be nil. An Error is returned if x has fewer than three distinct
+// values.
+func NewSmoothingSpline(x, y []float64, opts *SplineOptions) (*SmoothingSpline, error) {
+	if opts == nil {
+		opts = &SplineOptions{}
+	}
+	n := len(x)
+	if len(y) != n {
+		panic("regression: x and y have different lengths")
+	}
+	w := opts.Weights
+	if w == nil {
+		w = make([]float64, n)
+		for i := range w {
+			w[i] = 1
+		}
+	}
+	if len(w) != n {
+		panic("regression: weights must have one value per observation")
+	}
+	for _, v := range w {
+		if v <= 0 {
+			panic("regression: weights must be positive")
+		}
+	}
+
+	s := &SmoothingSpline{x: x, y: y, index: make([]int, n)}
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool { return x[order[i]] < x[order[j]] })
+	for k, i := range order {
+		if k == 0 || x[i] != s.t[len(s.t)-1] {
+			s.t = append(s.t, x[i])
+			s.ybar = append(s.ybar, 0)
+			s.w = append(s.w, 0)
+		}
+		m := len(s.t) - 1
+		s.index[i] = m
+		s.w[m] += w[i]
+		s.ybar[m] += w[i] * y[i]
+	}
+	m := len(s.t)
+	if m < 3 {
+		return nil, Error{Message: "regression: at least three distinct x values are needed"}
+	}
+	for i := range s.ybar {
+		s.ybar[i] /= s.w[i]
+	}
+
+	// Q is m by m-2 with column j non-zero in rows j, j+1 and j+2,
+	// R is the m-2 by m-2 tridiagonal penalty matrix.
+	s.h = make([]float64, m-1)
+	for i := range s.h {
+		s.h[i] = s.t[i+1] - s.t[i]
+	}
+	for k := range s.q {
+		s.q[k] = make([]float64, m-2)
+		s.qwq[k] = make([]float64, m-2)
+	}
+	for k := range s.r {
+		s.r[k] = make([]float64, m-2)
+	}
+	var trR, trQWQ float64
+	for j := 0; j < m-2; j++ {
+		s.q[0][j] = 1 / s.h[j]
+		s.q[1][j] = -1/s.h[j] - 1/s.h[j+1]
+		s.q[2][j] = 1 / s.h[j+1]
+		s.r[0][j] = (s.h[j] + s.h[j+1]) / 3
+		s.r[1][j] = s.h[j+1] / 6
+		trR += s.r[0][j]
+	}
+	for j := 0; j < m-2; j++ {
+		for k := 0; k < 3 && j+k < m-2; k++ {
+			// rows shared by columns j and j+k
+			v := 0.0
+			for i := j + k; i <= j+2; i++ {
+				v += s.q[i-j][j] * s.q[i-j-k][j+k] / s.w[i]
+			}
+			s.qwq[k][j] = v
+		}
+		trQWQ += s.qwq[0][j]
+	}
+	s.ratio = trR / trQWQ
+
+	switch {
+	case opts.Lambda > 0:
+		s.fit(opts.Lambda)
+	case opts.DF > 0:
+		if opts.DF <= 2 || opts.DF > float64(m) {
+			panic("regression: degrees of freedom must be in (2, m]")
+		}
+		lo, hi := -3.0, 4.0 // spar
+		for i := 0; i < 200 && hi-lo > 1e-12; i++ {
+			mid := (lo + hi) / 2
+			s.fit(s.lambdaOf(mid))
+			if s.df > opts.DF {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+		s.fit(s.lambdaOf((lo + hi) / 2))
+	default:
+		s.minimizeGCV()
+	}
+	return s, nil
+}
+
+func (s *SmoothingSpline) lambdaOf(spar float64) float64 {
+	return s.ratio * math.Pow(256, 3*spar-1)
+}
+
+// minimizeGCV fits the spline with the smoothing parameter that minimizes
+// the GCV score, found on a grid of spar and refined by golden section
+// search around the best grid point.
+func (s *SmoothingSpline) minimizeGCV() {
+	const lo, hi, steps = -1.5, 1.5, 60
+	score := func(spar float64) float64 {
+		s.fit(s.lambdaOf(spar))
+		return s.gcv
+	}
+	best, bestScore := lo, math.Inf(1)
+	for i := 0; i <= steps; i++ {
+		spar := lo + (hi-lo)*float64(i)/steps
+		if v := score(spar); v < bestScore {
+			best, bestScore = spar, v
+		}
+	}
+	a := math.Max(lo, best-(hi-lo)/steps)
+	b := math.Min(hi, best+(hi-lo)/steps)
+	ratio := (math.Sqrt(5) - 1) / 2
+	c, d := b-ratio*(b-a), a+ratio*(b-a)
+	fc, fd := score(c), score(d)
+	for b-a > 1e-8 {
+		if fc < fd {
+			b, d, fd = d, c, fc
+			c = b - ratio*(b-a)
+			fc = score(c)
+		} else {
+			a, c, fc = c, d, fd
+			d = a + ratio*(b-a)
+			fd = score(d)
+		}
+	}
+	spar := (a + b) / 2
+	if score(spar) > bestScore {
+		spar = best
+	}
+	s.fit(s.lambdaOf(spar))
+}
+
+// fit computes the spline, its hat matrix diagonal and GCV score
+// for the smoothing parameter lambda.
+func (s *SmoothingSpline) fit(lambda float64) {
+	m := len(s.t)
+	k := m - 2
+	s.lambda = lambda
+
+	// B = R + lambda * Q'W^-1Q = L*D*L', with L unit lower triangular
+	// with sub-diagonals l1 and l2.
+	d := make([]float64, k)
+	l1 := make([]float64, k)
+	l2 := make([]float64, k)
+	for j := 0; j < k; j++ {
+		b0 := s.r[0][j] + lambda*s.qwq[0][j]
+		b1 := s.r[1][j] + lambda*s.qwq[1][j]
+		b2 := lambda * s.qwq[2][j]
+		if j > 0 {
+			b0 -= l1[j-1] * l1[j-1] * d[j-1]
+			b1 -= l2[j-1] * l1[j-1] * d[j-1]
+		}
+		if j > 1 {
+			b0 -= l2[j-2] * l2[j-2] * d[j-2]
+		}
+		d[j] = b0
+		l1[j] = b1 / d[j]
+		l2[j] = b2 / d[j]
+	}
+
+	// solve B*gamma = Q'y
+	z := make([]float64, k)
+	for j := range z {
+		z[j] = s.q[0][j]*s.ybar[j] + s.q[1][j]*s.ybar[j+1] + s.q[2][j]*s.ybar[j+2]
+		if j > 0 {
+			z[j] -= l1[j-1] * z[j-1]
+		}
+		if j > 1 {
+			z[j] -= l2[j-2] * z[j-2]
+		}
+	}
+	s.gamma = make([]float64, m)
+	gamma := s.gamma[1 : m-1]
+	for j := k - 1; j >= 0; j-- {
+		gamma[j] = z[j] / d[j]
+		if j+1 < k {
+			gamma[j] -= l1[j] * gamma[j+1]
+		}
+		if j+2 < k {
+			gamma[j] -= l2[j] * gamma[j+2]
+		}
+	}
+
+	// g = y - lambda * W^-1 * Q * gamma
+	s.g = make([]float64, m)
+	for i := range s.g {
+		qg := 0.0
+		for j := i - 2; j <= i; j++ {
+			if j >= 0 && j < k {
+				qg += s.q[i-j][j] * gamma[j]
+			}
+		}
+		s.g[i] = s.ybar[i] - lambda*qg/s.w[i]
+	}
+
+	// band of S = B^-1
+	var band [3][]float64
+	for o := range band {
+		band[o] = make([]float64, k)
+	}
+	at := func(o, j int) float64 {
+		if j+o >= k {
+			return 0
+		}
+		return band[o][j]
+	}
+	for j := k - 1; j >= 0; j-- {
+		band[2][j] = -l1[j]*at(1, j+1) - l2[j]*at(0, j+2)
+		band[1][j] = -l1[j]*at(0, j+1) - l2[j]*at(1, j+1)
+		band[0][j] = 1/d[j] - l1[j]*band[1][j] - l2[j]*band[2][j]
+	}
+	sinv := func(i, j int) float64 {
+		if i > j {
+			i, j = j, i
+		}
+		return at(j-i, i)
+	}
+
+	// leverages 1 - lambda/w_i * (Q S Q')_ii
+	s.lev = make([]float64, m)
+	s.df = 0
+	rss := 0.0
+	for i := range s.lev {
+		v := 0.0
+		for a := i - 2; a <= i; a++ {
+			for b := i - 2; b <= i; b++ {
+				if a >= 0 && a < k && b >= 0 && b < k {
+					v += s.q[i-a][a] * s.q[i-b][b] * sinv(a, b)
+				}
+			}
+		}
+		s.lev[i] = 1 - lambda*v/s.w[i]
+		s.df += s.lev[i]
+		r := s.ybar[i] - s.g[i]
+		rss += s.w[i] * r * r
+	}
+	s.gcv = float64(m) * rss / ((float64(m) - s.df) * (float64(m) - s.df))
+}
+
+// Returns the number of observations.
+func (s *SmoothingSpline) N() int {
+	return len(s.x)
+}
+
+// Returns the distinct x values, the knots of the spline.
+func (s *SmoothingSpline) Knots() []float64 {
+	return append([]float64(nil), s.t...)
+}
+
+// Returns the smoothing parameter lambda.
+func (s *SmoothingSpline) Lambda() float64 {
+	return s.lambda
+}
+
+// Returns the equivalent degrees of freedom, the trace of the hat matrix,
+// from 2 for the least squares line to m for the interpolating spline.
+func (s *SmoothingSpline) EDF() float64 {
+	return s.df
+}
+
+// Returns the generalized cross-validation score of the fit.
+func (s *SmoothingSpline) GCV() float64 {
+	return s.gcv
+}
+
+// Returns the leverages of the distinct x values, the diagonal of the
+// hat matrix.
+func (s *SmoothingSpline) Leverages() []float64 {
+	return append([]float64(nil), s.lev...)
+}
+
+// Returns the fitted values of the observations.
+func (s *SmoothingSpline) Fitted() []float64 {
+	f := make([]float64, len(s.x))
+	for i, k := range s.index {
+		f[i] = s.g[k]
+	}
+	return f
+}
+
+// Returns the residuals y - fitted of the observations.
+func (s *SmoothingSpline) Residuals() []float64 {
+	r := make([]float64, len(s.y))
+	for i, k := range s.index {
+		r[i] = s.y[i] - s.g[k]
+	}
+	return r
+}
+
+// Returns the value of the spline at x. Outside the knots
+// the natural spline is extended linearly.
+func (s *SmoothingSpline) Predict(x float64) float64 {
+	t, g, gamma, h := s.t, s.g, s.gamma, s.h
+	m := len(t)
+	if x <= t[0] {
+		slope := (g[1]-g[0])/h[0] - h[0]*gamma[1]/6
+		return g[0] + slope*(x-t[0])
+	}
+	if x >= t[m-1] {
+		slope := (g[m-1]-g[m-2])/h[m-2] + h[m-2]*gamma[m-2]/6
+		return g[m-1] + slope*(x-t[m-1])
+	}
+	i := sort.SearchFloat64s(t, x) - 1 // t[i] < x <= t[i+1]
+	a, b := x-t[i], t[i+1]-x
+	return (a*g[i+1]+b*g[i])/h[i] -
+		a*b/6*((1+a/h[i])*gamma[i+1]+(1+b/h[i])*gamma[i])
+}
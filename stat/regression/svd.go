@@ -0,0 +1,117 @@
+package regression
+
+import (
+	"math"
+	"sort"
+)
+
+// svd returns the thin singular value decomposition A = U*diag(s)*V' of the
+// m by n matrix with the given rows, computed by one-sided Jacobi rotations
+// (Hestenes' method), which is accurate for small singular values.
+// With r = min(m, n), u is m by r, s has r elements in decreasing order
+// and v is n by r. a is not modified.
+func svd(a [][]float64) (u [][]float64, s []float64, v [][]float64) {
+	m, n := len(a), len(a[0])
+	if m < n {
+		// A' = V*diag(s)*U'
+		v, s, u = svd(transpose(a))
+		return
+	}
+
+	// work on columns: cols[j] is column j of A*V
+	cols := make([][]float64, n)
+	rot := make([][]float64, n) // rot[j] is column j of V
+	for j := range cols {
+		cols[j] = make([]float64, m)
+		for i, row := range a {
+			cols[j][i] = row[j]
+		}
+		rot[j] = make([]float64, n)
+		rot[j][j] = 1
+	}
+
+	const eps = 1e-15
+	for sweep := 0; sweep < 60; sweep++ {
+		rotated := false
+		for j := 0; j < n-1; j++ {
+			for k := j + 1; k < n; k++ {
+				var alpha, beta, gamma float64
+				for i := 0; i < m; i++ {
+					alpha += cols[j][i] * cols[j][i]
+					beta += cols[k][i] * cols[k][i]
+					gamma += cols[j][i] * cols[k][i]
+				}
+				if gamma == 0 || math.Abs(gamma) <= eps*math.Sqrt(alpha*beta) {
+					continue
+				}
+				rotated = true
+				zeta := (beta - alpha) / (2 * gamma)
+				t := 1 / (math.Abs(zeta) + math.Sqrt(1+zeta*zeta))
+				if zeta < 0 {
+					t = -t
+				}
+				c := 1 / math.Sqrt(1+t*t)
+				sn := c * t
+				rotate(cols[j], cols[k], c, sn)
+				rotate(rot[j], rot[k], c, sn)
+			}
+		}
+		if !rotated {
+			break
+		}
+	}
+
+	s = make([]float64, n)
+	for j, col := range cols {
+		for _, x := range col {
+			s[j] = math.Hypot(s[j], x)
+		}
+	}
+	order := make([]int, n)
+	for j := range order {
+		order[j] = j
+	}
+	sort.SliceStable(order, func(i, j int) bool { return s[order[i]] > s[order[j]] })
+
+	u = make([][]float64, m)
+	for i := range u {
+		u[i] = make([]float64, n)
+	}
+	v = make([][]float64, n)
+	for i := range v {
+		v[i] = make([]float64, n)
+	}
+	sorted := make([]float64, n)
+	for c, j := range order {
+		sorted[c] = s[j]
+		for i := 0; i < m; i++ {
+			if s[j] != 0 {
+				u[i][c] = cols[j][i] / s[j]
+			}
+		}
+		for i := 0; i < n; i++ {
+			v[i][c] = rot[j][i]
+		}
+	}
+	return u, sorted, v
+}
+
+// rotate applies the plane rotation [c -s; s c] to the columns x and y.
+func rotate(x, y []float64, c, s float64) {
+	for i := range x {
+		xi, yi := x[i], y[i]
+		x[i] = c*xi - s*yi
+		y[i] = s*xi + c*yi
+	}
+}
+
+func transpose(a [][]float64) [][]float64 {
+	t := make([][]float64, len(a[0]))
+	for j := range t {
+		t[j] = make([]float64, len(a))
+		for i, row := range a {
+			t[j][i] = row[j]
+		}
+	}
+	return t
+}
@@ -0,0 +1,347 @@
+package regression
+
+import (
+	"github.com/mingzhi/gomath/random"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Estimates a straight line by the Theil-Sen estimator: the slope is the
+// median of the slopes between all pairs of observations with different x,
+// and the intercept the median of y - slope * x. Up to 29% of the
+// observations can be arbitrary without breaking it down.
+//
+// The median slope is selected among the n(n-1)/2 slopes without listing
+// them, in O(n log n) expected time: the slopes smaller than t are the
+// inversions of y - t*x in the order of x, which can be counted and
+// sampled by merge sort and a Fenwick tree, and a random sample of the
+// slopes narrows the interval containing the median until few remain
+// (Dillencourt, Mount and Netanyahu, 1992).
+type TheilSen struct {
+	line
+	s *slopeSelector
+}
+
+// NewTheilSen fits a line to the observations (x[i], y[i]).
+// There must be two different x values.
+func NewTheilSen(x, y []float64) *TheilSen {
+	checkLineData(x, y)
+	t := &TheilSen{line: line{x: x, y: y}}
+	t.s = newSlopeSelector(x, y)
+	if t.s.total == 0 {
+		panic("regression: all the x values are equal")
+	}
+	n := t.s.total
+	if n%2 == 1 {
+		t.slope = t.s.selectSlope(n / 2)
+	} else {
+		t.slope = (t.s.selectSlope(n/2-1) + t.s.selectSlope(n/2)) / 2
+	}
+	d := make([]float64, len(x))
+	for i, v := range x {
+		d[i] = y[i] - t.slope*v
+	}
+	t.intercept = median(d)
+	return t
+}
+
+// SlopeInterval returns the distribution free 1-alpha confidence interval of
+// the slope (Sen, 1968), between order statistics of the pairwise slopes
+// whose ranks come from the normal approximation of Kendall's tau,
+// with the variance corrected for ties in x.
+func (t *TheilSen) SlopeInterval(alpha float64) (lower, upper float64) {
+	if alpha <= 0 || alpha >= 1 {
+		panic("regression: alpha must be in (0, 1)")
+	}
+	n := float64(t.N())
+	v := n * (n - 1) * (2*n + 5)
+	for _, g := range t.s.tieGroups() {
+		tg := float64(g)
+		v -= tg * (tg - 1) * (2*tg + 5)
+	}
+	c := random.Normal{Sigma: 1}.Quantile(1-alpha/2) * math.Sqrt(v/18)
+	total := float64(t.s.total)
+	m1 := int64(math.Floor((total - c) / 2))
+	m2 := int64(math.Ceil((total + c) / 2))
+	if m1 < 1 {
+		m1 = 1
+	}
+	if m2 > t.s.total-1 {
+		m2 = t.s.total - 1
+	}
+	return t.s.selectSlope(m1 - 1), t.s.selectSlope(m2)
+}
+
+// slopeSelector selects order statistics of the slopes between pairs of
+// points with different x.
+type slopeSelector struct {
+	x, y  []float64 // points sorted by x, then y
+	total int64     // number of pairs with different x
+	rng   *rand.Rand
+	keys  []float64
+	work  []float64
+}
+
+func newSlopeSelector(x, y []float64) *slopeSelector {
+	n := len(x)
+	idx := make([]int, n)
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.Slice(idx, func(a, b int) bool {
+		i, j := idx[a], idx[b]
+		return x[i] < x[j] || (x[i] == x[j] && y[i] < y[j])
+	})
+	s := &slopeSelector{x: make([]float64, n), y: make([]float64, n)}
+	for k, i := range idx {
+		s.x[k], s.y[k] = x[i], y[i]
+	}
+	s.total = int64(n) * int64(n-1) / 2
+	for _, g := range s.tieGroups() {
+		s.total -= int64(g) * int64(g-1) / 2
+	}
+	s.rng = rand.New(rand.NewSource(1))
+	s.keys = make([]float64, n)
+	s.work = make([]float64, n)
+	return s
+}
+
+// tieGroups returns the sizes of the groups of equal x values.
+func (s *slopeSelector) tieGroups() []int {
+	var groups []int
+	for i := 0; i < len(s.x); {
+		j := i + 1
+		for j < len(s.x) && s.x[j] == s.x[i] {
+			j++
+		}
+		if j-i > 1 {
+			groups = append(groups, j-i)
+		}
+		i = j
+	}
+	return groups
+}
+
+// countBelow returns the number of slopes smaller than t, the number of
+// inversions of y - t*x in the order of x.
+func (s *slopeSelector) countBelow(t float64) int64 {
+	switch {
+	case math.IsInf(t, -1):
+		return 0
+	case math.IsInf(t, 1):
+		return s.total
+	}
+	for i, x := range s.x {
+		s.keys[i] = s.y[i] - t*x
+	}
+	return countInversions(s.keys, s.work)
+}
+
+// countInversions returns the number of pairs i < j with a[i] > a[j],
+// sorting a by merge sort with the buffer work.
+func countInversions(a, work []float64) int64 {
+	n := len(a)
+	if n < 2 {
+		return 0
+	}
+	m := n / 2
+	count := countInversions(a[:m], work[:m]) + countInversions(a[m:], work[m:])
+	i, j, k := 0, m, 0
+	for i < m && j < n {
+		if a[j] < a[i] {
+			count += int64(m - i)
+			work[k] = a[j]
+			j++
+		} else {
+			work[k] = a[i]
+			i++
+		}
+		k++
+	}
+	k += copy(work[k:], a[i:m])
+	copy(work[k:], a[j:n])
+	copy(a, work[:n])
+	return count
+}
+
+// orderAt returns the indices of the points sorted by y - t*x, with ties
+// broken by x and y, so that a pair with slope s is in the order of x
+// for t <= s and in the reverse order for t > s.
+func (s *slopeSelector) orderAt(t float64) []int {
+	n := len(s.x)
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	switch {
+	case math.IsInf(t, -1):
+		return order
+	case math.IsInf(t, 1):
+		sort.Slice(order, func(a, b int) bool {
+			i, j := order[a], order[b]
+			return s.x[i] > s.x[j] || (s.x[i] == s.x[j] && i < j)
+		})
+		return order
+	}
+	for i, x := range s.x {
+		s.keys[i] = s.y[i] - t*x
+	}
+	// the points are sorted by x and y, so ties are broken by index
+	sort.Slice(order, func(a, b int) bool {
+		i, j := order[a], order[b]
+		return s.keys[i] < s.keys[j] || (s.keys[i] == s.keys[j] && i < j)
+	})
+	return order
+}
+
+// between calls f with the rank-th slope in [lo, hi), in an arbitrary but
+// fixed order, for each of the increasing ranks, and returns the number of
+// slopes in the interval. With a nil ranks, f is called for every slope.
+func (s *slopeSelector) between(lo, hi float64, ranks []int64, f func(slope float64)) int64 {
+	a := s.orderAt(lo)
+	b := s.orderAt(hi)
+	n := len(a)
+	rankB := make([]int, n)
+	for r, p := range b {
+		rankB[p] = r
+	}
+	tree := newFenwick(n)
+	var acc int64
+	for j, p := range a {
+		v := rankB[p]
+		less := tree.prefix(v)
+		greater := int64(j - less)
+		// the slopes between p and the earlier points of larger rank in b,
+		// ordered by that rank
+		slope := func(o int64) float64 {
+			q := b[tree.find(less+int(o))]
+			return (s.y[p] - s.y[q]) / (s.x[p] - s.x[q])
+		}
+		if ranks == nil {
+			for o := int64(0); o < greater; o++ {
+				f(slope(o))
+			}
+		} else {
+			for len(ranks) > 0 && ranks[0] < acc+greater {
+				f(slope(ranks[0] - acc))
+				ranks = ranks[1:]
+			}
+		}
+		acc += greater
+		tree.add(v)
+	}
+	return acc
+}
+
+// selectSlope returns the slope of rank k, from 0.
+func (s *slopeSelector) selectSlope(k int64) float64 {
+	n := len(s.x)
+	lo, hi := math.Inf(-1), math.Inf(1)
+	cLo, cHi := int64(0), s.total // numbers of slopes below lo and hi
+	for round := 0; ; round++ {
+		count := cHi - cLo
+		if count <= int64(4*n) || round > 50 {
+			slopes := make([]float64, 0, count)
+			s.between(lo, hi, nil, func(slope float64) { slopes = append(slopes, slope) })
+			sort.Float64s(slopes)
+			i := k - cLo
+			if i >= int64(len(slopes)) {
+				i = int64(len(slopes)) - 1
+			}
+			if i < 0 {
+				i = 0
+			}
+			return slopes[i]
+		}
+
+		// a sorted sample of the slopes in [lo, hi)
+		m := n
+		sample := make([]float64, 0, m)
+		if round == 0 {
+			for len(sample) < m {
+				i, j := s.rng.Intn(n), s.rng.Intn(n)
+				if s.x[i] != s.x[j] {
+					sample = append(sample, (s.y[j]-s.y[i])/(s.x[j]-s.x[i]))
+				}
+			}
+		} else {
+			ranks := make([]int64, m)
+			for i := range ranks {
+				ranks[i] = s.rng.Int63n(count)
+			}
+			sort.Slice(ranks, func(i, j int) bool { return ranks[i] < ranks[j] })
+			s.between(lo, hi, ranks, func(slope float64) { sample = append(sample, slope) })
+			m = len(sample)
+			if m == 0 {
+				round = 50 // rounding broke the counts, list the slopes
+				continue
+			}
+		}
+		sort.Float64s(sample)
+
+		// narrow the interval around the expected position of rank k
+		pos := int(float64(k-cLo) / float64(count) * float64(m))
+		d := int(3*math.Sqrt(float64(m))) + 1
+		newLo, newHi := lo, hi
+		nLo, nHi := cLo, cHi
+		if pos-d >= 0 {
+			newLo = sample[pos-d]
+			nLo = s.countBelow(newLo)
+		}
+		if pos+d < m {
+			newHi = math.Nextafter(sample[pos+d], math.Inf(1))
+			nHi = s.countBelow(newHi)
+		}
+		switch {
+		case k < nLo:
+			hi, cHi = newLo, nLo
+		case k >= nHi:
+			lo, cLo = newHi, nHi
+		default:
+			if newHi == math.Nextafter(newLo, math.Inf(1)) {
+				// the interval holds a single value
+				return newLo
+			}
+			lo, hi, cLo, cHi = newLo, newHi, nLo, nHi
+		}
+	}
+}
+
+// fenwick is a binary indexed tree counting the values in [0, n).
+type fenwick []int
+
+func newFenwick(n int) fenwick {
+	return make(fenwick, n+1)
+}
+
+func (f fenwick) add(v int) {
+	for i := v + 1; i < len(f); i += i & -i {
+		f[i]++
+	}
+}
+
+// prefix returns the number of values smaller than v.
+func (f fenwick) prefix(v int) int {
+	c := 0
+	for i := v; i > 0; i -= i & -i {
+		c += f[i]
+	}
+	return c
+}
+
+// find returns the value of rank k, from 0.
+func (f fenwick) find(k int) int {
+	pos := 0
+	step := 1
+	for step*2 < len(f) {
+		step *= 2
+	}
+	for ; step > 0; step /= 2 {
+		if pos+step < len(f) && f[pos+step] <= k {
+			pos += step
+			k -= f[pos]
+		}
+	}
+	return pos
+}
@@ -0,0 +1,401 @@
+package regression
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Estimates a multiple linear regression model by least squares without
+// storing the observations, using Miller's updating QR algorithm
+// (Applied Statistics algorithm AS 274).
+//
+// Each observation updates the decomposition X'X = R'DR, with R unit upper
+// triangular and D diagonal, by a sequence of square-root free Givens
+// rotations. The memory used is O(k^2) for k coefficients whatever the number
+// of observations, so that models can be fit on streams of data and fits of
+// separate shards of the data can be merged with Append.
+// Coefficients and their covariance can be computed at any point.
+type Updating struct {
+	nvars     int       // number of coefficients, including the intercept
+	intercept bool      // whether coefficient 0 is the intercept
+	d         []float64 // diagonal of D, the row multipliers
+	r         []float64 // strict upper triangle of R, packed by rows
+	rhs       []float64 // scaled projections of y, Q'y
+	sserr     float64   // sum of squared residuals
+	n         int       // number of observations
+	ybar      float64   // mean of y values
+	syy       float64   // sum of squared deviations of y from ybar
+	sumYY     float64   // sum of squared y values
+}
+
+// NewUpdating returns an empty regression on k independent variables,
+// with or without an intercept.
+func NewUpdating(k int, intercept bool) *Updating {
+	nvars := k
+	if intercept {
+		nvars++
+	}
+	if nvars < 1 {
+		panic("regression: no coefficients to estimate")
+	}
+	u := &Updating{nvars: nvars, intercept: intercept}
+	u.d = make([]float64, nvars)
+	u.r = make([]float64, nvars*(nvars-1)/2)
+	u.rhs = make([]float64, nvars)
+	return u
+}
+
+// Adds the observation of the independent variables x and the dependent
+// variable y to the regression data set.
+func (u *Updating) Add(x []float64, y float64) {
+	k := u.nvars
+	if u.intercept {
+		k--
+	}
+	if len(x) != k {
+		panic("regression: wrong number of independent variables")
+	}
+	xi := make([]float64, 0, u.nvars)
+	if u.intercept {
+		xi = append(xi, 1)
+	}
+	xi = append(xi, x...)
+	u.include(xi, 1, y)
+
+	u.n++
+	dy := y - u.ybar
+	u.ybar += dy / float64(u.n)
+	u.syy += dy * (y - u.ybar)
+	u.sumYY += y * y
+}
+
+// Adds the observations in the rows of x with the dependent variables y.
+func (u *Updating) AddBatch(x [][]float64, y []float64) {
+	if len(x) != len(y) {
+		panic("regression: x and y have different numbers of observations")
+	}
+	for i, row := range x {
+		u.Add(row, y[i])
+	}
+}
+
+// include rotates the row x with weight w and response y into the
+// decomposition. x is overwritten.
+func (u *Updating) include(x []float64, w, y float64) {
+	nextr := 0
+	for i := 0; i < u.nvars; i++ {
+		if w == 0 {
+			return
+		}
+		xi := x[i]
+		if xi == 0 {
+			nextr += u.nvars - i - 1
+			continue
+		}
+		di := u.d[i]
+		wxi := w * xi
+		w0 := w
+		var dpi float64
+		if di != 0 {
+			dpi = di + wxi*xi
+			w = di * w / dpi
+		} else {
+			dpi = wxi * xi
+			w = 0
+		}
+		u.d[i] = dpi
+		for k := i + 1; k < u.nvars; k++ {
+			xk := x[k]
+			x[k] = xk - xi*u.r[nextr]
+			if di != 0 {
+				u.r[nextr] = (di*u.r[nextr] + w0*xi*xk) / dpi
+			} else {
+				u.r[nextr] = xk / xi
+			}
+			nextr++
+		}
+		yk := y
+		y = yk - xi*u.rhs[i]
+		if di != 0 {
+			u.rhs[i] = (di*u.rhs[i] + wxi*yk) / dpi
+		} else {
+			u.rhs[i] = yk / xi
+		}
+	}
+	u.sserr += w * y * y
+}
+
+// Appends the data of another regression on the same variables to this one.
+// The rows of the other decomposition are rotated in as weighted
+// observations, which gives the fit of the union of both data sets.
+func (u *Updating) Append(v *Updating) {
+	if v.nvars != u.nvars || v.intercept != u.intercept {
+		panic("regression: appending a regression on different variables")
+	}
+	if v.n == 0 {
+		return
+	}
+	x := make([]float64, u.nvars)
+	for i := 0; i < v.nvars; i++ {
+		if v.d[i] == 0 {
+			continue
+		}
+		for j := range x {
+			x[j] = 0
+		}
+		x[i] = 1
+		copy(x[i+1:], v.r[v.rowStart(i):v.rowStart(i+1)])
+		u.include(x, v.d[i], v.rhs[i])
+	}
+	u.sserr += v.sserr
+
+	n := u.n + v.n
+	dy := v.ybar - u.ybar
+	u.syy += v.syy + dy*dy*float64(u.n)*float64(v.n)/float64(n)
+	u.ybar += dy * float64(v.n) / float64(n)
+	u.sumYY += v.sumYY
+	u.n = n
+}
+
+// rowStart returns the index in r of the first element of row i.
+func (u *Updating) rowStart(i int) int {
+	return i * (2*u.nvars - i - 1) / 2
+}
+
+// Clears all data from the model.
+func (u *Updating) Clear() {
+	k := u.nvars
+	if u.intercept {
+		k--
+	}
+	*u = *NewUpdating(k, u.intercept)
+}
+
+// Returns the number of observations.
+func (u *Updating) N() int {
+	return u.n
+}
+
+// Returns the number of coefficients, including the intercept.
+func (u *Updating) NumCoefficients() int {
+	return u.nvars
+}
+
+// Returns true if the model has an intercept.
+func (u *Updating) HasIntercept() bool {
+	return u.intercept
+}
+
+// checkRank returns an Error if there are too few observations or
+// a column of the design is a linear combination of the previous ones,
+// with the tolerances of AS 274's TOLSET.
+func (u *Updating) checkRank() error {
+	if u.n <= u.nvars {
+		return Error{Message: "regression: not enough observations for the number of coefficients"}
+	}
+	const eps = 1e-12
+	work := make([]float64, u.nvars)
+	for i, d := range u.d {
+		work[i] = math.Sqrt(d)
+	}
+	for col := 0; col < u.nvars; col++ {
+		total := work[col]
+		pos := col - 1
+		for row := 0; row < col; row++ {
+			total += math.Abs(u.r[pos]) * work[row]
+			pos += u.nvars - row - 2
+		}
+		if work[col] <= eps*total || u.d[col] == 0 {
+			return Error{Message: "regression: design matrix is rank deficient"}
+		}
+	}
+	return nil
+}
+
+// Returns the estimated coefficients, starting with the intercept if any.
+// An Error is returned if they are not identified by the data.
+func (u *Updating) Coefficients() ([]float64, error) {
+	if err := u.checkRank(); err != nil {
+		return nil, err
+	}
+	b := make([]float64, u.nvars)
+	for i := u.nvars - 1; i >= 0; i-- {
+		b[i] = u.rhs[i]
+		nextr := u.rowStart(i)
+		for j := i + 1; j < u.nvars; j++ {
+			b[i] -= u.r[nextr] * b[j]
+			nextr++
+		}
+	}
+	return b, nil
+}
+
+// Returns the estimated covariance matrix of the coefficients,
+// MSE * (X'X)^-1, or an Error if they are not identified by the data.
+func (u *Updating) Covariance() ([][]float64, error) {
+	if err := u.checkRank(); err != nil {
+		return nil, err
+	}
+	p := u.nvars
+	// inverse of the unit upper triangular R
+	ri := make([][]float64, p)
+	for i := range ri {
+		ri[i] = make([]float64, p)
+	}
+	for j := 0; j < p; j++ {
+		ri[j][j] = 1
+		for i := j - 1; i >= 0; i-- {
+			s := 0.0
+			start := u.rowStart(i)
+			for k := i + 1; k <= j; k++ {
+				s += u.r[start+k-i-1] * ri[k][j]
+			}
+			ri[i][j] = -s
+		}
+	}
+	mse := u.MeanSquareError()
+	c := make([][]float64, p)
+	for i := range c {
+		c[i] = make([]float64, p)
+	}
+	for i := 0; i < p; i++ {
+		for j := 0; j <= i; j++ {
+			s := 0.0
+			for k := i; k < p; k++ {
+				s += ri[i][k] * ri[j][k] / u.d[k]
+			}
+			c[i][j] = mse * s
+			c[j][i] = mse * s
+		}
+	}
+	return c, nil
+}
+
+// Returns the standard errors of the coefficients, or an Error if they are
+// not identified by the data.
+func (u *Updating) StdErrors() ([]float64, error) {
+	c, err := u.Covariance()
+	if err != nil {
+		return nil, err
+	}
+	se := make([]float64, u.nvars)
+	for i := range se {
+		se[i] = math.Sqrt(c[i][i])
+	}
+	return se, nil
+}
+
+// Returns the sum of squared residuals.
+func (u *Updating) SumSquaredErrors() float64 {
+	return u.sserr
+}
+
+// Returns the total sum of squares of y, about its mean if the model has an
+// intercept and about zero otherwise.
+func (u *Updating) TotalSumSquares() float64 {
+	if u.intercept {
+		return u.syy
+	}
+	return u.sumYY
+}
+
+// Returns the sum of squares explained by the regression.
+func (u *Updating) RegressionSumSquares() float64 {
+	return u.TotalSumSquares() - u.sserr
+}
+
+// Returns the unbiased estimate of the error variance, SSE / (n - p).
+func (u *Updating) MeanSquareError() float64 {
+	return u.sserr / float64(u.n-u.nvars)
+}
+
+// Returns the coefficient of determination, 1 - SSE/SST.
+func (u *Updating) RSquare() float64 {
+	return 1 - u.sserr/u.TotalSumSquares()
+}
+
+// The binary encoding of an Updating regression: a header of the magic
+// number, the format version, the number of coefficients, the intercept flag
+// and the number of observations, followed by the sums of squares and the
+// arrays d, rhs and r, all little endian with floats as IEEE 754 bits.
+const (
+	updatingMagic   = "AS274"
+	updatingVersion = 1
+	updatingHeader  = 5 + 1 + 4 + 1 + 8 // magic, version, nvars, intercept, n
+)
+
+// MarshalBinary encodes the state of the regression, so that it can be
+// checkpointed and restored with UnmarshalBinary.
+func (u *Updating) MarshalBinary() ([]byte, error) {
+	floats := 4 + len(u.d) + len(u.rhs) + len(u.r)
+	b := make([]byte, updatingHeader, updatingHeader+8*floats)
+	copy(b, updatingMagic)
+	b[5] = updatingVersion
+	binary.LittleEndian.PutUint32(b[6:], uint32(u.nvars))
+	if u.intercept {
+		b[10] = 1
+	}
+	binary.LittleEndian.PutUint64(b[11:], uint64(u.n))
+	put := func(v float64) {
+		b = binary.LittleEndian.AppendUint64(b, math.Float64bits(v))
+	}
+	for _, v := range []float64{u.sserr, u.ybar, u.syy, u.sumYY} {
+		put(v)
+	}
+	for _, s := range [][]float64{u.d, u.rhs, u.r} {
+		for _, v := range s {
+			put(v)
+		}
+	}
+	return b, nil
+}
+
+// UnmarshalBinary restores the regression from data encoded by
+// MarshalBinary. It returns an error, and leaves the regression unchanged,
+// if data is not a complete encoding of a valid state.
+func (u *Updating) UnmarshalBinary(data []byte) error {
+	if len(data) < updatingHeader || string(data[:5]) != updatingMagic {
+		return Error{Message: "regression: data is not an encoded updating regression"}
+	}
+	if data[5] != updatingVersion {
+		return Error{Message: fmt.Sprintf("regression: unsupported encoding version %d", data[5])}
+	}
+	nvars := int64(binary.LittleEndian.Uint32(data[6:]))
+	intercept := data[10] == 1
+	n := int64(binary.LittleEndian.Uint64(data[11:]))
+	if data[10] > 1 || nvars < 1 || n < 0 {
+		return Error{Message: "regression: invalid encoded header"}
+	}
+	// bound nvars by the payload before computing sizes of order nvars^2
+	words := int64(len(data)-updatingHeader) / 8
+	if nvars > words || (nvars-1)/2 > words/nvars {
+		return Error{Message: "regression: encoded data is truncated"}
+	}
+	payload := int64(len(data) - updatingHeader)
+	floats := 4 + 2*nvars + nvars*(nvars-1)/2
+	if payload != 8*floats {
+		return Error{Message: "regression: encoded data has the wrong length"}
+	}
+
+	k := int(nvars)
+	if intercept {
+		k--
+	}
+	v := NewUpdating(k, intercept)
+	v.n = int(n)
+	data = data[updatingHeader:]
+	next := func() float64 {
+		f := math.Float64frombits(binary.LittleEndian.Uint64(data))
+		data = data[8:]
+		return f
+	}
+	v.sserr, v.ybar, v.syy, v.sumYY = next(), next(), next(), next()
+	for _, s := range [][]float64{v.d, v.rhs, v.r} {
+		for i := range s {
+			s[i] = next()
+		}
+	}
+	*u = *v
+	return nil
+}
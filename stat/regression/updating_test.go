@@ -0,0 +1,145 @@
+package regression
+
+import (
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestUpdatingLongley(t *testing.T) {
+	x, y := splitXY(longleyData)
+	o, err := NewOLS(x, y, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := NewUpdating(6, true)
+	u.AddBatch(x, y)
+	compareUpdating(t, u, o, 1e-8)
+}
+
+func TestUpdatingAppend(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	n, k := 1000, 3
+	x := make([][]float64, n)
+	y := make([]float64, n)
+	for i := range x {
+		x[i] = []float64{rng.NormFloat64(), 10 * rng.Float64(), rng.ExpFloat64()}
+		y[i] = 1 + 2*x[i][0] - 0.5*x[i][1] + 3*x[i][2] + rng.NormFloat64()
+	}
+	for _, intercept := range []bool{true, false} {
+		o, err := NewOLS(x, y, intercept)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// fit shards separately and merge them
+		total := NewUpdating(k, intercept)
+		for start := 0; start < n; start += 300 {
+			end := start + 300
+			if end > n {
+				end = n
+			}
+			shard := NewUpdating(k, intercept)
+			shard.AddBatch(x[start:end], y[start:end])
+			total.Append(shard)
+		}
+		total.Append(NewUpdating(k, intercept))
+		compareUpdating(t, total, o, 1e-10)
+
+		// checkpoint and restore
+		data, err := total.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		restored := &Updating{}
+		if err := restored.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		compareUpdating(t, restored, o, 1e-10)
+		// corrupt encodings are rejected and leave the regression unchanged
+		for name, bad := range map[string][]byte{
+			"empty":     nil,
+			"magic":     append([]byte("XS274"), data[5:]...),
+			"version":   append(append(append([]byte(nil), data[:5]...), 2), data[6:]...),
+			"truncated": data[:len(data)-8],
+			"trailing":  append(append([]byte(nil), data...), 0),
+		} {
+			if err := restored.UnmarshalBinary(bad); err == nil {
+				t.Errorf("%s encoding was accepted", name)
+			}
+		}
+		huge := append([]byte(nil), data...)
+		binary.LittleEndian.PutUint32(huge[6:], 1<<31)
+		if err := restored.UnmarshalBinary(huge); err == nil {
+			t.Error("encoding with a huge number of coefficients was accepted")
+		}
+		negative := append([]byte(nil), data...)
+		binary.LittleEndian.PutUint64(negative[11:], uint64(1)<<63)
+		if err := restored.UnmarshalBinary(negative); err == nil {
+			t.Error("encoding with a negative number of observations was accepted")
+		}
+		compareUpdating(t, restored, o, 1e-10)
+
+		total.Clear()
+		if total.N() != 0 || total.NumCoefficients() != o.NumCoefficients() {
+			t.Errorf("cleared regression has %d observations and %d coefficients", total.N(), total.NumCoefficients())
+		}
+	}
+}
+
+func TestUpdatingRankDeficient(t *testing.T) {
+	u := NewUpdating(2, true)
+	for i := 0; i < 10; i++ {
+		u.Add([]float64{float64(i), 2 * float64(i)}, float64(i*i))
+	}
+	if _, err := u.Coefficients(); err == nil {
+		t.Error("expected an error for collinear columns")
+	}
+	v := NewUpdating(1, false)
+	v.Add([]float64{1}, 1)
+	if _, err := v.Coefficients(); err == nil {
+		t.Error("expected an error for too few observations")
+	}
+}
+
+func compareUpdating(t *testing.T, u *Updating, o *OLS, tol float64) {
+	if u.N() != o.N() {
+		t.Errorf("%d observations, expected %d", u.N(), o.N())
+	}
+	b, err := u.Coefficients()
+	if err != nil {
+		t.Fatal(err)
+	}
+	se, err := u.StdErrors()
+	if err != nil {
+		t.Fatal(err)
+	}
+	eb, ese := o.Coefficients(), o.StdErrors()
+	for i := range eb {
+		if relErr(b[i], eb[i]) > tol {
+			t.Errorf("coefficient %d: %g, expected %g", i, b[i], eb[i])
+		}
+		if relErr(se[i], ese[i]) > tol {
+			t.Errorf("standard error %d: %g, expected %g", i, se[i], ese[i])
+		}
+	}
+	cov, _ := u.Covariance()
+	ecov := o.Covariance()
+	for i := range cov {
+		for j := range cov[i] {
+			if math.Abs(cov[i][j]-ecov[i][j]) > tol*math.Sqrt(ecov[i][i]*ecov[j][j]) {
+				t.Errorf("covariance %d, %d: %g, expected %g", i, j, cov[i][j], ecov[i][j])
+			}
+		}
+	}
+	if relErr(u.SumSquaredErrors(), o.SumSquaredErrors()) > tol {
+		t.Errorf("sum of squared errors %g, expected %g", u.SumSquaredErrors(), o.SumSquaredErrors())
+	}
+	if relErr(u.TotalSumSquares(), o.TotalSumSquares()) > tol {
+		t.Errorf("total sum of squares %g, expected %g", u.TotalSumSquares(), o.TotalSumSquares())
+	}
+	if math.Abs(u.RSquare()-o.RSquare()) > tol {
+		t.Errorf("r-square %g, expected %g", u.RSquare(), o.RSquare())
+	}
+}
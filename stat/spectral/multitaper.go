@@ -0,0 +1,240 @@
+package spectral
+
+import (
+	"github.com/mingzhi/gomath/stat/correlation"
+	"math"
+)
+
+// Multitaper returns Thomson's multitaper estimate of the spectral density
+// of x sampled at frequency fs. The demeaned series is tapered by the first
+// k discrete prolate spheroidal sequences with time-halfbandwidth product nw,
+// and the k eigenspectra are averaged with their concentrations as weights.
+// The estimate has 2k degrees of freedom. k is usually at most 2*nw-1.
+func Multitaper(x []float64, fs, nw float64, k int, alpha float64) *Spectrum {
+	n := len(x)
+	if n < 2 {
+		panic("spectral: at least two observations are needed")
+	}
+	tapers, concentrations := DPSS(n, nw, k)
+	var total float64
+	for _, c := range concentrations {
+		total += c
+	}
+
+	e := newEstimator(n, fs)
+	for i, w := range tapers {
+		e.add(x, w, concentrations[i]/total)
+	}
+	return e.spectrum(2*float64(k), alpha)
+}
+
+// DPSS returns the first k discrete prolate spheroidal (Slepian) sequences
+// of length n with time-halfbandwidth product nw, normalized to unit energy,
+// together with their concentrations, the fraction of their energy within
+// the band |f| < nw/n.
+//
+// The sequences are the eigenvectors of the largest eigenvalues of a
+// symmetric tridiagonal matrix (Percival and Walden, 1993, section 8.3),
+// found by bisection and inverse iteration in O(k*n) time.
+// As in SciPy, even sequences have a positive sum and odd sequences
+// start with a positive lobe.
+func DPSS(n int, nw float64, k int) (tapers [][]float64, concentrations []float64) {
+	if k < 1 || k > n {
+		panic("spectral: number of tapers must be in [1, n]")
+	}
+	w := nw / float64(n)
+	if w <= 0 || w >= 0.5 {
+		panic("spectral: nw/n must be in (0, 0.5)")
+	}
+
+	diag := make([]float64, n)
+	off := make([]float64, n) // off[i] couples i-1 and i
+	cos := math.Cos(2 * math.Pi * w)
+	for i := 0; i < n; i++ {
+		h := (float64(n-1) - 2*float64(i)) / 2
+		diag[i] = h * h * cos
+		if i > 0 {
+			off[i] = float64(i) * float64(n-i) / 2
+		}
+	}
+
+	tapers = make([][]float64, k)
+	concentrations = make([]float64, k)
+	for j := 0; j < k; j++ {
+		lambda := tridiagonalEigenvalue(diag, off, n-1-j)
+		v := inverseIteration(diag, off, lambda, tapers[:j])
+
+		// sign convention
+		if j%2 == 0 {
+			sum := 0.0
+			for _, t := range v {
+				sum += t
+			}
+			if sum < 0 {
+				scale(v, -1)
+			}
+		} else {
+			thresh := math.Max(1e-7, 1/float64(n))
+			for _, t := range v {
+				if t*t > thresh {
+					if t < 0 {
+						scale(v, -1)
+					}
+					break
+				}
+			}
+		}
+		tapers[j] = v
+
+		// concentration v' A v with A[m][l] = sin(2*pi*w*(m-l)) / (pi*(m-l))
+		r := correlation.AutoCorrFFT(v, false)
+		c := sinc2(w, 0) * r[0]
+		for l := 1; l < n; l++ {
+			c += 2 * sinc2(w, l) * r[l]
+		}
+		concentrations[j] = c
+	}
+	return
+}
+
+// sinc2 returns sin(2*pi*w*l) / (pi*l), with the limit 2w at l = 0.
+func sinc2(w float64, l int) float64 {
+	if l == 0 {
+		return 2 * w
+	}
+	return math.Sin(2*math.Pi*w*float64(l)) / (math.Pi * float64(l))
+}
+
+// tridiagonalEigenvalue returns the eigenvalue of index idx, in increasing
+// order, of the symmetric tridiagonal matrix with the given diagonal and
+// off-diagonal, by bisection on Sturm sequence counts.
+func tridiagonalEigenvalue(diag, off []float64, idx int) float64 {
+	// Gershgorin bounds
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for i, d := range diag {
+		r := math.Abs(off[i])
+		if i+1 < len(diag) {
+			r += math.Abs(off[i+1])
+		}
+		lo = math.Min(lo, d-r)
+		hi = math.Max(hi, d+r)
+	}
+	for i := 0; i < 200; i++ {
+		mid := lo + (hi-lo)/2
+		if mid == lo || mid == hi {
+			break
+		}
+		if sturmCount(diag, off, mid) > idx {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	return lo + (hi-lo)/2
+}
+
+// sturmCount returns the number of eigenvalues smaller than x.
+func sturmCount(diag, off []float64, x float64) int {
+	count := 0
+	q := 1.0
+	for i, d := range diag {
+		if i == 0 {
+			q = d - x
+		} else {
+			q = d - x - off[i]*off[i]/q
+		}
+		if q == 0 {
+			q = 1e-300
+		}
+		if q < 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// inverseIteration returns the unit eigenvector of the tridiagonal matrix
+// for the eigenvalue lambda, orthogonal to the vectors in prev.
+func inverseIteration(diag, off []float64, lambda float64, prev [][]float64) []float64 {
+	n := len(diag)
+	v := make([]float64, n)
+	for i := range v {
+		// a start vector that is not orthogonal to any eigenvector
+		v[i] = 1 + 0.1*math.Sin(float64(i+1))
+	}
+	dl := make([]float64, n-1)
+	d := make([]float64, n)
+	du := make([]float64, n-1)
+	for iter := 0; iter < 4; iter++ {
+		for i := 0; i < n; i++ {
+			d[i] = diag[i] - lambda
+			if i > 0 {
+				dl[i-1] = off[i]
+				du[i-1] = off[i]
+			}
+		}
+		solveTridiagonal(dl, d, du, v)
+		for _, p := range prev {
+			dot := 0.0
+			for i := range v {
+				dot += v[i] * p[i]
+			}
+			for i := range v {
+				v[i] -= dot * p[i]
+			}
+		}
+		norm := 0.0
+		for _, t := range v {
+			norm += t * t
+		}
+		scale(v, 1/math.Sqrt(norm))
+	}
+	return v
+}
+
+// solveTridiagonal solves the tridiagonal system with sub-diagonal dl,
+// diagonal d and super-diagonal du in place of b, by Gaussian elimination
+// with partial pivoting (LAPACK dgtsv). dl, d and du are overwritten.
+// Zero pivots are replaced by a tiny value, as wanted by inverse iteration.
+func solveTridiagonal(dl, d, du, b []float64) {
+	n := len(d)
+	pivot := func(i int) {
+		if d[i] == 0 {
+			d[i] = 1e-300
+		}
+	}
+	for i := 0; i < n-1; i++ {
+		if math.Abs(d[i]) >= math.Abs(dl[i]) {
+			pivot(i)
+			fact := dl[i] / d[i]
+			d[i+1] -= fact * du[i]
+			b[i+1] -= fact * b[i]
+			dl[i] = 0
+		} else {
+			fact := d[i] / dl[i]
+			d[i] = dl[i]
+			temp := d[i+1]
+			d[i+1] = du[i] - fact*temp
+			if i < n-2 {
+				dl[i] = du[i+1]
+				du[i+1] = -fact * dl[i]
+			}
+			du[i] = temp
+			b[i], b[i+1] = b[i+1], b[i]-fact*b[i+1]
+		}
+	}
+	pivot(n - 1)
+	b[n-1] /= d[n-1]
+	if n > 1 {
+		b[n-2] = (b[n-2] - du[n-2]*b[n-1]) / d[n-2]
+	}
+	for i := n - 3; i >= 0; i-- {
+		b[i] = (b[i] - du[i]*b[i+1] - dl[i]*b[i+2]) / d[i]
+	}
+}
+
+func scale(v []float64, f float64) {
+	for i := range v {
+		v[i] *= f
+	}
+}
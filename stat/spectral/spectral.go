@@ -0,0 +1,160 @@
+// Spectral density estimation of real time series:
+// raw and windowed periodograms, Welch's averaged periodogram and
+// Thomson's multitaper estimate, with chi-squared confidence intervals.
+//
+// All the estimates are one-sided power spectral densities: integrating
+// Power over the frequencies 0..fs/2 gives the variance of the series.
+package spectral
+
+import (
+	"github.com/mingzhi/gomath/fft"
+	"github.com/mingzhi/gomath/specfunc"
+)
+
+// A Spectrum is an estimated one-sided power spectral density.
+type Spectrum struct {
+	Freq  []float64 // frequencies from 0 to fs/2
+	Power []float64 // power spectral density at Freq
+	Lower []float64 // lower confidence limits of Power
+	Upper []float64 // upper confidence limits of Power
+	DF    float64   // equivalent degrees of freedom for 0 < Freq < fs/2
+}
+
+// Periodogram returns the periodogram of x sampled at frequency fs,
+// tapered by window (Rectangular for the raw periodogram) after removing
+// the mean, with 1-alpha confidence intervals.
+// Each estimate has 2 degrees of freedom (1 at 0 and fs/2).
+func Periodogram(x []float64, fs float64, window Window, alpha float64) *Spectrum {
+	n := len(x)
+	if n < 2 {
+		panic("spectral: at least two observations are needed")
+	}
+	w := window(n)
+	e := newEstimator(n, fs)
+	e.add(x, w, 1)
+	return e.spectrum(2, alpha)
+}
+
+// Welch returns Welch's averaged periodogram of x sampled at frequency fs.
+// x is split into segments of the given length that overlap by the given
+// number of samples; each segment is demeaned and tapered by window,
+// and the periodograms of the segments are averaged.
+// The degrees of freedom account for the correlation of overlapping
+// segments (Percival and Walden, 1993, eq. 292b).
+// Samples after the last full segment are ignored.
+func Welch(x []float64, fs float64, segment, overlap int, window Window, alpha float64) *Spectrum {
+	n := len(x)
+	if segment < 2 || segment > n {
+		panic("spectral: segment length must be in [2, len(x)]")
+	}
+	if overlap < 0 || overlap >= segment {
+		panic("spectral: overlap must be in [0, segment)")
+	}
+	step := segment - overlap
+	k := (n-segment)/step + 1
+
+	w := window(segment)
+	e := newEstimator(segment, fs)
+	for i := 0; i < k; i++ {
+		e.add(x[i*step:i*step+segment], w, 1/float64(k))
+	}
+
+	// equivalent degrees of freedom of the average of k correlated periodograms
+	var ww float64
+	for _, v := range w {
+		ww += v * v
+	}
+	sum := 0.0
+	for m := 1; m < k && m*step < segment; m++ {
+		rho := 0.0
+		for t := 0; t+m*step < segment; t++ {
+			rho += w[t] * w[t+m*step]
+		}
+		rho /= ww
+		sum += (1 - float64(m)/float64(k)) * rho * rho
+	}
+	df := 2 * float64(k) / (1 + 2*sum)
+
+	return e.spectrum(df, alpha)
+}
+
+// estimator accumulates weighted periodograms of equal length.
+type estimator struct {
+	n     int
+	fs    float64
+	plan  *fft.RealPlan
+	data  []float64
+	spec  []complex128
+	power []float64
+}
+
+func newEstimator(n int, fs float64) *estimator {
+	e := &estimator{n: n, fs: fs}
+	e.plan = fft.NewRealPlan(n)
+	e.data = make([]float64, n)
+	e.spec = make([]complex128, e.plan.SpectrumLen())
+	e.power = make([]float64, e.plan.SpectrumLen())
+	return e
+}
+
+// add adds weight times the one-sided periodogram of x demeaned and
+// tapered by w, normalized by the energy of w.
+func (e *estimator) add(x, w []float64, weight float64) {
+	mean := 0.0
+	for _, v := range x {
+		mean += v
+	}
+	mean /= float64(len(x))
+	var ww float64
+	for i, v := range x {
+		e.data[i] = (v - mean) * w[i]
+		ww += w[i] * w[i]
+	}
+	e.plan.Forward(e.spec, e.data)
+
+	scale := weight / (e.fs * ww)
+	for k, v := range e.spec {
+		p := (real(v)*real(v) + imag(v)*imag(v)) * scale
+		if k > 0 && !(e.n%2 == 0 && k == e.n/2) {
+			p *= 2
+		}
+		e.power[k] += p
+	}
+}
+
+// spectrum returns the accumulated estimate with df degrees of freedom
+// at the inner frequencies and df/2 at 0 and, for even n, at fs/2.
+func (e *estimator) spectrum(df, alpha float64) *Spectrum {
+	m := len(e.power)
+	s := &Spectrum{
+		Freq:  make([]float64, m),
+		Power: e.power,
+		Lower: make([]float64, m),
+		Upper: make([]float64, m),
+		DF:    df,
+	}
+	inner := newChiSquaredInterval(df, alpha)
+	edge := newChiSquaredInterval(df/2, alpha)
+	for k := range s.Freq {
+		s.Freq[k] = float64(k) * e.fs / float64(e.n)
+		ci := inner
+		if k == 0 || (e.n%2 == 0 && k == e.n/2) {
+			ci = edge
+		}
+		s.Lower[k] = s.Power[k] * ci.lower
+		s.Upper[k] = s.Power[k] * ci.upper
+	}
+	return s
+}
+
+// chiSquaredInterval holds the factors of the confidence interval
+// [df*P/chi2(1-alpha/2), df*P/chi2(alpha/2)] of a scaled chi-squared estimate.
+type chiSquaredInterval struct {
+	lower, upper float64
+}
+
+func newChiSquaredInterval(df, alpha float64) chiSquaredInterval {
+	hi := 2 * specfunc.InvRegularizedGammaQ(df/2, alpha/2)
+	lo := 2 * specfunc.InvRegularizedGammaP(df/2, alpha/2)
+	return chiSquaredInterval{lower: df / hi, upper: df / lo}
+}
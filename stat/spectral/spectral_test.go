@@ -0,0 +1,149 @@
+package spectral
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func whiteNoise(n int, sigma float64, seed int64) []float64 {
+	rng := rand.New(rand.NewSource(seed))
+	x := make([]float64, n)
+	for i := range x {
+		x[i] = sigma * rng.NormFloat64()
+	}
+	return x
+}
+
+func mean(x []float64) float64 {
+	s := 0.0
+	for _, v := range x {
+		s += v
+	}
+	return s / float64(len(x))
+}
+
+func TestPeriodogramParseval(t *testing.T) {
+	for _, n := range []int{100, 101} {
+		x := whiteNoise(n, 2, 1)
+		fs := 10.0
+		s := Periodogram(x, fs, Rectangular, 0.05)
+		if len(s.Freq) != n/2+1 || s.Freq[len(s.Freq)-1] > fs/2 {
+			t.Errorf("n=%d: %d frequencies up to %g", n, len(s.Freq), s.Freq[len(s.Freq)-1])
+		}
+
+		// the integral of the density is the (biased) variance
+		m := mean(x)
+		variance := 0.0
+		for _, v := range x {
+			variance += (v - m) * (v - m)
+		}
+		variance /= float64(n)
+		integral := 0.0
+		for _, p := range s.Power {
+			integral += p * fs / float64(n)
+		}
+		if math.Abs(integral-variance) > 1e-10 {
+			t.Errorf("n=%d: integral of the periodogram %g, variance %g", n, integral, variance)
+		}
+		for k := range s.Power {
+			if !(s.Lower[k] <= s.Power[k] && s.Power[k] <= s.Upper[k]) {
+				t.Errorf("n=%d: power %g outside its interval [%g, %g]", n, s.Power[k], s.Lower[k], s.Upper[k])
+			}
+		}
+	}
+}
+
+func TestWelchWhiteNoise(t *testing.T) {
+	sigma, fs := 1.5, 4.0
+	x := whiteNoise(1<<14, sigma, 2)
+	level := 2 * sigma * sigma / fs
+	for _, window := range []Window{Hann, Hamming, Blackman} {
+		s := Welch(x, fs, 256, 128, window, 0.05)
+		if math.Abs(mean(s.Power)/level-1) > 0.05 {
+			t.Errorf("mean Welch density %g, expected %g", mean(s.Power), level)
+		}
+		// 50% overlapping Hann windows: 36k^2/(19k-1) degrees of freedom
+		k := 127.0
+		if window(4)[0] == 0 && math.Abs(s.DF-36*k*k/(19*k-1)) > 1 {
+			t.Errorf("Welch degrees of freedom %g, expected %g", s.DF, 36*k*k/(19*k-1))
+		}
+		covered := 0
+		for i := range s.Power {
+			if s.Lower[i] <= level && level <= s.Upper[i] {
+				covered++
+			}
+		}
+		if f := float64(covered) / float64(len(s.Power)); f < 0.85 {
+			t.Errorf("confidence intervals cover the true density at %g of the frequencies", f)
+		}
+	}
+}
+
+func TestSinusoidPeak(t *testing.T) {
+	fs, f0 := 100.0, 12.5
+	x := whiteNoise(1000, 0.5, 3)
+	for i := range x {
+		x[i] += math.Sin(2 * math.Pi * f0 * float64(i) / fs)
+	}
+	for _, s := range []*Spectrum{
+		Periodogram(x, fs, Hann, 0.05),
+		Welch(x, fs, 200, 100, Hann, 0.05),
+		Multitaper(x, fs, 4, 7, 0.05),
+	} {
+		best := 0
+		for k := range s.Power {
+			if s.Power[k] > s.Power[best] {
+				best = k
+			}
+		}
+		if math.Abs(s.Freq[best]-f0) > 0.5 {
+			t.Errorf("peak at %g, expected %g", s.Freq[best], f0)
+		}
+	}
+}
+
+func TestDPSS(t *testing.T) {
+	n, nw, k := 128, 4.0, 7
+	tapers, concentrations := DPSS(n, nw, k)
+	for i := 0; i < k; i++ {
+		for j := 0; j <= i; j++ {
+			dot := 0.0
+			for l := 0; l < n; l++ {
+				dot += tapers[i][l] * tapers[j][l]
+			}
+			expected := 0.0
+			if i == j {
+				expected = 1
+			}
+			if math.Abs(dot-expected) > 1e-10 {
+				t.Errorf("taper %d . taper %d = %g, expected %g", i, j, dot, expected)
+			}
+		}
+		// even tapers are symmetric, odd ones antisymmetric
+		sign := 1.0
+		if i%2 == 1 {
+			sign = -1
+		}
+		for l := 0; l < n; l++ {
+			if math.Abs(tapers[i][l]-sign*tapers[i][n-1-l]) > 1e-10 {
+				t.Errorf("taper %d has the wrong symmetry at %d", i, l)
+				break
+			}
+		}
+		if concentrations[i] > 1 || (i > 0 && concentrations[i] > concentrations[i-1]) {
+			t.Errorf("concentrations are not decreasing below 1: %v", concentrations)
+		}
+	}
+	if concentrations[0] < 1-1e-9 || concentrations[k-1] < 0.9 {
+		t.Errorf("concentrations %v", concentrations)
+	}
+	if tapers[1][n/4] < 0 {
+		t.Error("the first lobe of odd tapers should be positive")
+	}
+
+	s := Multitaper(whiteNoise(n, 1, 4), 1, nw, k, 0.05)
+	if s.DF != 2*float64(k) {
+		t.Errorf("multitaper degrees of freedom %g, expected %d", s.DF, 2*k)
+	}
+}
@@ -0,0 +1,44 @@
+package spectral
+
+import (
+	"math"
+)
+
+// A Window returns the n weights of a data taper.
+type Window func(n int) []float64
+
+// Rectangular returns n weights equal to one, i.e. no tapering.
+func Rectangular(n int) []float64 {
+	w := make([]float64, n)
+	for i := range w {
+		w[i] = 1
+	}
+	return w
+}
+
+// Hann returns the periodic Hann window of length n,
+// 0.5 - 0.5*cos(2*pi*i/n).
+func Hann(n int) []float64 {
+	return cosineWindow(n, 0.5, 0.5, 0)
+}
+
+// Hamming returns the periodic Hamming window of length n,
+// 0.54 - 0.46*cos(2*pi*i/n).
+func Hamming(n int) []float64 {
+	return cosineWindow(n, 0.54, 0.46, 0)
+}
+
+// Blackman returns the periodic Blackman window of length n,
+// 0.42 - 0.5*cos(2*pi*i/n) + 0.08*cos(4*pi*i/n).
+func Blackman(n int) []float64 {
+	return cosineWindow(n, 0.42, 0.5, 0.08)
+}
+
+func cosineWindow(n int, a0, a1, a2 float64) []float64 {
+	w := make([]float64, n)
+	for i := range w {
+		t := 2 * math.Pi * float64(i) / float64(n)
+		w[i] = a0 - a1*math.Cos(t) + a2*math.Cos(2*t)
+	}
+	return w
+}